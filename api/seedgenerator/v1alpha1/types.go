@@ -45,6 +45,33 @@ type SeedGeneratorSpec struct {
 	SeedImage string `json:"seedImage,omitempty"`
 	//+operator-sdk:csv:customresourcedefinitions:type=spec,xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
 	RecertImage string `json:"recertImage,omitempty"`
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Signing"
+	Signing *SeedImageSigning `json:"signing,omitempty"`
+	// BaseSeedImage is a seed image to create a delta seed image from. The delta seed image only holds the changes
+	// from the base seed image, which must be present on the clusters upgraded with it: their booted deployment can
+	// provide the ostree objects, but /var and /etc are always rebuilt from the base seed image. Without the base seed
+	// image, Prep fails and the full seed image must be used
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Base Seed Image",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	BaseSeedImage string `json:"baseSeedImage,omitempty"`
+}
+
+// SeedImageSigning defines how the seed image is signed once it is pushed
+type SeedImageSigning struct {
+	// CosignKeyRef references a secret in the openshift-lifecycle-agent namespace holding the cosign private key
+	// used to create a sigstore signature of the seed image, under the cosign.key key. The passphrase of the
+	// private key, if any, is read from the cosign.password key
+	// +kubebuilder:validation:Required
+	// +required
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Cosign Key Reference"
+	CosignKeyRef SecretRef `json:"cosignKeyRef"`
+}
+
+// SecretRef defines a reference to a secret in the openshift-lifecycle-agent namespace
+type SecretRef struct {
+	// +kubebuilder:validation:Required
+	// +required
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	Name string `json:"name"`
 }
 
 // SeedGeneratorStatus defines the observed state of SeedGenerator
@@ -25,12 +25,27 @@ import (
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretRef) DeepCopyInto(out *SecretRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretRef.
+func (in *SecretRef) DeepCopy() *SecretRef {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SeedGenerator) DeepCopyInto(out *SeedGenerator) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	in.Status.DeepCopyInto(&out.Status)
 }
 
@@ -87,6 +102,11 @@ func (in *SeedGeneratorList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SeedGeneratorSpec) DeepCopyInto(out *SeedGeneratorSpec) {
 	*out = *in
+	if in.Signing != nil {
+		in, out := &in.Signing, &out.Signing
+		*out = new(SeedImageSigning)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SeedGeneratorSpec.
@@ -122,3 +142,19 @@ func (in *SeedGeneratorStatus) DeepCopy() *SeedGeneratorStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SeedImageSigning) DeepCopyInto(out *SeedImageSigning) {
+	*out = *in
+	out.CosignKeyRef = in.CosignKeyRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SeedImageSigning.
+func (in *SeedImageSigning) DeepCopy() *SeedImageSigning {
+	if in == nil {
+		return nil
+	}
+	out := new(SeedImageSigning)
+	in.DeepCopyInto(out)
+	return out
+}
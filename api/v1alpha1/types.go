@@ -29,9 +29,15 @@ import (
 // +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.conditions[-1:].reason"
 // +kubebuilder:printcolumn:name="Details",type="string",JSONPath=".status.conditions[-1:].message"
 // +kubebuilder:validation:XValidation:message="can not change spec.seedImageRef while ibu is in progress", rule="!has(oldSelf.status) || oldSelf.status.conditions.exists(c, c.type=='Idle' && c.status=='True') || has(oldSelf.spec.seedImageRef) && has(self.spec.seedImageRef) && oldSelf.spec.seedImageRef==self.spec.seedImageRef || !has(self.spec.seedImageRef) && !has(oldSelf.spec.seedImageRef)"
+// +kubebuilder:validation:XValidation:message="can not change spec.additionalImages while ibu is in progress", rule="!has(oldSelf.status) || oldSelf.status.conditions.exists(c, c.type=='Idle' && c.status=='True') || has(oldSelf.spec.additionalImages) && has(self.spec.additionalImages) && oldSelf.spec.additionalImages==self.spec.additionalImages || !has(self.spec.additionalImages) && !has(oldSelf.spec.additionalImages)"
 // +kubebuilder:validation:XValidation:message="can not change spec.oadpContent while ibu is in progress", rule="!has(oldSelf.status) || oldSelf.status.conditions.exists(c, c.type=='Idle' && c.status=='True') || has(oldSelf.spec.oadpContent) && has(self.spec.oadpContent) && oldSelf.spec.oadpContent==self.spec.oadpContent || !has(self.spec.oadpContent) && !has(oldSelf.spec.oadpContent)"
 // +kubebuilder:validation:XValidation:message="can not change spec.extraManifests while ibu is in progress", rule="!has(oldSelf.status) || oldSelf.status.conditions.exists(c, c.type=='Idle' && c.status=='True') || has(oldSelf.spec.extraManifests) && has(self.spec.extraManifests) && oldSelf.spec.extraManifests==self.spec.extraManifests || !has(self.spec.extraManifests) && !has(oldSelf.spec.extraManifests)"
 // +kubebuilder:validation:XValidation:message="can not change spec.autoRollbackOnFailure while ibu is in progress", rule="!has(oldSelf.status) || oldSelf.status.conditions.exists(c, c.type=='Idle' && c.status=='True') || has(oldSelf.spec.autoRollbackOnFailure) && has(self.spec.autoRollbackOnFailure) && oldSelf.spec.autoRollbackOnFailure==self.spec.autoRollbackOnFailure || !has(self.spec.autoRollbackOnFailure) && !has(oldSelf.spec.autoRollbackOnFailure)"
+// +kubebuilder:validation:XValidation:message="can not change spec.hooks while ibu is in progress", rule="!has(oldSelf.status) || oldSelf.status.conditions.exists(c, c.type=='Idle' && c.status=='True') || has(oldSelf.spec.hooks) && has(self.spec.hooks) && oldSelf.spec.hooks==self.spec.hooks || !has(self.spec.hooks) && !has(oldSelf.spec.hooks)"
+// +kubebuilder:validation:XValidation:message="can not change spec.healthChecks while ibu is in progress", rule="!has(oldSelf.status) || oldSelf.status.conditions.exists(c, c.type=='Idle' && c.status=='True') || has(oldSelf.spec.healthChecks) && has(self.spec.healthChecks) && oldSelf.spec.healthChecks==self.spec.healthChecks || !has(self.spec.healthChecks) && !has(oldSelf.spec.healthChecks)"
+// +kubebuilder:validation:XValidation:message="can not change spec.upgradeGraphRef while ibu is in progress", rule="!has(oldSelf.status) || oldSelf.status.conditions.exists(c, c.type=='Idle' && c.status=='True') || has(oldSelf.spec.upgradeGraphRef) && has(self.spec.upgradeGraphRef) && oldSelf.spec.upgradeGraphRef==self.spec.upgradeGraphRef || !has(self.spec.upgradeGraphRef) && !has(oldSelf.spec.upgradeGraphRef)"
+// +kubebuilder:validation:XValidation:message="can not change spec.operatorCompatibility while ibu is in progress", rule="!has(oldSelf.status) || oldSelf.status.conditions.exists(c, c.type=='Idle' && c.status=='True') || has(oldSelf.spec.operatorCompatibility) && has(self.spec.operatorCompatibility) && oldSelf.spec.operatorCompatibility==self.spec.operatorCompatibility || !has(self.spec.operatorCompatibility) && !has(oldSelf.spec.operatorCompatibility)"
+// +kubebuilder:validation:XValidation:message="can not change spec.notifications while ibu is in progress", rule="!has(oldSelf.status) || oldSelf.status.conditions.exists(c, c.type=='Idle' && c.status=='True') || has(oldSelf.spec.notifications) && has(self.spec.notifications) && oldSelf.spec.notifications==self.spec.notifications || !has(self.spec.notifications) && !has(oldSelf.spec.notifications)"
 // +operator-sdk:csv:customresourcedefinitions:displayName="Image-based Cluster Upgrade",resources={{Namespace, v1},{Deployment,apps/v1}}
 // ImageBasedUpgrade is the Schema for the ImageBasedUpgrades API
 type ImageBasedUpgrade struct {
@@ -71,8 +77,38 @@ type ImageBasedUpgradeSpec struct {
 	OADPContent []ConfigMapRef `json:"oadpContent,omitempty"`
 	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Extra Manifests"
 	ExtraManifests []ConfigMapRef `json:"extraManifests,omitempty"`
+	// UpgradeGraphRef references a config map holding an upgrade graph in Cincinnati format, under the graph.json
+	// key. The graph must have a path from the current version of the cluster to the seed version
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Upgrade Graph Reference"
+	UpgradeGraphRef *ConfigMapRef `json:"upgradeGraphRef,omitempty"`
+	// OperatorCompatibility is what Prep does when the OLM operators of the cluster don't match the operators
+	// recorded in the seed image: an operator missing from the seed image, installed in an older version, or from
+	// another channel. Fail fails Prep, Warn reports the differences in an event. Defaults to Warn
+	//+kubebuilder:validation:Enum=Fail;Warn
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Operator Compatibility"
+	OperatorCompatibility OperatorCompatibilityPolicy `json:"operatorCompatibility,omitempty"`
 	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Auto Rollback On Failure"
 	AutoRollbackOnFailure AutoRollbackOnFailure `json:"autoRollbackOnFailure,omitempty"`
+	// UpgradeGate holds the Upgrade stage once the backups are taken and exported to the new stateroot, before the
+	// node reboots to the new stateroot. Set it to Hold to pause the Upgrade stage, and to Release to reboot
+	//+kubebuilder:validation:Enum=Hold;Release
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Upgrade Gate"
+	UpgradeGate UpgradeGate `json:"upgradeGate,omitempty"`
+	// UpgradeWindow restricts the Upgrade stage to a maintenance window. The Upgrade stage waits for the window to
+	// open before starting, and the node only reboots to the new stateroot if enough of the window remains
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Upgrade Window"
+	UpgradeWindow *UpgradeWindow `json:"upgradeWindow,omitempty"`
+	// Hooks defines user Jobs run around the Prep stage, the pivot to the new stateroot and the rollback
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Hooks"
+	Hooks Hooks `json:"hooks,omitempty"`
+	// HealthChecks configures the health checks run once the node rebooted to the new stateroot, before the Upgrade
+	// stage completes
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Health Checks"
+	HealthChecks HealthChecks `json:"healthChecks,omitempty"`
+	// Notifications configures HTTP endpoints that are sent the status of the IBU when a stage starts, completes or
+	// fails, and when an automatic rollback is triggered
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Notifications"
+	Notifications *Notifications `json:"notifications,omitempty"`
 }
 
 // SeedImageRef defines the seed image and OCP version for the upgrade
@@ -83,6 +119,21 @@ type SeedImageRef struct {
 	Image string `json:"image,omitempty"`
 	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Pull Secret Reference"
 	PullSecretRef *PullSecretRef `json:"pullSecretRef,omitempty"`
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Verification"
+	Verification *SeedImageVerification `json:"verification,omitempty"`
+}
+
+// SeedImageVerification defines how the signature of the seed image is verified when it is pulled
+// +kubebuilder:validation:XValidation:rule="has(self.cosignKeyRef) != has(self.policyRef)",message="exactly one of cosignKeyRef or policyRef must be set"
+type SeedImageVerification struct {
+	// CosignKeyRef references a secret in the openshift-lifecycle-agent namespace holding the cosign public key
+	// used to verify the sigstore signature of the seed image, under the cosign.pub key
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Cosign Key Reference"
+	CosignKeyRef *SecretRef `json:"cosignKeyRef,omitempty"`
+	// PolicyRef references a config map holding a containers policy.json, under the policy.json key, that the
+	// seed image must be accepted by
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Policy Reference"
+	PolicyRef *ConfigMapRef `json:"policyRef,omitempty"`
 }
 
 type AutoRollbackOnFailure struct {
@@ -96,6 +147,217 @@ type AutoRollbackOnFailure struct {
 	InitMonitorTimeoutSeconds int `json:"initMonitorTimeoutSeconds,omitempty"` // LCA Init Monitor watchdog timeout, in seconds. Value <= 0 is treated as "use default" when writing config file in Prep stage
 }
 
+// UpgradeWindow defines the maintenance windows in which the node may reboot to the new stateroot
+// +kubebuilder:validation:XValidation:rule="has(self.start) || has(self.schedule)",message="at least one of start or schedule must be set"
+type UpgradeWindow struct {
+	// Start is when the window first opens. Without a schedule, it is the only window
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Start"
+	Start *metav1.Time `json:"start,omitempty"`
+	// Schedule is a cron expression of when the window opens again, e.g. "0 2 * * SAT" for every Saturday at 2:00
+	// UTC. The time zone can be set with a CRON_TZ= prefix
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	Schedule string `json:"schedule,omitempty"`
+	// Duration is how long the window stays open, e.g. 4h
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	Duration metav1.Duration `json:"duration"`
+	// ExpectedDuration is how long the upgrade is expected to take from the reboot to the new stateroot, e.g. 1h. The
+	// node doesn't reboot unless at least that much of the window remains
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	ExpectedDuration metav1.Duration `json:"expectedDuration,omitempty"`
+}
+
+// UpgradeGate defines whether the Upgrade stage may reboot to the new stateroot
+type UpgradeGate string
+
+// UpgradeGates defines the string values for valid upgrade gates
+var UpgradeGates = struct {
+	Hold    UpgradeGate
+	Release UpgradeGate
+}{
+	Hold:    "Hold",
+	Release: "Release",
+}
+
+// OperatorCompatibilityPolicy defines how Prep handles the differences between the operators of the cluster and
+// those of the seed image
+type OperatorCompatibilityPolicy string
+
+// OperatorCompatibilityPolicies defines the string values for valid operator compatibility policies
+var OperatorCompatibilityPolicies = struct {
+	Fail OperatorCompatibilityPolicy
+	Warn OperatorCompatibilityPolicy
+}{
+	Fail: "Fail",
+	Warn: "Warn",
+}
+
+// Hooks defines the Jobs run at fixed points of the upgrade
+type Hooks struct {
+	// PrePrep is run at the start of the Prep stage, before the seed image is pulled
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Pre Prep"
+	PrePrep *Hook `json:"prePrep,omitempty"`
+	// PostPrep is run at the end of the Prep stage, once the images are precached
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Post Prep"
+	PostPrep *Hook `json:"postPrep,omitempty"`
+	// PrePivot is run at the start of the Upgrade stage, before the backups are taken and the node reboots to the
+	// new stateroot
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Pre Pivot"
+	PrePivot *Hook `json:"prePivot,omitempty"`
+	// PostPivot is run at the end of the Upgrade stage, once the backups are restored in the new stateroot. Its Job
+	// template is saved to the new stateroot before the reboot
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Post Pivot"
+	PostPivot *Hook `json:"postPivot,omitempty"`
+	// PostRollback is run at the end of the Rollback stage, once the node rebooted to the original stateroot
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Post Rollback"
+	PostRollback *Hook `json:"postRollback,omitempty"`
+}
+
+// HealthChecks defines the checks the cluster must pass after the pivot, in addition to the built-in checks of the
+// ClusterOperators, ClusterVersion, MachineConfigPools, ClusterServiceVersions and Node
+type HealthChecks struct {
+	// Timeout is how long each check may take to pass, e.g. 30m. Defaults to 15m
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+	// Resources lists resources that must reach an expected state. The lifecycle-agent can get SriovNetworkNodeStates
+	// and PtpOperatorConfigs; for other kinds, get must be granted to its service account, or the check fails
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Resources"
+	Resources []ResourceHealthCheck `json:"resources,omitempty"`
+	// Workloads selects Deployments, StatefulSets and DaemonSets that must become ready
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Workloads"
+	Workloads []WorkloadHealthCheck `json:"workloads,omitempty"`
+	// PreUpgrade lists the checks run once when the Prep and Upgrade stages start. Unlike after the pivot, the checks
+	// don't wait for the cluster to become healthy, and the stage fails right away if one of them doesn't pass.
+	// Defaults to ClusterOperators, ClusterVersion, MachineConfigPools, ClusterServiceVersions and Nodes
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Pre Upgrade"
+	PreUpgrade []HealthCheckName `json:"preUpgrade,omitempty"`
+	// DisabledPreUpgrade skips the checks run when the Prep and Upgrade stages start
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,xDescriptors={"urn:alm:descriptor:com.tectonic.ui:booleanSwitch"}
+	DisabledPreUpgrade bool `json:"disabledPreUpgrade,omitempty"`
+}
+
+// HealthCheckName names a group of health checks
+// +kubebuilder:validation:Enum=ClusterOperators;ClusterVersion;MachineConfigPools;ClusterServiceVersions;Nodes;Resources;Workloads
+type HealthCheckName string
+
+// HealthCheckNames defines the string values for valid health check names. Resources and Workloads are the checks
+// listed in spec.healthChecks
+var HealthCheckNames = struct {
+	ClusterOperators       HealthCheckName
+	ClusterVersion         HealthCheckName
+	MachineConfigPools     HealthCheckName
+	ClusterServiceVersions HealthCheckName
+	Nodes                  HealthCheckName
+	Resources              HealthCheckName
+	Workloads              HealthCheckName
+}{
+	ClusterOperators:       "ClusterOperators",
+	ClusterVersion:         "ClusterVersion",
+	MachineConfigPools:     "MachineConfigPools",
+	ClusterServiceVersions: "ClusterServiceVersions",
+	Nodes:                  "Nodes",
+	Resources:              "Resources",
+	Workloads:              "Workloads",
+}
+
+// ResourceHealthCheck defines a resource that must have a True status condition, or a field with an expected value
+// +kubebuilder:validation:XValidation:rule="has(self.condition) != has(self.jsonPath)",message="exactly one of condition or jsonPath must be set"
+// +kubebuilder:validation:XValidation:rule="!has(self.value) || has(self.jsonPath)",message="value requires jsonPath"
+type ResourceHealthCheck struct {
+	// APIVersion is the group and version of the resource, e.g. sriovnetwork.openshift.io/v1
+	// +kubebuilder:validation:Required
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	APIVersion string `json:"apiVersion"`
+	// +kubebuilder:validation:Required
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	Kind string `json:"kind"`
+	// +kubebuilder:validation:Required
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	Name string `json:"name"`
+	// Namespace of the resource, empty for cluster-scoped resources
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	Namespace string `json:"namespace,omitempty"`
+	// Condition is the type of a status condition of the resource that must be True, e.g. Available
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	Condition string `json:"condition,omitempty"`
+	// JSONPath is a JSONPath template evaluated against the resource, e.g. {.status.syncStatus}
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="JSON Path",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	JSONPath string `json:"jsonPath,omitempty"`
+	// Value is the expected result of the JSONPath template, e.g. Succeeded. When empty, the template must give a
+	// non-empty result
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	Value string `json:"value,omitempty"`
+	// Timeout overrides the timeout of the health checks for this resource
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// WorkloadHealthCheck defines a label selector for workloads that must become ready
+type WorkloadHealthCheck struct {
+	// Namespace of the workloads, empty for all namespaces
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	Namespace string `json:"namespace,omitempty"`
+	// Selector selects the Deployments, StatefulSets and DaemonSets by label. At least one of them must match
+	// +kubebuilder:validation:Required
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Selector"
+	Selector metav1.LabelSelector `json:"selector"`
+	// Timeout overrides the timeout of the health checks for these workloads
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// Notifications defines where the stage transitions of the IBU are sent
+type Notifications struct {
+	// Endpoints lists the endpoints every notification is POSTed to
+	// +kubebuilder:validation:MinItems=1
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Endpoints"
+	Endpoints []NotificationEndpoint `json:"endpoints"`
+}
+
+// NotificationEndpoint defines an HTTP endpoint notified of the stage transitions
+type NotificationEndpoint struct {
+	// URL is the http or https URL the notifications are POSTed to
+	// +kubebuilder:validation:Required
+	// +kubebuilder:validation:Pattern=`^https?://`
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="URL",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	URL string `json:"url"`
+	// SigningKeyRef references a secret in the openshift-lifecycle-agent namespace holding the key used to sign the
+	// notifications with HMAC-SHA256, under the hmac.key key
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Signing Key Reference"
+	SigningKeyRef *SecretRef `json:"signingKeyRef,omitempty"`
+}
+
+// HookFailurePolicy defines how a failed hook affects the stage
+type HookFailurePolicy string
+
+// HookFailurePolicies defines the string values for valid hook failure policies
+var HookFailurePolicies = struct {
+	Fail     HookFailurePolicy
+	Ignore   HookFailurePolicy
+	Rollback HookFailurePolicy
+}{
+	Fail:     "Fail",
+	Ignore:   "Ignore",
+	Rollback: "Rollback",
+}
+
+// Hook defines a Job run at a fixed point of the upgrade
+type Hook struct {
+	// JobTemplateRef references a config map holding the manifest of the Job under the job.yaml key
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Job Template Reference"
+	JobTemplateRef ConfigMapRef `json:"jobTemplateRef"`
+	// TimeoutSeconds is how long the Job may run before the hook fails. Defaults to 1800 (30 minutes)
+	// +kubebuilder:validation:Minimum=0
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,xDescriptors={"urn:alm:descriptor:com.tectonic.ui:number"}
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
+	// FailurePolicy defines what happens when the Job fails or times out. Fail fails the stage, Ignore carries on
+	// with the stage, and Rollback automatically rolls back the upgrade for the postPivot hook, and fails the stage
+	// for the other hooks
+	// +kubebuilder:validation:Enum=Fail;Ignore;Rollback
+	// +kubebuilder:default=Fail
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Failure Policy"
+	FailurePolicy HookFailurePolicy `json:"failurePolicy,omitempty"`
+}
+
 // ConfigMapRef defines a reference to a config map
 type ConfigMapRef struct {
 	// +kubebuilder:validation:Required
@@ -117,6 +379,14 @@ type PullSecretRef struct {
 	Name string `json:"name"`
 }
 
+// SecretRef defines a reference to a secret in the openshift-lifecycle-agent namespace
+type SecretRef struct {
+	// +kubebuilder:validation:Required
+	// +required
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	Name string `json:"name"`
+}
+
 // ImageBasedUpgradeStatus defines the observed state of ImageBasedUpgrade
 type ImageBasedUpgradeStatus struct {
 	ObservedGeneration int64       `json:"observedGeneration,omitempty"`
@@ -126,6 +396,77 @@ type ImageBasedUpgradeStatus struct {
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
 	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Valid Next Stage"
 	ValidNextStages []ImageBasedUpgradeStage `json:"validNextStages,omitempty"`
+	// SeedImageDigest is the digest of the seed image pulled during Prep. The rest of the upgrade uses the image by
+	// this digest, and the Upgrade stage fails to start if the seed image tag has been moved to another digest
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Seed Image Digest"
+	SeedImageDigest string `json:"seedImageDigest,omitempty"`
+	// NextUpgradeWindow is when the next upgrade window opens, while the Upgrade stage waits for it
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Next Upgrade Window"
+	NextUpgradeWindow *metav1.Time `json:"nextUpgradeWindow,omitempty"`
+	// History records the latest upgrade attempts, most recent first. Unlike the conditions, it is kept when the IBU
+	// returns to Idle
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="History"
+	History []UpgradeRecord `json:"history,omitempty"`
+	// PostPivotSteps are the steps of the post-pivot configuration run on the new stateroot before the cluster
+	// starts, e.g. recert, the network reconfiguration and the manifests application
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Post Pivot Steps"
+	PostPivotSteps []PostPivotStep `json:"postPivotSteps,omitempty"`
+}
+
+// UpgradeOutcome is the final outcome of an upgrade attempt
+type UpgradeOutcome string
+
+// UpgradeOutcomes defines the string values for valid upgrade outcomes
+var UpgradeOutcomes = struct {
+	Upgraded   UpgradeOutcome
+	RolledBack UpgradeOutcome
+	Aborted    UpgradeOutcome
+}{
+	Upgraded:   "Upgraded",
+	RolledBack: "RolledBack",
+	Aborted:    "Aborted",
+}
+
+// UpgradeRecord records an upgrade attempt, from the start of the Prep stage to the return to Idle
+type UpgradeRecord struct {
+	SeedImage       string `json:"seedImage,omitempty"`
+	SeedImageDigest string `json:"seedImageDigest,omitempty"`
+	// FromVersion is the version of the cluster when the upgrade started
+	FromVersion string `json:"fromVersion,omitempty"`
+	// ToVersion is the seed version
+	ToVersion      string       `json:"toVersion,omitempty"`
+	StartTime      metav1.Time  `json:"startTime"`
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// Stages records the stages run during the attempt, in order
+	Stages []StageRecord `json:"stages,omitempty"`
+	// Outcome is set once the IBU returns to Idle
+	Outcome UpgradeOutcome `json:"outcome,omitempty"`
+	// RollbackReason is why the upgrade was rolled back, either on request or automatically on failure
+	RollbackReason string `json:"rollbackReason,omitempty"`
+	// PrecacheSummary is the summary of the precaching job run during Prep
+	PrecacheSummary string `json:"precacheSummary,omitempty"`
+}
+
+// StageRecord records a stage of an upgrade attempt
+type StageRecord struct {
+	Stage          ImageBasedUpgradeStage `json:"stage"`
+	StartTime      metav1.Time            `json:"startTime"`
+	CompletionTime *metav1.Time           `json:"completionTime,omitempty"`
+	// Result is Completed or Failed once the stage ends
+	Result string `json:"result,omitempty"`
+	// Message is the failure message of a failed stage
+	Message string `json:"message,omitempty"`
+}
+
+// PostPivotStep records a step of the post-pivot configuration
+type PostPivotStep struct {
+	Name           string       `json:"name"`
+	StartTime      metav1.Time  `json:"startTime"`
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// Result is InProgress, Completed or Failed
+	Result string `json:"result"`
+	// Error is the error of a failed step
+	Error string `json:"error,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -55,6 +55,101 @@ func (in *ConfigMapRef) DeepCopy() *ConfigMapRef {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HealthChecks) DeepCopyInto(out *HealthChecks) {
+	*out = *in
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]ResourceHealthCheck, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Workloads != nil {
+		in, out := &in.Workloads, &out.Workloads
+		*out = make([]WorkloadHealthCheck, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PreUpgrade != nil {
+		in, out := &in.PreUpgrade, &out.PreUpgrade
+		*out = make([]HealthCheckName, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HealthChecks.
+func (in *HealthChecks) DeepCopy() *HealthChecks {
+	if in == nil {
+		return nil
+	}
+	out := new(HealthChecks)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Hook) DeepCopyInto(out *Hook) {
+	*out = *in
+	out.JobTemplateRef = in.JobTemplateRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Hook.
+func (in *Hook) DeepCopy() *Hook {
+	if in == nil {
+		return nil
+	}
+	out := new(Hook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Hooks) DeepCopyInto(out *Hooks) {
+	*out = *in
+	if in.PrePrep != nil {
+		in, out := &in.PrePrep, &out.PrePrep
+		*out = new(Hook)
+		**out = **in
+	}
+	if in.PostPrep != nil {
+		in, out := &in.PostPrep, &out.PostPrep
+		*out = new(Hook)
+		**out = **in
+	}
+	if in.PrePivot != nil {
+		in, out := &in.PrePivot, &out.PrePivot
+		*out = new(Hook)
+		**out = **in
+	}
+	if in.PostPivot != nil {
+		in, out := &in.PostPivot, &out.PostPivot
+		*out = new(Hook)
+		**out = **in
+	}
+	if in.PostRollback != nil {
+		in, out := &in.PostRollback, &out.PostRollback
+		*out = new(Hook)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Hooks.
+func (in *Hooks) DeepCopy() *Hooks {
+	if in == nil {
+		return nil
+	}
+	out := new(Hooks)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ImageBasedUpgrade) DeepCopyInto(out *ImageBasedUpgrade) {
 	*out = *in
@@ -129,7 +224,24 @@ func (in *ImageBasedUpgradeSpec) DeepCopyInto(out *ImageBasedUpgradeSpec) {
 		*out = make([]ConfigMapRef, len(*in))
 		copy(*out, *in)
 	}
+	if in.UpgradeGraphRef != nil {
+		in, out := &in.UpgradeGraphRef, &out.UpgradeGraphRef
+		*out = new(ConfigMapRef)
+		**out = **in
+	}
 	out.AutoRollbackOnFailure = in.AutoRollbackOnFailure
+	if in.UpgradeWindow != nil {
+		in, out := &in.UpgradeWindow, &out.UpgradeWindow
+		*out = new(UpgradeWindow)
+		(*in).DeepCopyInto(*out)
+	}
+	in.Hooks.DeepCopyInto(&out.Hooks)
+	in.HealthChecks.DeepCopyInto(&out.HealthChecks)
+	if in.Notifications != nil {
+		in, out := &in.Notifications, &out.Notifications
+		*out = new(Notifications)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageBasedUpgradeSpec.
@@ -159,6 +271,24 @@ func (in *ImageBasedUpgradeStatus) DeepCopyInto(out *ImageBasedUpgradeStatus) {
 		*out = make([]ImageBasedUpgradeStage, len(*in))
 		copy(*out, *in)
 	}
+	if in.NextUpgradeWindow != nil {
+		in, out := &in.NextUpgradeWindow, &out.NextUpgradeWindow
+		*out = (*in).DeepCopy()
+	}
+	if in.History != nil {
+		in, out := &in.History, &out.History
+		*out = make([]UpgradeRecord, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PostPivotSteps != nil {
+		in, out := &in.PostPivotSteps, &out.PostPivotSteps
+		*out = make([]PostPivotStep, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageBasedUpgradeStatus.
@@ -171,6 +301,68 @@ func (in *ImageBasedUpgradeStatus) DeepCopy() *ImageBasedUpgradeStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NotificationEndpoint) DeepCopyInto(out *NotificationEndpoint) {
+	*out = *in
+	if in.SigningKeyRef != nil {
+		in, out := &in.SigningKeyRef, &out.SigningKeyRef
+		*out = new(SecretRef)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NotificationEndpoint.
+func (in *NotificationEndpoint) DeepCopy() *NotificationEndpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(NotificationEndpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Notifications) DeepCopyInto(out *Notifications) {
+	*out = *in
+	if in.Endpoints != nil {
+		in, out := &in.Endpoints, &out.Endpoints
+		*out = make([]NotificationEndpoint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Notifications.
+func (in *Notifications) DeepCopy() *Notifications {
+	if in == nil {
+		return nil
+	}
+	out := new(Notifications)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PostPivotStep) DeepCopyInto(out *PostPivotStep) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PostPivotStep.
+func (in *PostPivotStep) DeepCopy() *PostPivotStep {
+	if in == nil {
+		return nil
+	}
+	out := new(PostPivotStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PullSecretRef) DeepCopyInto(out *PullSecretRef) {
 	*out = *in
@@ -186,6 +378,41 @@ func (in *PullSecretRef) DeepCopy() *PullSecretRef {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ResourceHealthCheck) DeepCopyInto(out *ResourceHealthCheck) {
+	*out = *in
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ResourceHealthCheck.
+func (in *ResourceHealthCheck) DeepCopy() *ResourceHealthCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(ResourceHealthCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretRef) DeepCopyInto(out *SecretRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretRef.
+func (in *SecretRef) DeepCopy() *SecretRef {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SeedImageRef) DeepCopyInto(out *SeedImageRef) {
 	*out = *in
@@ -194,6 +421,11 @@ func (in *SeedImageRef) DeepCopyInto(out *SeedImageRef) {
 		*out = new(PullSecretRef)
 		**out = **in
 	}
+	if in.Verification != nil {
+		in, out := &in.Verification, &out.Verification
+		*out = new(SeedImageVerification)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SeedImageRef.
@@ -205,3 +437,117 @@ func (in *SeedImageRef) DeepCopy() *SeedImageRef {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SeedImageVerification) DeepCopyInto(out *SeedImageVerification) {
+	*out = *in
+	if in.CosignKeyRef != nil {
+		in, out := &in.CosignKeyRef, &out.CosignKeyRef
+		*out = new(SecretRef)
+		**out = **in
+	}
+	if in.PolicyRef != nil {
+		in, out := &in.PolicyRef, &out.PolicyRef
+		*out = new(ConfigMapRef)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SeedImageVerification.
+func (in *SeedImageVerification) DeepCopy() *SeedImageVerification {
+	if in == nil {
+		return nil
+	}
+	out := new(SeedImageVerification)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StageRecord) DeepCopyInto(out *StageRecord) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StageRecord.
+func (in *StageRecord) DeepCopy() *StageRecord {
+	if in == nil {
+		return nil
+	}
+	out := new(StageRecord)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeRecord) DeepCopyInto(out *UpgradeRecord) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Stages != nil {
+		in, out := &in.Stages, &out.Stages
+		*out = make([]StageRecord, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeRecord.
+func (in *UpgradeRecord) DeepCopy() *UpgradeRecord {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeRecord)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeWindow) DeepCopyInto(out *UpgradeWindow) {
+	*out = *in
+	if in.Start != nil {
+		in, out := &in.Start, &out.Start
+		*out = (*in).DeepCopy()
+	}
+	out.Duration = in.Duration
+	out.ExpectedDuration = in.ExpectedDuration
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeWindow.
+func (in *UpgradeWindow) DeepCopy() *UpgradeWindow {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeWindow)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkloadHealthCheck) DeepCopyInto(out *WorkloadHealthCheck) {
+	*out = *in
+	in.Selector.DeepCopyInto(&out.Selector)
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkloadHealthCheck.
+func (in *WorkloadHealthCheck) DeepCopy() *WorkloadHealthCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkloadHealthCheck)
+	in.DeepCopyInto(out)
+	return out
+}
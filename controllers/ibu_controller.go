@@ -26,11 +26,16 @@ import (
 	"github.com/openshift-kni/lifecycle-agent/internal/backuprestore"
 	"github.com/openshift-kni/lifecycle-agent/internal/reboot"
 
+	"github.com/distribution/reference"
 	"github.com/go-logr/logr"
 	"github.com/openshift-kni/lifecycle-agent/controllers/utils"
 	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/internal/hooks"
+	"github.com/openshift-kni/lifecycle-agent/internal/metrics"
+	"github.com/openshift-kni/lifecycle-agent/internal/notify"
 	"github.com/openshift-kni/lifecycle-agent/internal/ostreeclient"
 	"github.com/openshift-kni/lifecycle-agent/internal/precache"
+	"github.com/openshift-kni/lifecycle-agent/internal/progress"
 	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
 	rpmostreeclient "github.com/openshift-kni/lifecycle-agent/lca-cli/ostreeclient"
 
@@ -60,12 +65,14 @@ type ImageBasedUpgradeReconciler struct {
 	Scheme          *runtime.Scheme
 	Recorder        record.EventRecorder
 	Precache        *precache.PHandler
+	Hooks           *hooks.Runner
 	BackupRestore   backuprestore.BackuperRestorer
 	RPMOstreeClient rpmostreeclient.IClient
 	Executor        ops.Execute
 	OstreeClient    ostreeclient.IClient
 	Ops             ops.Ops
 	RebootClient    reboot.RebootIntf
+	Notifier        *notify.Notifier
 	PrepTask        *Task
 	Mux             *sync.Mutex
 }
@@ -76,7 +83,16 @@ type Task struct {
 	Success  bool
 	Cancel   context.CancelFunc
 	Progress string
-	done     chan struct{}
+	// FailureReason overrides the reason of the failed condition, for failures that need a dedicated reason
+	FailureReason utils.ConditionReason
+	// FromVersion and PrecacheSummary are recorded in the upgrade history
+	FromVersion     string
+	PrecacheSummary string
+	// PrecacheDiskSpaceChecked is set when the images to precache were counted in the disk space check
+	PrecacheDiskSpaceChecked bool
+	// HookAttempt identifies the upgrade attempt the hook Jobs are run for
+	HookAttempt string
+	done                     chan struct{}
 }
 
 // Reset Re-initialize the Task variables to initial values
@@ -85,6 +101,11 @@ func (c *Task) Reset() {
 	c.Success = false
 	c.Cancel = nil
 	c.Progress = ""
+	c.FailureReason = ""
+	c.FromVersion = ""
+	c.PrecacheSummary = ""
+	c.PrecacheDiskSpaceChecked = false
+	c.HookAttempt = ""
 	select {
 	case _, open := <-c.done:
 		if open {
@@ -174,6 +195,7 @@ func (r *ImageBasedUpgradeReconciler) Reconcile(ctx context.Context, req ctrl.Re
 		r.Log.Error(err, "Failed to get ImageBasedUpgrade")
 		return
 	}
+	defer r.notify(ctx, ibu)
 
 	r.Log.Info("Loaded IBU", "name", req.NamespacedName, "version", ibu.GetResourceVersion(), "desired stage", ibu.Spec.Stage)
 
@@ -194,6 +216,7 @@ func (r *ImageBasedUpgradeReconciler) Reconcile(ctx context.Context, req ctrl.Re
 					return
 				}
 				if !isValid {
+					metrics.IncIBUFailure(ibu, lcav1alpha1.Stages.Prep, "InvalidSpec")
 					err = utils.UpdateIBUStatus(ctx, r.Client, ibu)
 					return
 				}
@@ -203,53 +226,62 @@ func (r *ImageBasedUpgradeReconciler) Reconcile(ctx context.Context, req ctrl.Re
 	} else {
 		inProgressStage := utils.GetInProgressStage(ibu)
 		if inProgressStage != "" {
+			var stageStart time.Time
+			if condition := utils.GetInProgressCondition(ibu, inProgressStage); condition != nil {
+				stageStart = condition.LastTransitionTime.Time
+			}
 			nextReconcile, err = r.handleStage(ctx, ibu, inProgressStage)
+			observeStageDuration(ibu, inProgressStage, stageStart)
 			if err != nil {
 				_ = utils.UpdateIBUStatus(ctx, r.Client, ibu)
 				return
 			}
-			ibu.Status.ValidNextStages = getValidNextStageList(ibu, isAfterPivot)
+			ibu.Status.ValidNextStages = utils.GetValidNextStages(ibu, isAfterPivot)
 		}
 	}
 
+	if isAfterPivot {
+		r.mergePostPivotProgress(ibu)
+	}
+
 	// Update status
 	err = utils.UpdateIBUStatus(ctx, r.Client, ibu)
 	return
 }
 
-func getValidNextStageList(ibu *lcav1alpha1.ImageBasedUpgrade, isAfterPivot bool) []lcav1alpha1.ImageBasedUpgradeStage {
-	inProgressStage := utils.GetInProgressStage(ibu)
-	if inProgressStage == lcav1alpha1.Stages.Idle || inProgressStage == lcav1alpha1.Stages.Rollback || utils.IsStageFailed(ibu, lcav1alpha1.Stages.Rollback) {
-		// no valid transition if abort/finalize/rollback in progress or failed
-		return []lcav1alpha1.ImageBasedUpgradeStage{}
+// mergePostPivotProgress reports the steps of the post-pivot configuration, which ran or is still running on the new
+// stateroot while the LCA Operator was down
+func (r *ImageBasedUpgradeReconciler) mergePostPivotProgress(ibu *lcav1alpha1.ImageBasedUpgrade) {
+	steps, err := progress.Read(postPivotProgressPath)
+	if err != nil {
+		r.Log.Error(err, "Failed to read the post-pivot progress")
+		return
 	}
+	progress.Merge(ibu, steps)
+}
 
-	if inProgressStage == lcav1alpha1.Stages.Prep || utils.IsStageFailed(ibu, lcav1alpha1.Stages.Prep) {
-		return []lcav1alpha1.ImageBasedUpgradeStage{lcav1alpha1.Stages.Idle}
+// notify queues the notifications for the stage transitions made by the reconcile
+func (r *ImageBasedUpgradeReconciler) notify(ctx context.Context, ibu *lcav1alpha1.ImageBasedUpgrade) {
+	if r.Notifier == nil {
+		return
 	}
-
-	if inProgressStage == lcav1alpha1.Stages.Upgrade || utils.IsStageFailed(ibu, lcav1alpha1.Stages.Upgrade) {
-		if isAfterPivot {
-			return []lcav1alpha1.ImageBasedUpgradeStage{lcav1alpha1.Stages.Rollback}
-		} else {
-			return []lcav1alpha1.ImageBasedUpgradeStage{lcav1alpha1.Stages.Idle}
-		}
+	if err := r.Notifier.Notify(ctx, ibu); err != nil {
+		r.Log.Error(err, "Failed to notify the stage transitions")
 	}
+}
 
-	// no in progress stage, check completed stages in reverse order
-	if utils.IsStageCompleted(ibu, lcav1alpha1.Stages.Rollback) {
-		return []lcav1alpha1.ImageBasedUpgradeStage{lcav1alpha1.Stages.Idle}
-	}
-	if utils.IsStageCompleted(ibu, lcav1alpha1.Stages.Upgrade) {
-		return []lcav1alpha1.ImageBasedUpgradeStage{lcav1alpha1.Stages.Idle, lcav1alpha1.Stages.Rollback}
-	}
-	if utils.IsStageCompleted(ibu, lcav1alpha1.Stages.Prep) {
-		return []lcav1alpha1.ImageBasedUpgradeStage{lcav1alpha1.Stages.Idle, lcav1alpha1.Stages.Upgrade}
+// observeStageDuration records the duration of the Prep, Upgrade or Rollback stage once it completed or failed.
+// The start of the stage is taken from its in-progress condition, so that it survives restarts and reboots
+func observeStageDuration(ibu *lcav1alpha1.ImageBasedUpgrade, stage lcav1alpha1.ImageBasedUpgradeStage, start time.Time) {
+	if stage == lcav1alpha1.Stages.Idle || start.IsZero() {
+		return
 	}
-	if utils.IsStageCompleted(ibu, lcav1alpha1.Stages.Idle) {
-		return []lcav1alpha1.ImageBasedUpgradeStage{lcav1alpha1.Stages.Prep}
+	switch {
+	case utils.IsStageCompleted(ibu, stage):
+		metrics.ObserveIBUStageDuration(ibu, stage, metrics.ResultCompleted, start)
+	case utils.IsStageFailed(ibu, stage):
+		metrics.ObserveIBUStageDuration(ibu, stage, metrics.ResultFailed, start)
 	}
-	return []lcav1alpha1.ImageBasedUpgradeStage{}
 }
 
 func isTransitionRequested(ibu *lcav1alpha1.ImageBasedUpgrade) bool {
@@ -461,6 +493,31 @@ func (r *ImageBasedUpgradeReconciler) validateIBUSpec(ctx context.Context, ibu *
 			return false, fmt.Errorf("failed to check oadp operator availability: %w", err)
 		}
 	}
+
+	// If additional images configmap is provided, validate that it exists and lists valid images
+	if ibu.Spec.AdditionalImages.Name != "" {
+		images, err := r.getAdditionalImages(ctx, ibu)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				utils.SetPrepStatusFailed(ibu, fmt.Sprintf("additional images configMap %s/%s not found",
+					ibu.Spec.AdditionalImages.Namespace, ibu.Spec.AdditionalImages.Name))
+				return false, nil
+			}
+			return false, fmt.Errorf("failed to get additional images configMap: %w", err)
+		}
+		if len(images) == 0 {
+			utils.SetPrepStatusFailed(ibu, fmt.Sprintf("additional images configMap %s/%s does not list any image",
+				ibu.Spec.AdditionalImages.Namespace, ibu.Spec.AdditionalImages.Name))
+			return false, nil
+		}
+		for _, image := range images {
+			if _, err := reference.ParseNormalizedNamed(image); err != nil {
+				utils.SetPrepStatusFailed(ibu, fmt.Sprintf("additional images configMap %s/%s contains an invalid image reference %q: %s",
+					ibu.Spec.AdditionalImages.Namespace, ibu.Spec.AdditionalImages.Name, image, err))
+				return false, nil
+			}
+		}
+	}
 	return true, nil
 }
 
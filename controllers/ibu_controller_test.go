@@ -883,8 +883,8 @@ func Test_getValidNextStageList(t *testing.T) {
 			for _, condition := range tt.conditions {
 				utils.SetStatusCondition(&ibu.Status.Conditions, condition.Type, condition.Reason, condition.Status, "", 1)
 			}
-			if gotStageList := getValidNextStageList(ibu, tt.isAfterPivot); !reflect.DeepEqual(gotStageList, tt.wantStageList) {
-				t.Errorf("getValidNextStageList() = %v, want %v", gotStageList, tt.wantStageList)
+			if gotStageList := utils.GetValidNextStages(ibu, tt.isAfterPivot); !reflect.DeepEqual(gotStageList, tt.wantStageList) {
+				t.Errorf("GetValidNextStages() = %v, want %v", gotStageList, tt.wantStageList)
 			}
 		})
 	}
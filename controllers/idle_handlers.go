@@ -40,7 +40,11 @@ func (r *ImageBasedUpgradeReconciler) handleAbort(ctx context.Context, ibu *lcav
 
 	if successful, errMsg := r.cleanup(ctx, false, ibu); successful {
 		r.Log.Info("Finished handleAbort successfully")
+		utils.CompleteHistory(ibu, false)
 		utils.ResetStatusConditions(&ibu.Status.Conditions, ibu.Generation)
+		ibu.Status.SeedImageDigest = ""
+		ibu.Status.NextUpgradeWindow = nil
+		ibu.Status.PostPivotSteps = nil
 		return doNotRequeue(), nil
 	} else {
 		utils.SetStatusCondition(&ibu.Status.Conditions,
@@ -96,7 +100,11 @@ func (r *ImageBasedUpgradeReconciler) handleFinalize(ctx context.Context, ibu *l
 
 	if successful, errMsg := r.cleanup(ctx, true, ibu); successful {
 		r.Log.Info("Finished handleFinalize successfully")
+		utils.CompleteHistory(ibu, true)
 		utils.ResetStatusConditions(&ibu.Status.Conditions, ibu.Generation)
+		ibu.Status.SeedImageDigest = ""
+		ibu.Status.NextUpgradeWindow = nil
+		ibu.Status.PostPivotSteps = nil
 		return doNotRequeue(), nil
 	} else {
 		utils.SetStatusCondition(&ibu.Status.Conditions,
@@ -137,6 +145,9 @@ func (r *ImageBasedUpgradeReconciler) cleanup(
 	if err := r.Precache.Cleanup(ctx); err != nil {
 		handleError(err, "failed to cleanup precaching resources.")
 	}
+	if err := r.Hooks.Cleanup(ctx); err != nil {
+		handleError(err, "failed to cleanup hook jobs.")
+	}
 
 	// only delete Backup CRs
 	if allRemoved, err := r.BackupRestore.CleanupBackups(ctx); err != nil {
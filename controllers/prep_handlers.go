@@ -23,69 +23,176 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
-	"github.com/coreos/go-semver/semver"
 	configv1 "github.com/openshift/api/config/v1"
+	"github.com/samber/lo"
 	"golang.org/x/sync/errgroup"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
 
 	lcav1alpha1 "github.com/openshift-kni/lifecycle-agent/api/v1alpha1"
 	"github.com/openshift-kni/lifecycle-agent/controllers/utils"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
 	"github.com/openshift-kni/lifecycle-agent/lca-cli/seedclusterinfo"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/seedcontent"
 	lcautils "github.com/openshift-kni/lifecycle-agent/utils"
 
 	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/internal/healthcheck"
+	"github.com/openshift-kni/lifecycle-agent/internal/hooks"
+	"github.com/openshift-kni/lifecycle-agent/internal/metrics"
 	"github.com/openshift-kni/lifecycle-agent/internal/precache"
 	"github.com/openshift-kni/lifecycle-agent/internal/prep"
+	"github.com/openshift-kni/lifecycle-agent/internal/sigstore"
+	"github.com/openshift-kni/lifecycle-agent/internal/upgradepath"
 	corev1 "k8s.io/api/core/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// writeSeedPullSecret returns the auth file for pulling the seed image. The cluster wide pull-secret is used,
+// unless the spec references a dedicated secret, which is then written to the workspace. The returned cleanup
+// function removes the written file
+func writeSeedPullSecret(ctx context.Context, c client.Client, ibu *lcav1alpha1.ImageBasedUpgrade) (string, func(), error) {
+	if ibu.Spec.SeedImageRef.PullSecretRef == nil {
+		return common.ImageRegistryAuthFile, func() {}, nil
+	}
+
+	pullSecret, err := lcautils.GetSecretData(ctx, ibu.Spec.SeedImageRef.PullSecretRef.Name,
+		common.LcaNamespace, corev1.DockerConfigJsonKey, c)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to retrieve pull-secret from secret %s, err: %w", ibu.Spec.SeedImageRef.PullSecretRef.Name, err)
+	}
+
+	pullSecretFilename := filepath.Join(utils.IBUWorkspacePath, "seed-pull-secret")
+	if err = os.WriteFile(common.PathOutsideChroot(pullSecretFilename), []byte(pullSecret), 0o600); err != nil {
+		return "", nil, fmt.Errorf("failed to write seed image pull-secret to file %s, err: %w", pullSecretFilename, err)
+	}
+	return pullSecretFilename, func() { os.Remove(common.PathOutsideChroot(pullSecretFilename)) }, nil
+}
+
+// getSeedImage pulls the seed image by the given reference, which pins its digest
 func (r *ImageBasedUpgradeReconciler) getSeedImage(
-	ctx context.Context, ibu *lcav1alpha1.ImageBasedUpgrade) error {
-	// Use cluster wide pull-secret by default
-	pullSecretFilename := common.ImageRegistryAuthFile
-
-	if ibu.Spec.SeedImageRef.PullSecretRef != nil {
-		var pullSecret string
-		pullSecret, err := lcautils.GetSecretData(ctx, ibu.Spec.SeedImageRef.PullSecretRef.Name,
-			common.LcaNamespace, corev1.DockerConfigJsonKey, r.Client)
-		if err != nil {
-			err = fmt.Errorf("failed to retrieve pull-secret from secret %s, err: %w", ibu.Spec.SeedImageRef.PullSecretRef.Name, err)
-			return err
-		}
+	ctx context.Context, ibu *lcav1alpha1.ImageBasedUpgrade, seedImage string) error {
+	pullSecretFilename, cleanup, err := writeSeedPullSecret(ctx, r.Client, ibu)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
 
-		pullSecretFilename = filepath.Join(utils.IBUWorkspacePath, "seed-pull-secret")
-		if err = os.WriteFile(common.PathOutsideChroot(pullSecretFilename), []byte(pullSecret), 0o600); err != nil {
-			err = fmt.Errorf("failed to write seed image pull-secret to file %s, err: %w", pullSecretFilename, err)
+	if verification := ibu.Spec.SeedImageRef.Verification; verification != nil {
+		policyPath, registriesDir, err := r.writeSeedVerificationPolicy(ctx, verification)
+		if err != nil {
 			return err
 		}
-		defer os.Remove(common.PathOutsideChroot(pullSecretFilename))
+		r.Log.Info("Pulling seed image and verifying its signature")
+		return prep.PullVerifiedImage(r.Executor, seedImage, pullSecretFilename, policyPath, registriesDir) //nolint:wrapcheck
 	}
 
 	r.Log.Info("Pulling seed image")
-	if _, err := r.Executor.Execute("podman", "pull", "--authfile", pullSecretFilename, ibu.Spec.SeedImageRef.Image); err != nil {
+	if _, err := r.Executor.Execute("podman", "pull", "--authfile", pullSecretFilename, seedImage); err != nil {
 		return fmt.Errorf("failed to pull image: %w", err)
 	}
 
-	r.Log.Info("Checking seed image compatibility")
-	if err := r.checkSeedImageCompatibility(ctx, ibu.Spec.SeedImageRef.Image); err != nil {
-		return fmt.Errorf("checking seed image compatibility: %w", err)
+	return nil
+}
+
+// writeSeedVerificationPolicy writes the policy.json the seed image signature is verified against to the workspace.
+// With a cosign public key, the registries.d configuration to look up its sigstore signature is written as well
+func (r *ImageBasedUpgradeReconciler) writeSeedVerificationPolicy(
+	ctx context.Context, verification *lcav1alpha1.SeedImageVerification) (policyPath, registriesDir string, err error) {
+	verificationDir := filepath.Join(utils.IBUWorkspacePath, "seed-verification")
+	if err = os.MkdirAll(common.PathOutsideChroot(verificationDir), 0o700); err != nil {
+		return "", "", fmt.Errorf("failed to create %s: %w", verificationDir, err)
 	}
+	policyPath = filepath.Join(verificationDir, common.ContainersPolicyKey)
 
-	return nil
+	var policy []byte
+	if verification.PolicyRef != nil {
+		cm, err := common.GetConfigMap(ctx, r.Client, *verification.PolicyRef)
+		if err != nil {
+			return "", "", err
+		}
+		data, ok := cm.Data[common.ContainersPolicyKey]
+		if !ok {
+			return "", "", fmt.Errorf("configMap %s/%s has no %s key", cm.Namespace, cm.Name, common.ContainersPolicyKey)
+		}
+		policy = []byte(data)
+	} else {
+		publicKey, err := lcautils.GetSecretData(ctx, verification.CosignKeyRef.Name, common.LcaNamespace, common.CosignPublicKeyKey, r.Client)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to retrieve cosign public key from secret %s: %w", verification.CosignKeyRef.Name, err)
+		}
+		publicKeyPath := filepath.Join(verificationDir, common.CosignPublicKeyKey)
+		if err = os.WriteFile(common.PathOutsideChroot(publicKeyPath), []byte(publicKey), 0o600); err != nil {
+			return "", "", fmt.Errorf("failed to write cosign public key to %s: %w", publicKeyPath, err)
+		}
+		if policy, err = sigstore.VerificationPolicy(publicKeyPath); err != nil {
+			return "", "", err //nolint:wrapcheck
+		}
+		registriesDir = filepath.Join(verificationDir, "registries.d")
+		if err = sigstore.WriteRegistriesConfig(common.PathOutsideChroot(registriesDir)); err != nil {
+			return "", "", err //nolint:wrapcheck
+		}
+	}
+
+	if err = os.WriteFile(common.PathOutsideChroot(policyPath), policy, 0o600); err != nil {
+		return "", "", fmt.Errorf("failed to write verification policy to %s: %w", policyPath, err)
+	}
+	return policyPath, registriesDir, nil
+}
+
+// resolveSeedImageDigest returns the digest the seed image tag currently points to in the registry
+func resolveSeedImageDigest(ctx context.Context, c client.Client, hostOps ops.Ops, ibu *lcav1alpha1.ImageBasedUpgrade) (string, error) {
+	pullSecretFilename, cleanup, err := writeSeedPullSecret(ctx, c, ibu)
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
+
+	return prep.ResolveImageDigest(hostOps, ibu.Spec.SeedImageRef.Image, pullSecretFilename) //nolint:wrapcheck
+}
+
+// checkPrepDiskSpace fails early if the host doesn't have room for the seed image, the new stateroot and the images to
+// precache. The images of the seed are read from its annotations in the registry; for seed images without them, the
+// precaching disk space is checked again once the image list is extracted from the seed image
+func (r *ImageBasedUpgradeReconciler) checkPrepDiskSpace(ctx context.Context, ibu *lcav1alpha1.ImageBasedUpgrade) error {
+	pullSecretFilename, cleanup, err := writeSeedPullSecret(ctx, r.Client, ibu)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	content, err := prep.InspectSeedContent(r.Ops, ibu.Spec.SeedImageRef.Image, pullSecretFilename)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	additionalImages, err := r.getAdditionalImages(ctx, ibu)
+	if err != nil {
+		return fmt.Errorf("failed to get additional images: %w", err)
+	}
+	var images []string
+	if content != nil && len(content.Images) > 0 {
+		images = content.Images
+		r.PrepTask.PrecacheDiskSpaceChecked = true
+	} else {
+		r.Log.Info("Seed image doesn't list its images in its annotations, the precaching disk space is checked after the stateroot setup")
+	}
+	images = common.RemoveDuplicates(append(append([]string{}, images...), additionalImages...))
+
+	//nolint:wrapcheck
+	return prep.CheckPrepDiskSpace(r.Log, r.Ops, ibu.Spec.SeedImageRef.Image, pullSecretFilename, images, common.ImageRegistryAuthFile)
 }
 
 // checkSeedImageCompatibility checks if the seed image is compatible with the
 // current version of the lifecycle-agent by inspecting the OCI image's labels
-// and checking if the specified format version equals the hard-coded one that
-// this version of the lifecycle agent expects. That format version is set by
-// the lca-cli during the image build process, and is only manually bumped by
-// developers when the image format changes in a way that is incompatible with
-// previous versions of the lifecycle-agent.
-func (r *ImageBasedUpgradeReconciler) checkSeedImageCompatibility(_ context.Context, seedImageRef string) error {
+// and checking if the specified format version is one that this version of the
+// lifecycle agent supports. The operators recorded in the image's annotations
+// are then checked against those of the cluster.
+func (r *ImageBasedUpgradeReconciler) checkSeedImageCompatibility(ctx context.Context, ibu *lcav1alpha1.ImageBasedUpgrade, seedImageRef string) error {
 	inspectArgs := []string{
 		"inspect",
 		"--format", "json",
@@ -93,7 +200,8 @@ func (r *ImageBasedUpgradeReconciler) checkSeedImageCompatibility(_ context.Cont
 	}
 
 	var inspect []struct {
-		Labels map[string]string `json:"Labels"`
+		Labels      map[string]string `json:"Labels"`
+		Annotations map[string]string `json:"Annotations"`
 	}
 
 	// TODO: use the context when execute supports it
@@ -109,53 +217,69 @@ func (r *ImageBasedUpgradeReconciler) checkSeedImageCompatibility(_ context.Cont
 		return fmt.Errorf("expected 1 image inspect result, got %d", len(inspect))
 	}
 
-	seedFormatLabelValue, ok := inspect[0].Labels[common.SeedFormatOCILabel]
-	if !ok {
-		return fmt.Errorf(
-			"seed image %s is missing the %s label, please build a new image using the latest version of the lca-cli",
-			seedImageRef, common.SeedFormatOCILabel)
+	if err := prep.CheckSeedFormatVersion(seedImageRef, inspect[0].Labels); err != nil {
+		return err //nolint:wrapcheck
 	}
 
-	// Hard equal since we don't have backwards compatibility guarantees yet.
-	// In the future we might want to have backwards compatibility code to
-	// handle older seed formats and in that case we'll look at the version
-	// number and do the right thing.
-	if seedFormatLabelValue != fmt.Sprintf("%d", common.SeedFormatVersion) {
-		return fmt.Errorf("seed image format version mismatch: expected %d, got %s",
-			common.SeedFormatVersion, seedFormatLabelValue)
+	// A delta seed image can only be used if its base seed image is present
+	if baseSeedImage, ok := inspect[0].Labels[common.SeedBaseImageOCILabel]; ok {
+		exists, err := r.Ops.ImageExists(baseSeedImage)
+		if err != nil {
+			return fmt.Errorf("failed to check for the base seed image: %w", err)
+		}
+		if !exists {
+			return &prep.SeedDeltaBaseMissingError{BaseSeedImage: baseSeedImage}
+		}
 	}
 
-	return nil
+	return r.checkOperatorCompatibility(ctx, ibu, inspect[0].Annotations)
 }
 
-// validateSeedOcpVersion rejects upgrade request if seed image version is not higher than current cluster (target) OCP version
-func (r *ImageBasedUpgradeReconciler) validateSeedOcpVersion(seedOcpVersion string) error {
-	// get target OCP version
-	targetClusterVersion := &configv1.ClusterVersion{}
-	if err := r.Get(context.Background(), types.NamespacedName{Name: "version"}, targetClusterVersion); err != nil {
-		return fmt.Errorf("failed to get ClusterVersion for target: %w", err)
+// checkOperatorCompatibility compares the OLM operators of the cluster with the operator inventory recorded in the
+// seed image, as the operators missing from the seed image would be gone after the pivot. The differences fail Prep
+// or are reported in a warning event, per the operator compatibility policy
+func (r *ImageBasedUpgradeReconciler) checkOperatorCompatibility(ctx context.Context, ibu *lcav1alpha1.ImageBasedUpgrade, annotations map[string]string) error {
+	content, err := seedcontent.FromAnnotations(annotations)
+	if err != nil {
+		return fmt.Errorf("failed to read seed content annotations: %w", err)
+	}
+	if content == nil {
+		r.Log.Info("Seed image has no operator inventory, skipping operator compatibility check")
+		return nil
 	}
-	targetOCP := targetClusterVersion.Status.Desired.Version
 
-	// parse versions
-	targetSemVer, err := semver.NewVersion(targetOCP)
+	operators, err := seedcontent.ListOperators(ctx, r.Client)
 	if err != nil {
-		return fmt.Errorf("failed to parse target version %s: %w", targetOCP, err)
+		return fmt.Errorf("failed to list cluster operators: %w", err)
 	}
-	seedSemVer, err := semver.NewVersion(seedOcpVersion)
-	if err != nil {
-		return fmt.Errorf("failed to parse seed version %s: %w", seedOcpVersion, err)
+	incompatibilities := prep.CheckOperatorCompatibility(operators, content.Operators)
+	if len(incompatibilities) == 0 {
+		return nil
 	}
 
-	// compare versions
-	if seedSemVer.Compare(*targetSemVer) <= 0 {
-		return fmt.Errorf("seed OCP version (%s) must be higher than current OCP version (%s)", seedOcpVersion, targetOCP)
+	if ibu.Spec.OperatorCompatibility == lcav1alpha1.OperatorCompatibilityPolicies.Fail {
+		return &prep.OperatorIncompatibilityError{Incompatibilities: incompatibilities}
 	}
-
-	r.Log.Info("OCP versions are validated", "seed", seedOcpVersion, "target", targetOCP)
+	r.Log.Info("Seed image operators differ from the cluster operators", "incompatibilities", incompatibilities)
+	r.Recorder.Event(ibu, corev1.EventTypeWarning, "OperatorIncompatibility", strings.Join(incompatibilities, "; "))
 	return nil
 }
 
+// validateUpgradePath checks the upgrade from the current version of the cluster to the seed version against the
+// upgrade graph referenced by the spec, and the version skew rules
+func (r *ImageBasedUpgradeReconciler) validateUpgradePath(ctx context.Context, ibu *lcav1alpha1.ImageBasedUpgrade) (*upgradepath.Path, error) {
+	clusterVersion := &configv1.ClusterVersion{}
+	if err := r.Get(ctx, types.NamespacedName{Name: "version"}, clusterVersion); err != nil {
+		return nil, fmt.Errorf("failed to get ClusterVersion: %w", err)
+	}
+
+	validator, err := upgradepath.NewValidatorForIBU(ctx, r.Client, ibu)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	return validator.Validate(clusterVersion.Status.Desired.Version, ibu.Spec.SeedImageRef.Version) //nolint:wrapcheck
+}
+
 func (r *ImageBasedUpgradeReconciler) getPodEnvVars(ctx context.Context) (envVars []corev1.EnvVar, err error) {
 	pod := &corev1.Pod{}
 	if err = r.Client.Get(ctx, types.NamespacedName{Name: os.Getenv("MY_POD_NAME"), Namespace: common.LcaNamespace}, pod); err != nil {
@@ -199,13 +323,40 @@ func (r *ImageBasedUpgradeReconciler) launchPrecaching(ctx context.Context, imag
 		return false, fmt.Errorf("failed to read pre-caching image file: %s, %w", common.PathOutsideChroot(imageListFile), err)
 	}
 
+	// Merge the user requested additional images with the seed image list
+	additionalImages, err := r.getAdditionalImages(ctx, ibu)
+	if err != nil {
+		return false, fmt.Errorf("failed to get additional images: %w", err)
+	}
+	additionalImages, err = prep.BuildPrecachingList(additionalImages, clusterRegistry, seedInfo.ReleaseRegistry, shouldOverrideRegistry)
+	if err != nil {
+		return false, fmt.Errorf("failed to build additional images list: %w", err)
+	}
+	var extraImages []string
+	for _, image := range common.RemoveDuplicates(additionalImages) {
+		if !lo.Contains(imageList, image) {
+			extraImages = append(extraImages, image)
+		}
+	}
+	if len(extraImages) > 0 {
+		r.Log.Info("Adding additional images to the pre-caching list", "count", len(extraImages))
+		imageList = append(imageList, extraImages...)
+	}
+
+	// The images of seed images without the images annotation were left out of the disk space check
+	if !r.PrepTask.PrecacheDiskSpaceChecked {
+		if err := prep.CheckPrecacheDiskSpace(r.Log, r.Ops, imageList, common.ImageRegistryAuthFile); err != nil {
+			return false, err //nolint:wrapcheck
+		}
+	}
+
 	envVars, err := r.getPodEnvVars(ctx)
 	if err != nil {
 		return false, fmt.Errorf("failed to get pod env vars: %w", err)
 	}
 
 	// Create pre-cache config using default values
-	config := precache.NewConfig(imageList, envVars)
+	config := precache.NewConfig(imageList, envVars, "AdditionalImageList", extraImages)
 	err = r.Precache.CreateJob(ctx, config)
 	if err != nil {
 		return false, fmt.Errorf("failed to create precaching job: %w", err)
@@ -214,6 +365,39 @@ func (r *ImageBasedUpgradeReconciler) launchPrecaching(ctx context.Context, imag
 	return true, nil
 }
 
+// getAdditionalImages returns the images listed in the spec.additionalImages configmap, if any. Every line of every
+// data entry in the configmap is treated as an image reference
+func (r *ImageBasedUpgradeReconciler) getAdditionalImages(ctx context.Context, ibu *lcav1alpha1.ImageBasedUpgrade) ([]string, error) {
+	if ibu.Spec.AdditionalImages.Name == "" {
+		return nil, nil
+	}
+
+	cm, err := common.GetConfigMap(ctx, r.Client, ibu.Spec.AdditionalImages)
+	if err != nil {
+		return nil, err
+	}
+
+	// Sort the keys so the resulting list is stable across reconciles
+	keys := make([]string, 0, len(cm.Data))
+	for key := range cm.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var images []string
+	for _, key := range keys {
+		for _, line := range strings.Split(cm.Data[key], "\n") {
+			image := strings.TrimSpace(line)
+			if image == "" || strings.HasPrefix(image, "#") {
+				continue
+			}
+			images = append(images, image)
+		}
+	}
+
+	return common.RemoveDuplicates(images), nil
+}
+
 func (r *ImageBasedUpgradeReconciler) queryPrecachingStatus(ctx context.Context) (status *precache.Status, err error) {
 	status, err = r.Precache.QueryJobStatus(ctx)
 	if err != nil {
@@ -247,9 +431,11 @@ func (r *ImageBasedUpgradeReconciler) queryPrecachingStatus(ctx context.Context)
 	return
 }
 
-func (r *ImageBasedUpgradeReconciler) SetupStateroot(ctx context.Context, ibu *lcav1alpha1.ImageBasedUpgrade, imageListFile string) error {
-	if err := prep.SetupStateroot(r.Log, r.Ops, r.OstreeClient, r.RPMOstreeClient, ibu.Spec.SeedImageRef.Image,
-		ibu.Spec.SeedImageRef.Version, imageListFile, false); err != nil {
+func (r *ImageBasedUpgradeReconciler) SetupStateroot(ctx context.Context, ibu *lcav1alpha1.ImageBasedUpgrade, seedImage, imageListFile string) error {
+	if err := prep.SetupStateroot(ctx, r.Log, r.Ops, r.OstreeClient, r.RPMOstreeClient, seedImage,
+		ibu.Spec.SeedImageRef.Version, imageListFile, false, func(progress string) {
+			r.PrepTask.Progress = "Setting up stateroot: " + progress
+		}); err != nil {
 		return fmt.Errorf("failed to setup stateroot: %w", err)
 	}
 
@@ -268,7 +454,7 @@ func (r *ImageBasedUpgradeReconciler) SetupStateroot(ctx context.Context, ibu *l
 	return nil
 }
 
-func (r *ImageBasedUpgradeReconciler) verifyPrecachingCompleteFunc(retries int, interval time.Duration) wait.ConditionWithContextFunc {
+func (r *ImageBasedUpgradeReconciler) verifyPrecachingCompleteFunc(ibu *lcav1alpha1.ImageBasedUpgrade, retries int, interval time.Duration) wait.ConditionWithContextFunc {
 	return func(ctx context.Context) (bool, error) {
 		r.Log.Info("Querying pre-caching job for completion...")
 		for retry := 0; retry < retries; retry++ {
@@ -280,6 +466,7 @@ func (r *ImageBasedUpgradeReconciler) verifyPrecachingCompleteFunc(retries int,
 				if status.Message != "" {
 					r.PrepTask.Progress = fmt.Sprintf("Precaching progress: %s", status.Message)
 				}
+				metrics.SetIBUPrecacheImages(ibu, status.Progress.Total, status.Progress.Pulled, status.Progress.Skipped, status.Progress.Failed)
 				if status.Status == precache.Succeeded {
 					// precaching job succeeded
 					return true, nil
@@ -296,10 +483,67 @@ func (r *ImageBasedUpgradeReconciler) verifyPrecachingCompleteFunc(retries int,
 	}
 }
 
+// runPrepStep runs a checkpointed Prep step. Steps already completed, e.g. before a restart of the
+// lifecycle-agent, are skipped. Steps that were started but never completed are cleaned up before
+// being rerun
+func (r *ImageBasedUpgradeReconciler) runPrepStep(ctx context.Context, checkpoint *prep.Checkpoint, step prep.Step,
+	cleanup, run func() error) error {
+	if checkpoint.IsCompleted(step) {
+		r.Log.Info("Prep step already completed, skipping", "step", step)
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("context canceled before prep step %s: %w", step, ctx.Err())
+	default:
+	}
+
+	if checkpoint.IsInterrupted(step) && cleanup != nil {
+		r.Log.Info("Prep step was interrupted, cleaning up before running it again", "step", step)
+		if err := cleanup(); err != nil {
+			return fmt.Errorf("failed to cleanup interrupted prep step %s: %w", step, err)
+		}
+	}
+
+	if err := checkpoint.MarkStarted(step); err != nil {
+		return err
+	}
+	if err := run(); err != nil {
+		return err
+	}
+	return checkpoint.MarkCompleted(step)
+}
+
+// runPrepHook runs the user hook configured at the point, if any, and waits for its Job to complete. A failure of a
+// hook with the Ignore failure policy doesn't fail the Prep stage
+func (r *ImageBasedUpgradeReconciler) runPrepHook(ctx context.Context, ibu *lcav1alpha1.ImageBasedUpgrade, point hooks.Point) error {
+	hook := hooks.HookFor(ibu.Spec.Hooks, point)
+	if hook == nil {
+		return nil
+	}
+
+	r.PrepTask.Progress = fmt.Sprintf("Running %s hook", point)
+	template, err := r.Hooks.LoadTemplate(ctx, hook)
+	if err != nil {
+		return fmt.Errorf("failed to load %s hook job template: %w", point, err)
+	}
+	if err := r.Hooks.Wait(ctx, point, hook, template, r.PrepTask.HookAttempt, 10*time.Second); err != nil {
+		if hooks.IsFailedError(err) && hook.FailurePolicy == lcav1alpha1.HookFailurePolicies.Ignore {
+			r.Log.Info("Ignoring hook failure", "hook", point, "error", err.Error())
+			return nil
+		}
+		return err //nolint:wrapcheck
+	}
+	r.PrepTask.Progress = fmt.Sprintf("Successfully ran %s hook", point)
+	return nil
+}
+
 func (r *ImageBasedUpgradeReconciler) prepStageWorker(ctx context.Context, ibu *lcav1alpha1.ImageBasedUpgrade) (err error) {
 	var (
 		derivedCtx context.Context
 		errGroup   errgroup.Group
+		checkpoint *prep.Checkpoint
 	)
 
 	// Create a new context for the worker, derived from the original context
@@ -307,47 +551,122 @@ func (r *ImageBasedUpgradeReconciler) prepStageWorker(ctx context.Context, ibu *
 	defer r.PrepTask.Cancel() // Ensure that the cancel function is called when the prepStageWorker function exits
 
 	errGroup.Go(func() error {
-		var ok bool
 		imageListFile := filepath.Join(utils.IBUWorkspacePath, "image-list-file")
 
-		// check spec against this cluster's version and possibly exit early
-		if err := r.validateSeedOcpVersion(ibu.Spec.SeedImageRef.Version); err != nil {
-			return fmt.Errorf("failed to validate seed image OCP version in spec: %w", err)
+		// check that the upgrade to the seed version is supported, and report the path allowing it
+		upgradePath, err := r.validateUpgradePath(derivedCtx, ibu)
+		if err != nil {
+			return fmt.Errorf("failed to validate upgrade path: %w", err)
 		}
+		r.Log.Info("Upgrade path validated", "path", upgradePath.String())
+		r.PrepTask.Progress = fmt.Sprintf("Upgrade path validated: %s", upgradePath)
+		r.PrepTask.FromVersion = upgradePath.Versions[0]
 
-		// Pull seed image
-		select {
-		case <-derivedCtx.Done():
-			return fmt.Errorf("context canceled before pulling seed image: %w", derivedCtx.Err())
-		default:
+		// Load the checkpoint of a previous run of the worker, if any, to resume from the next step
+		checkpoint, err = prep.LoadCheckpoint(common.PathOutsideChroot(utils.PrepCheckpointFile),
+			ibu.Spec.SeedImageRef.Image, ibu.Spec.SeedImageRef.Version)
+		if err != nil {
+			return fmt.Errorf("failed to load prep checkpoint: %w", err)
+		}
+
+		// Refuse to prepare the upgrade of an unhealthy cluster, as the upgrade failure would otherwise be blamed on the
+		// new stateroot
+		if err = r.runPrepStep(derivedCtx, checkpoint, prep.Steps.ClusterHealthCheck, nil, func() error {
+			if ibu.Spec.HealthChecks.DisabledPreUpgrade {
+				return nil
+			}
+			r.PrepTask.Progress = "Checking cluster health"
+			return CheckClusterHealth(derivedCtx, r.Client, r.Log, &ibu.Spec.HealthChecks) //nolint:wrapcheck
+		}); err != nil {
+			return err
+		}
+
+		// Run the user hook before anything is changed on the node. An interrupted hook Job keeps running,
+		// so it is waited on again after a restart
+		if err = r.runPrepStep(derivedCtx, checkpoint, prep.Steps.PrePrepHook, nil, func() error {
+			return r.runPrepHook(derivedCtx, ibu, hooks.Points.PrePrep)
+		}); err != nil {
+			return err
+		}
+
+		// Check that there is room for the seed image, the new stateroot and the images to precache before pulling
+		// anything
+		if err = r.runPrepStep(derivedCtx, checkpoint, prep.Steps.DiskSpaceCheck, nil, func() error {
+			r.PrepTask.Progress = "Checking disk space"
+			return r.checkPrepDiskSpace(derivedCtx, ibu)
+		}); err != nil {
+			return err
+		}
+
+		// Pull seed image by the digest its tag points to, so that the following steps use the same image even if
+		// the tag is moved in the meantime
+		var seedImage string
+		if err = r.runPrepStep(derivedCtx, checkpoint, prep.Steps.SeedImagePull, nil, func() error {
 			r.PrepTask.Progress = "Pulling seed image"
-			if err = r.getSeedImage(derivedCtx, ibu); err != nil {
+			digest, err := resolveSeedImageDigest(derivedCtx, r.Client, r.Ops, ibu)
+			if err != nil {
+				return fmt.Errorf("failed to resolve seed image digest: %w", err)
+			}
+			if seedImage, err = prep.PinImage(ibu.Spec.SeedImageRef.Image, digest); err != nil {
+				return err //nolint:wrapcheck
+			}
+			if err := r.getSeedImage(derivedCtx, ibu, seedImage); err != nil {
 				return fmt.Errorf("failed to pull seed image: %w", err)
 			}
-			r.Log.Info("Successfully pulled seed image")
+			if err := checkpoint.SetSeedImageDigest(digest); err != nil {
+				return err //nolint:wrapcheck
+			}
+			r.Log.Info("Successfully pulled seed image", "image", seedImage)
 			r.PrepTask.Progress = "Successfully pulled seed image"
+			return nil
+		}); err != nil {
+			return err
+		}
+		if seedImage == "" {
+			// The image was pulled before a restart of the lifecycle-agent
+			if seedImage, err = prep.PinImage(ibu.Spec.SeedImageRef.Image, checkpoint.SeedImageDigest); err != nil {
+				return fmt.Errorf("failed to get the seed image digest from the prep checkpoint: %w", err)
+			}
+		}
+
+		// Check seed image compatibility
+		if err = r.runPrepStep(derivedCtx, checkpoint, prep.Steps.SeedImageCompatibility, nil, func() error {
+			r.PrepTask.Progress = "Checking seed image compatibility"
+			if err := r.checkSeedImageCompatibility(derivedCtx, ibu, seedImage); err != nil {
+				return fmt.Errorf("checking seed image compatibility: %w", err)
+			}
+			return nil
+		}); err != nil {
+			return err
 		}
 
 		// Setup state-root
-		select {
-		case <-derivedCtx.Done():
-			return fmt.Errorf("context canceled before setting up stateroot: %w", derivedCtx.Err())
-		default:
+		if err = r.runPrepStep(derivedCtx, checkpoint, prep.Steps.StaterootSetup, func() error {
+			// Remove the partially created stateroot. The seed image is removed once the stateroot
+			// setup ends, so make sure it is present before running the setup again
+			if err := r.cleanupUnbootedStateroot(common.GetDesiredStaterootName(ibu)); err != nil {
+				return err
+			}
+			return r.getSeedImage(derivedCtx, ibu, seedImage)
+		}, func() error {
 			r.PrepTask.Progress = "Setting up stateroot"
-			if err = r.SetupStateroot(derivedCtx, ibu, imageListFile); err != nil {
+			if err := r.SetupStateroot(derivedCtx, ibu, seedImage, imageListFile); err != nil {
 				return fmt.Errorf("failed to setup stateroot with prep stage worker: %w", err)
 			}
 			r.Log.Info("Successfully setup stateroot")
 			r.PrepTask.Progress = "Successfully setup stateroot"
+			return nil
+		}); err != nil {
+			return err
 		}
 
 		// Launch precaching job
-		select {
-		case <-derivedCtx.Done():
-			return fmt.Errorf("context canceled before creating precaching job: %w", derivedCtx.Err())
-		default:
+		if err = r.runPrepStep(derivedCtx, checkpoint, prep.Steps.PrecacheLaunch, func() error {
+			// Remove the precaching resources that may have been created before the interruption
+			return r.Precache.Cleanup(derivedCtx)
+		}, func() error {
 			r.PrepTask.Progress = "Creating precaching job"
-			ok, err = r.launchPrecaching(derivedCtx, imageListFile, ibu)
+			ok, err := r.launchPrecaching(derivedCtx, imageListFile, ibu)
 			if err != nil {
 				return fmt.Errorf("failed to launch pre-caching phase: %w", err)
 			}
@@ -356,20 +675,36 @@ func (r *ImageBasedUpgradeReconciler) prepStageWorker(ctx context.Context, ibu *
 			}
 			r.Log.Info("Successfully created precaching job")
 			r.PrepTask.Progress = "Successfully created precaching job"
+			return nil
+		}); err != nil {
+			return err
 		}
 
-		// Wait for precaching job to complete
-		r.PrepTask.Progress = "Waiting for precaching job to complete"
-		interval := 30 * time.Second
-		if err = wait.PollUntilContextCancel(derivedCtx, interval, false, r.verifyPrecachingCompleteFunc(5, interval)); err != nil {
-			return fmt.Errorf("failed to precache images: %w", err)
+		// Wait for precaching job to complete. The job keeps running if the worker is interrupted,
+		// so there is nothing to cleanup before waiting on it again
+		if err = r.runPrepStep(derivedCtx, checkpoint, prep.Steps.PrecacheWait, nil, func() error {
+			r.PrepTask.Progress = "Waiting for precaching job to complete"
+			interval := 30 * time.Second
+			if err := wait.PollUntilContextCancel(derivedCtx, interval, false, r.verifyPrecachingCompleteFunc(ibu, 5, interval)); err != nil {
+				return fmt.Errorf("failed to precache images: %w", err)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		if err = r.runPrepStep(derivedCtx, checkpoint, prep.Steps.PostPrepHook, nil, func() error {
+			return r.runPrepHook(derivedCtx, ibu, hooks.Points.PostPrep)
+		}); err != nil {
+			return err
 		}
 
 		// Fetch final precaching job report summary
-		msg := "Prep completed successfully"
+		msg := fmt.Sprintf("Prep completed successfully. Upgrade path: %s", upgradePath)
 		status, err := r.Precache.QueryJobStatus(ctx)
 		if err == nil && status != nil && status.Message != "" {
 			r.Log.Info(msg, "summary", status.Message)
+			r.PrepTask.PrecacheSummary = status.Message
 		}
 		r.PrepTask.Progress = msg
 
@@ -379,6 +714,35 @@ func (r *ImageBasedUpgradeReconciler) prepStageWorker(ctx context.Context, ibu *
 
 	if err := errGroup.Wait(); err != nil {
 		r.PrepTask.Progress = fmt.Sprintf("Prep failed with error: %v", err)
+		if derivedCtx.Err() == nil {
+			// Not canceled by an abort, so count the failure against the step that was running
+			reason := "Unknown"
+			switch {
+			case ops.IsInsufficientDiskSpaceError(err):
+				reason = "InsufficientDiskSpace"
+				r.PrepTask.FailureReason = utils.ConditionReasons.InsufficientDiskSpace
+			case upgradepath.IsRejectedError(err):
+				reason = "UpgradePathRejected"
+				r.PrepTask.FailureReason = utils.ConditionReasons.UpgradePathRejected
+			case healthcheck.IsNotHealthyError(err):
+				reason = "ClusterNotHealthy"
+				r.PrepTask.FailureReason = utils.ConditionReasons.ClusterNotHealthy
+			case hooks.IsFailedError(err):
+				reason = "HookFailed"
+				r.PrepTask.FailureReason = utils.ConditionReasons.HookFailed
+			case prep.IsSeedImageVerificationError(err):
+				reason = "SeedImageVerification"
+				r.PrepTask.FailureReason = utils.ConditionReasons.SeedImageVerificationFailed
+			case prep.IsOperatorIncompatibilityError(err):
+				reason = "OperatorIncompatibility"
+				r.PrepTask.FailureReason = utils.ConditionReasons.OperatorIncompatible
+			case checkpoint != nil:
+				if step, ok := checkpoint.InterruptedStep(); ok {
+					reason = string(step)
+				}
+			}
+			metrics.IncIBUFailure(ibu, lcav1alpha1.Stages.Prep, reason)
+		}
 		return fmt.Errorf("encountered error while running prep-stage worker goroutine: %w", err)
 	}
 
@@ -410,7 +774,16 @@ func (r *ImageBasedUpgradeReconciler) handlePrep(ctx context.Context, ibu *lcav1
 		r.PrepTask.done = make(chan struct{})
 		r.PrepTask.Active = true
 		r.PrepTask.Success = false
+		r.PrepTask.FailureReason = ""
+		r.PrepTask.PrecacheDiskSpaceChecked = false
+		r.PrepTask.HookAttempt = utils.CurrentAttempt(ibu)
 		r.PrepTask.Progress = "Prep stage initialized"
+		if _, err = os.Stat(common.PathOutsideChroot(utils.PrepCheckpointFile)); err == nil {
+			// The worker was interrupted, e.g. by a restart of the lifecycle-agent, and resumes from its checkpoint
+			r.Log.Info("Prep checkpoint found, resuming prep stage")
+			r.PrepTask.Progress = "Prep stage resumed"
+		}
+		err = nil
 		go func() {
 			err = r.prepStageWorker(ctx, ibu)
 			close(r.PrepTask.done)
@@ -425,12 +798,15 @@ func (r *ImageBasedUpgradeReconciler) handlePrep(ctx context.Context, ibu *lcav1
 		utils.SetPrepStatusInProgress(ibu, r.PrepTask.Progress)
 		result = requeueWithShortInterval()
 	case r.PrepTask.Active:
+		r.setSeedImageDigest(ibu)
+		utils.SetHistoryFromVersion(ibu, r.PrepTask.FromVersion)
+		utils.SetHistoryPrecacheSummary(ibu, r.PrepTask.PrecacheSummary)
 		select {
 		case <-r.PrepTask.done:
 			if r.PrepTask.Success {
 				utils.SetPrepStatusCompleted(ibu, r.PrepTask.Progress)
 			} else {
-				utils.SetPrepStatusFailed(ibu, r.PrepTask.Progress)
+				utils.SetPrepStatusFailedWithReason(ibu, r.PrepTask.FailureReason, r.PrepTask.Progress)
 			}
 			// Reset Task values
 			r.PrepTask.Reset()
@@ -444,6 +820,18 @@ func (r *ImageBasedUpgradeReconciler) handlePrep(ctx context.Context, ibu *lcav1
 	return
 }
 
+// setSeedImageDigest reports the digest of the seed image in the IBU status, once the prep stage worker has pulled
+// the image and recorded its digest in the checkpoint
+func (r *ImageBasedUpgradeReconciler) setSeedImageDigest(ibu *lcav1alpha1.ImageBasedUpgrade) {
+	checkpoint, err := prep.LoadCheckpoint(common.PathOutsideChroot(utils.PrepCheckpointFile),
+		ibu.Spec.SeedImageRef.Image, ibu.Spec.SeedImageRef.Version)
+	if err != nil {
+		r.Log.Error(err, "Failed to read the seed image digest from the prep checkpoint")
+		return
+	}
+	ibu.Status.SeedImageDigest = checkpoint.SeedImageDigest
+}
+
 func getSeedManifestPath(osname string) string {
 	return filepath.Join(
 		common.GetStaterootPath(osname),
@@ -1,71 +1,190 @@
 package controllers
 
 import (
-	"fmt"
+	"context"
+	"testing"
+
+	"github.com/blang/semver/v4"
 	"github.com/go-logr/logr"
-	configv1 "github.com/openshift/api/config/v1"
+	lcav1alpha1 "github.com/openshift-kni/lifecycle-agent/api/v1alpha1"
+	"github.com/openshift-kni/lifecycle-agent/controllers/utils"
+	"github.com/openshift-kni/lifecycle-agent/internal/prep"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/seedcontent"
+	"github.com/operator-framework/api/pkg/lib/version"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
 	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
-	"testing"
 )
 
-func TestImageBasedUpgradeReconciler_validateSeedOcpVersion(t *testing.T) {
-	// fake client setup
-	s := scheme.Scheme
-	s.AddKnownTypes(configv1.GroupVersion, &configv1.ClusterVersion{})
-	version := &configv1.ClusterVersion{
-		ObjectMeta: metav1.ObjectMeta{
-			Name: "version",
+func TestImageBasedUpgradeReconciler_validateAdditionalImages(t *testing.T) {
+	cmRef := lcav1alpha1.ConfigMapRef{Name: "additional-images", Namespace: "default"}
+
+	tests := []struct {
+		name           string
+		configMap      *corev1.ConfigMap
+		wantValid      bool
+		wantImages     []string
+		wantErrMessage string
+	}{
+		{
+			name:           "configmap not found",
+			configMap:      nil,
+			wantValid:      false,
+			wantErrMessage: "additional images configMap default/additional-images not found",
+		},
+		{
+			name: "configmap without images",
+			configMap: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: cmRef.Name, Namespace: cmRef.Namespace},
+				Data:       map[string]string{"images.txt": "\n# comment only\n"},
+			},
+			wantValid:      false,
+			wantErrMessage: "additional images configMap default/additional-images does not list any image",
 		},
-		Status: configv1.ClusterVersionStatus{
-			Desired: configv1.Release{
-				Version: "4.14.8",
+		{
+			name: "configmap with invalid image",
+			configMap: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: cmRef.Name, Namespace: cmRef.Namespace},
+				Data:       map[string]string{"images.txt": "quay.io/foo/bar:latest\nquay.io/foo/bar baz\n"},
+			},
+			wantValid:      false,
+			wantErrMessage: "additional images configMap default/additional-images contains an invalid image reference \"quay.io/foo/bar baz\": invalid reference format",
+		},
+		{
+			name: "configmap with malformed image",
+			configMap: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: cmRef.Name, Namespace: cmRef.Namespace},
+				Data:       map[string]string{"images.txt": "quay.io/Foo/bar:latest\n"},
+			},
+			wantValid:      false,
+			wantErrMessage: "additional images configMap default/additional-images contains an invalid image reference \"quay.io/Foo/bar:latest\": invalid reference format: repository name (Foo/bar) must be lowercase",
+		},
+		{
+			name: "configmap with images across multiple keys",
+			configMap: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: cmRef.Name, Namespace: cmRef.Namespace},
+				Data: map[string]string{
+					"b": "quay.io/foo/app2:v1\nquay.io/foo/app1:v1\n",
+					"a": "quay.io/foo/app1:v1\n\n",
+				},
 			},
+			wantValid:  true,
+			wantImages: []string{"quay.io/foo/app1:v1", "quay.io/foo/app2:v1"},
 		},
 	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ibu := &lcav1alpha1.ImageBasedUpgrade{
+				ObjectMeta: metav1.ObjectMeta{Name: utils.IBUName},
+				Spec: lcav1alpha1.ImageBasedUpgradeSpec{
+					Stage:            lcav1alpha1.Stages.Prep,
+					AdditionalImages: cmRef,
+				},
+			}
+
+			builder := fake.NewClientBuilder().WithScheme(testscheme)
+			if tt.configMap != nil {
+				builder = builder.WithObjects(tt.configMap)
+			}
+			r := &ImageBasedUpgradeReconciler{
+				Client: builder.Build(),
+				Log:    logr.Discard(),
+			}
+
+			valid, err := r.validateIBUSpec(context.Background(), ibu)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantValid, valid)
+			if !tt.wantValid {
+				cond := meta.FindStatusCondition(ibu.Status.Conditions, string(utils.ConditionTypes.PrepInProgress))
+				assert.NotNil(t, cond)
+				assert.Equal(t, tt.wantErrMessage, cond.Message)
+				return
+			}
+
+			images, err := r.getAdditionalImages(context.Background(), ibu)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantImages, images)
+		})
+	}
+}
 
-	type args struct {
-		seedOcpVersion string
+func TestImageBasedUpgradeReconciler_checkOperatorCompatibility(t *testing.T) {
+	s := runtime.NewScheme()
+	assert.NoError(t, operatorsv1alpha1.AddToScheme(s))
+	objs := []client.Object{
+		&operatorsv1alpha1.ClusterServiceVersion{
+			ObjectMeta: metav1.ObjectMeta{Name: "sriov-network-operator.v4.16.0", Namespace: "openshift-sriov-network-operator"},
+			Spec:       operatorsv1alpha1.ClusterServiceVersionSpec{Version: version.OperatorVersion{Version: semver.MustParse("4.16.0")}},
+		},
+		&operatorsv1alpha1.Subscription{
+			ObjectMeta: metav1.ObjectMeta{Name: "sriov-network-operator", Namespace: "openshift-sriov-network-operator"},
+			Spec:       &operatorsv1alpha1.SubscriptionSpec{Package: "sriov-network-operator", Channel: "stable"},
+			Status:     operatorsv1alpha1.SubscriptionStatus{InstalledCSV: "sriov-network-operator.v4.16.0"},
+		},
 	}
-	tests := []struct {
-		name       string
-		args       args
-		wantErr    assert.ErrorAssertionFunc
-		wantErrMsg string
+	seedContent := func(operators ...seedcontent.Operator) map[string]string {
+		annotations, err := (&seedcontent.SeedContent{Operators: operators}).Annotations()
+		assert.NoError(t, err)
+		return annotations
+	}
+	sriov := seedcontent.Operator{Name: "sriov-network-operator.v4.16.0", Namespace: "openshift-sriov-network-operator",
+		Version: "4.16.0", Package: "sriov-network-operator", Channel: "stable"}
+
+	testcases := []struct {
+		name        string
+		policy      lcav1alpha1.OperatorCompatibilityPolicy
+		annotations map[string]string
+		wantErr     bool
+		wantEvent   string
 	}{
 		{
-			name:    "when seed OCP is greater than target cluster",
-			args:    args{seedOcpVersion: "4.14.9"},
-			wantErr: assert.NoError,
+			name:        "compatible",
+			policy:      lcav1alpha1.OperatorCompatibilityPolicies.Fail,
+			annotations: seedContent(sriov),
 		},
 		{
-			name:       "when seed OCP is equal to target cluster, version also contains metadata",
-			args:       args{seedOcpVersion: "4.14.8+metadata"},
-			wantErr:    assert.Error,
-			wantErrMsg: "seed OCP version (4.14.8+metadata) must be higher than current OCP version (4.14.8)",
+			name:        "seed image without operator inventory",
+			policy:      lcav1alpha1.OperatorCompatibilityPolicies.Fail,
+			annotations: map[string]string{},
 		},
 		{
-			name:       "when seed OCP is less to target cluster, version is also pre release",
-			args:       args{seedOcpVersion: "4.14.7-rc.1"},
-			wantErr:    assert.Error,
-			wantErrMsg: "seed OCP version (4.14.7-rc.1) must be higher than current OCP version (4.14.8)",
+			name:        "missing operator fails",
+			policy:      lcav1alpha1.OperatorCompatibilityPolicies.Fail,
+			annotations: seedContent(),
+			wantErr:     true,
+		},
+		{
+			name:        "missing operator warns by default",
+			annotations: seedContent(),
+			wantEvent:   "Warning OperatorIncompatibility operator openshift-sriov-network-operator/sriov-network-operator is missing from the seed image",
 		},
 	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
 
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(1)
 			r := &ImageBasedUpgradeReconciler{
-				Client: fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(version).Build(),
-				Log:    logr.Logger{},
+				Client:   fake.NewClientBuilder().WithScheme(s).WithObjects(objs...).Build(),
+				Log:      logr.Logger{},
+				Recorder: recorder,
 			}
+			ibu := &lcav1alpha1.ImageBasedUpgrade{Spec: lcav1alpha1.ImageBasedUpgradeSpec{OperatorCompatibility: tc.policy}}
 
-			err := r.validateSeedOcpVersion(tt.args.seedOcpVersion)
-			tt.wantErr(t, err, fmt.Sprintf("validateSeedOcpVersion(%v)", tt.args.seedOcpVersion))
-			if err != nil {
-				assert.Equal(t, tt.wantErrMsg, err.Error())
+			err := r.checkOperatorCompatibility(context.Background(), ibu, tc.annotations)
+			assert.Equal(t, tc.wantErr, prep.IsOperatorIncompatibilityError(err))
+			if !tc.wantErr {
+				assert.NoError(t, err)
+			}
+			if tc.wantEvent != "" {
+				assert.Equal(t, tc.wantEvent, <-recorder.Events)
 			}
+			assert.Empty(t, recorder.Events)
 		})
 	}
 }
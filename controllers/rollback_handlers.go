@@ -18,10 +18,14 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 	"path/filepath"
 
 	"github.com/openshift-kni/lifecycle-agent/controllers/utils"
 	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/internal/hooks"
+	"github.com/openshift-kni/lifecycle-agent/internal/metrics"
+	"github.com/openshift-kni/lifecycle-agent/internal/notify"
 	lcautils "github.com/openshift-kni/lifecycle-agent/utils"
 	ctrl "sigs.k8s.io/controller-runtime"
 
@@ -29,18 +33,39 @@ import (
 	corev1 "k8s.io/api/core/v1"
 )
 
+// rollbackSteps defines the names of the Rollback steps, as reported in failure metrics
+var rollbackSteps = struct {
+	StaterootCheck       string
+	RemountSysroot       string
+	SetDefaultDeployment string
+	SaveIBU              string
+	Reboot               string
+	PostRollbackHook     string
+}{
+	StaterootCheck:       "StaterootCheck",
+	RemountSysroot:       "RemountSysroot",
+	SetDefaultDeployment: "SetDefaultDeployment",
+	SaveIBU:              "SaveIBU",
+	Reboot:               "Reboot",
+	PostRollbackHook:     "PostRollbackHook",
+}
+
 //nolint:unparam
 func (r *ImageBasedUpgradeReconciler) startRollback(ctx context.Context, ibu *lcav1alpha1.ImageBasedUpgrade) (ctrl.Result, error) {
 	utils.SetRollbackStatusInProgress(ibu, "Initiating rollback")
+	// The automatic rollbacks record their own reason, this one is requested by the user
+	utils.SetHistoryRollbackReason(ibu, utils.ManualRollbackReason)
 
 	stateroot, err := r.RPMOstreeClient.GetUnbootedStaterootName()
 	if err != nil {
 		utils.SetRollbackStatusFailed(ibu, err.Error())
+		metrics.IncIBUFailure(ibu, lcav1alpha1.Stages.Rollback, rollbackSteps.StaterootCheck)
 		return doNotRequeue(), nil
 	}
 
 	if err := r.Ops.RemountSysroot(); err != nil {
 		utils.SetRollbackStatusFailed(ibu, err.Error())
+		metrics.IncIBUFailure(ibu, lcav1alpha1.Stages.Rollback, rollbackSteps.RemountSysroot)
 		return doNotRequeue(), nil
 	}
 
@@ -48,6 +73,7 @@ func (r *ImageBasedUpgradeReconciler) startRollback(ctx context.Context, ibu *lc
 	deploymentIndex, err := r.RPMOstreeClient.GetUnbootedDeploymentIndex()
 	if err != nil {
 		utils.SetRollbackStatusFailed(ibu, err.Error())
+		metrics.IncIBUFailure(ibu, lcav1alpha1.Stages.Rollback, rollbackSteps.SetDefaultDeployment)
 		return doNotRequeue(), nil
 	}
 
@@ -59,6 +85,7 @@ func (r *ImageBasedUpgradeReconciler) startRollback(ctx context.Context, ibu *lc
 
 		if err = r.OstreeClient.SetDefaultDeployment(deploymentIndex); err != nil {
 			utils.SetRollbackStatusFailed(ibu, err.Error())
+			metrics.IncIBUFailure(ibu, lcav1alpha1.Stages.Rollback, rollbackSteps.SetDefaultDeployment)
 			return doNotRequeue(), nil
 		}
 	} else {
@@ -82,6 +109,13 @@ func (r *ImageBasedUpgradeReconciler) startRollback(ctx context.Context, ibu *lc
 	filePath := common.PathOutsideChroot(filepath.Join(common.GetStaterootPath(stateroot), utils.IBUFilePath))
 	if err := lcautils.MarshalToFile(ibu, filePath); err != nil {
 		utils.SetRollbackStatusFailed(ibu, err.Error())
+		metrics.IncIBUFailure(ibu, lcav1alpha1.Stages.Rollback, rollbackSteps.SaveIBU)
+		return doNotRequeue(), nil
+	}
+	// Carry the pending notifications over to the original stateroot
+	if err := notify.NewOutbox(notificationsPath).CopyTo(common.PathOutsideChroot(filepath.Join(common.GetStaterootPath(stateroot), notify.Dir))); err != nil {
+		utils.SetRollbackStatusFailed(ibu, err.Error())
+		metrics.IncIBUFailure(ibu, lcav1alpha1.Stages.Rollback, rollbackSteps.SaveIBU)
 		return doNotRequeue(), nil
 	}
 
@@ -92,14 +126,37 @@ func (r *ImageBasedUpgradeReconciler) startRollback(ctx context.Context, ibu *lc
 		//todo: abort handler? e.g delete desired stateroot
 		r.Log.Error(err, "")
 		utils.SetUpgradeStatusFailed(ibu, err.Error())
+		metrics.IncIBUFailure(ibu, lcav1alpha1.Stages.Rollback, rollbackSteps.Reboot)
 		return doNotRequeue(), nil
 	}
 
 	return doNotRequeue(), nil
 }
 
-//nolint:unparam
 func (r *ImageBasedUpgradeReconciler) finishRollback(ctx context.Context, ibu *lcav1alpha1.ImageBasedUpgrade) (ctrl.Result, error) {
+	// Run the user hook once the original stateroot is back. There is nothing left to roll back, so any failure
+	// policy but Ignore fails the Rollback stage
+	if hook := ibu.Spec.Hooks.PostRollback; hook != nil {
+		template, err := r.Hooks.LoadTemplate(ctx, hook)
+		if err != nil {
+			return requeueWithError(fmt.Errorf("error while loading %s hook job template: %w", hooks.Points.PostRollback, err))
+		}
+		done, err := r.Hooks.Run(ctx, hooks.Points.PostRollback, hook, template, utils.CurrentAttempt(ibu))
+		switch {
+		case hooks.IsFailedError(err) && hook.FailurePolicy == lcav1alpha1.HookFailurePolicies.Ignore:
+			r.Log.Info("Ignoring hook failure", "hook", hooks.Points.PostRollback, "error", err.Error())
+		case hooks.IsFailedError(err):
+			utils.SetRollbackStatusFailed(ibu, err.Error())
+			metrics.IncIBUFailure(ibu, lcav1alpha1.Stages.Rollback, rollbackSteps.PostRollbackHook)
+			return doNotRequeue(), nil
+		case err != nil:
+			return requeueWithError(fmt.Errorf("error while running %s hook: %w", hooks.Points.PostRollback, err))
+		case !done:
+			utils.SetRollbackStatusInProgress(ibu, fmt.Sprintf("Waiting for %s hook job to complete", hooks.Points.PostRollback))
+			return requeueWithShortInterval(), nil
+		}
+	}
+
 	utils.SetRollbackStatusCompleted(ibu)
 
 	return doNotRequeue(), nil
@@ -111,6 +168,7 @@ func (r *ImageBasedUpgradeReconciler) handleRollback(ctx context.Context, ibu *l
 	if err != nil {
 		//todo: abort handler? e.g delete desired stateroot
 		utils.SetRollbackStatusFailed(ibu, err.Error())
+		metrics.IncIBUFailure(ibu, lcav1alpha1.Stages.Rollback, rollbackSteps.StaterootCheck)
 		return doNotRequeue(), nil
 	}
 
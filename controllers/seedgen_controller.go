@@ -30,11 +30,13 @@ import (
 	"github.com/openshift-kni/lifecycle-agent/controllers/utils"
 	"github.com/openshift-kni/lifecycle-agent/internal/common"
 	"github.com/openshift-kni/lifecycle-agent/internal/healthcheck"
+	"github.com/openshift-kni/lifecycle-agent/internal/metrics"
 	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
 	commonUtils "github.com/openshift-kni/lifecycle-agent/utils"
 	lcautils "github.com/openshift-kni/lifecycle-agent/utils"
 
 	"github.com/go-logr/logr"
+	configv1 "github.com/openshift/api/config/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -73,6 +75,8 @@ var (
 	lcaImage               string
 	seedgenAuthFile        = filepath.Join(utils.SeedgenWorkspacePath, "auth.json")
 	storedManagedClusterCR = filepath.Join(utils.SeedgenWorkspacePath, "managedcluster.json")
+	seedgenCosignKeyFile   = filepath.Join(utils.SeedgenWorkspacePath, common.CosignPrivateKeyKey)
+	seedgenCosignPassFile  = filepath.Join(utils.SeedgenWorkspacePath, common.CosignPasswordKey)
 	lcaCliContainerName    = "lca_image_builder"
 )
 
@@ -467,7 +471,7 @@ func (r *SeedGeneratorReconciler) launchLCACli(seedgen *seedgenv1alpha1.SeedGene
 		"podman", "run", "--privileged", "--pid=host",
 		fmt.Sprintf("--name=%s", lcaCliContainerName),
 		"--replace", "--net=host",
-		"-v", "/etc:/etc", "-v", "/var:/var", "-v", "/var/run:/var/run", "-v", "/run/systemd/journal/socket:/run/systemd/journal/socket",
+		"-v", "/etc:/etc", "-v", "/var:/var", "-v", "/ostree:/ostree:ro", "-v", "/var/run:/var/run", "-v", "/run/systemd/journal/socket:/run/systemd/journal/socket",
 		"-v", fmt.Sprintf("%s:%s", seedgenAuthFile, seedgenAuthFile),
 		"--entrypoint", "lca-cli",
 		lcaImage,
@@ -481,6 +485,17 @@ func (r *SeedGeneratorReconciler) launchLCACli(seedgen *seedgenv1alpha1.SeedGene
 		lcaCliCmdArgs = append(lcaCliCmdArgs, "--skip-recert-validation")
 	}
 
+	if seedgen.Spec.BaseSeedImage != "" {
+		lcaCliCmdArgs = append(lcaCliCmdArgs, "--base-image", seedgen.Spec.BaseSeedImage)
+	}
+
+	if seedgen.Spec.Signing != nil {
+		lcaCliCmdArgs = append(lcaCliCmdArgs, "--sign-by-sigstore-private-key", seedgenCosignKeyFile)
+		if _, err := os.Stat(common.PathOutsideChroot(seedgenCosignPassFile)); err == nil {
+			lcaCliCmdArgs = append(lcaCliCmdArgs, "--sign-passphrase-file", seedgenCosignPassFile)
+		}
+	}
+
 	// In order to have the lca-cli container both survive the LCA pod shutdown and have continued network access
 	// after all other pods are shutdown, we're using systemd-run to launch it as a transient service-unit
 	systemdRunOpts := []string{"--collect", "--wait", "--unit", "lca-generate-seed-image"}
@@ -612,6 +627,30 @@ func (r *SeedGeneratorReconciler) wipeExistingWorkspace() error {
 	return nil
 }
 
+// writeSigningKey writes the cosign private key, and its optional password, from the signing secret to the workspace,
+// for the lca-cli to sign the seed image once pushed
+func (r *SeedGeneratorReconciler) writeSigningKey(ctx context.Context, signing *seedgenv1alpha1.SeedImageSigning) error {
+	secret := &corev1.Secret{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: signing.CosignKeyRef.Name, Namespace: common.LcaNamespace}, secret); err != nil {
+		return fmt.Errorf("could not access signing secret %s in %s: %w", signing.CosignKeyRef.Name, common.LcaNamespace, err)
+	}
+
+	key, exists := secret.Data[common.CosignPrivateKeyKey]
+	if !exists {
+		return fmt.Errorf("could not find %s in %s secret", common.CosignPrivateKeyKey, signing.CosignKeyRef.Name)
+	}
+	if err := os.WriteFile(common.PathOutsideChroot(seedgenCosignKeyFile), key, 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", seedgenCosignKeyFile, err)
+	}
+
+	if password, exists := secret.Data[common.CosignPasswordKey]; exists {
+		if err := os.WriteFile(common.PathOutsideChroot(seedgenCosignPassFile), password, 0o600); err != nil {
+			return fmt.Errorf("failed to write %s: %w", seedgenCosignPassFile, err)
+		}
+	}
+	return nil
+}
+
 // Generate the seed image
 func (r *SeedGeneratorReconciler) generateSeedImage(ctx context.Context, seedgen *seedgenv1alpha1.SeedGenerator, clusterName string) error {
 	if err := r.wipeExistingWorkspace(); err != nil {
@@ -650,6 +689,12 @@ func (r *SeedGeneratorReconciler) generateSeedImage(ctx context.Context, seedgen
 		return fmt.Errorf("could not find seedAuth in %s secret", utils.SeedGenSecretName)
 	}
 
+	if seedgen.Spec.Signing != nil {
+		if err := r.writeSigningKey(ctx, seedgen.Spec.Signing); err != nil {
+			return err
+		}
+	}
+
 	// Save the seedgen CR in order to restore it after the lca-cli is complete
 	if err := commonUtils.MarshalToFile(seedgen, common.PathOutsideChroot(utils.SeedGenStoredCR)); err != nil {
 		return fmt.Errorf("failed to write CR to %s: %w", utils.SeedGenStoredCR, err)
@@ -825,7 +870,7 @@ func (r *SeedGeneratorReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 
 	// Wait for system stability before doing anything
 	r.Log.Info("Checking system health")
-	if err = healthcheck.HealthChecks(r.Client, r.Log); err != nil {
+	if err = healthcheck.HealthChecks(r.Client, r.Log, nil); err != nil {
 		rc = fmt.Errorf("health check failed: %w", err)
 		return
 	}
@@ -868,6 +913,7 @@ func (r *SeedGeneratorReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 
 	if rejection := r.validateSystem(ctx); len(rejection) > 0 {
 		setSeedGenStatusFailed(seedgen, rejection)
+		metrics.IncSeedGenFailure(r.getSeedVersion(ctx), "SystemValidation")
 		r.Log.Info(fmt.Sprintf("Seed generation rejected: system validation failed: %s", rejection))
 
 		// Update status
@@ -888,7 +934,9 @@ func (r *SeedGeneratorReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		if err = r.generateSeedImage(ctx, seedgen, clusterName); err != nil {
 			r.Log.Error(err, "Seed generation failed")
 
+			start := seedGenStartTime(seedgen)
 			setSeedGenStatusFailed(seedgen, fmt.Sprintf("Seed generation failed: %s", err))
+			r.observeSeedGenResult(ctx, start, metrics.ResultFailed, "Generation")
 			if err = r.updateStatus(ctx, seedgen); err != nil {
 				r.Log.Error(err, "Failed to update status")
 			}
@@ -900,7 +948,9 @@ func (r *SeedGeneratorReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 		r.Log.Info("Completing Seed Generation")
 		if err = r.finishSeedgen(ctx, clusterName); err != nil {
 			r.Log.Error(err, "Seed generation failed")
+			start := seedGenStartTime(seedgen)
 			setSeedGenStatusFailed(seedgen, fmt.Sprintf("Seed generation failed: %s", err))
+			r.observeSeedGenResult(ctx, start, metrics.ResultFailed, "Completion")
 			if err = r.updateStatus(ctx, seedgen); err != nil {
 				r.Log.Error(err, "Failed to update status")
 			}
@@ -908,7 +958,9 @@ func (r *SeedGeneratorReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 			return
 		}
 
+		start := seedGenStartTime(seedgen)
 		setSeedGenStatusCompleted(seedgen)
+		r.observeSeedGenResult(ctx, start, metrics.ResultCompleted, "")
 	} else if isSeedGenCompleted(seedgen) {
 		r.Log.Info("Seed Generation is completed")
 	}
@@ -920,6 +972,40 @@ func (r *SeedGeneratorReconciler) Reconcile(ctx context.Context, req ctrl.Reques
 	return
 }
 
+// getSeedVersion returns the OCP version of this cluster, which is the version of the generated seed image.
+// It is only used to label metrics, so an empty version is returned if it can't be determined
+func (r *SeedGeneratorReconciler) getSeedVersion(ctx context.Context) string {
+	clusterVersion := &configv1.ClusterVersion{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Name: "version"}, clusterVersion); err != nil {
+		r.Log.Info(fmt.Sprintf("Unable to get cluster version for metrics: %s", err))
+		return ""
+	}
+	return clusterVersion.Status.Desired.Version
+}
+
+// observeSeedGenResult records the duration of a seed generation that completed or failed, along with
+// the failure reason
+func (r *SeedGeneratorReconciler) observeSeedGenResult(ctx context.Context, start time.Time, result, reason string) {
+	seedVersion := r.getSeedVersion(ctx)
+	if result == metrics.ResultFailed {
+		metrics.IncSeedGenFailure(seedVersion, reason)
+	}
+	if !start.IsZero() {
+		metrics.ObserveSeedGenDuration(seedVersion, result, start)
+	}
+}
+
+// seedGenStartTime returns when the seed generation started, based on the in-progress condition which
+// survives the restart of the lifecycle-agent by the lca-cli container. It must be read before the
+// condition is updated with the result
+func seedGenStartTime(seedgen *seedgenv1alpha1.SeedGenerator) time.Time {
+	condition := meta.FindStatusCondition(seedgen.Status.Conditions, string(utils.SeedGenConditionTypes.SeedGenInProgress))
+	if condition == nil || condition.Status != metav1.ConditionTrue {
+		return time.Time{}
+	}
+	return condition.LastTransitionTime.Time
+}
+
 // Utility functions for conditions/status
 func setSeedGenStatusFailed(seedgen *seedgenv1alpha1.SeedGenerator, msg string) {
 	utils.SetStatusCondition(&seedgen.Status.Conditions,
@@ -999,6 +1085,7 @@ func (r *SeedGeneratorReconciler) updateStatus(ctx context.Context, seedgen *see
 		return fmt.Errorf("failed to update seedgen status: %w", err)
 	}
 
+	metrics.RecordSeedGenStatus(seedgen, r.getSeedVersion(ctx))
 	return nil
 }
 
@@ -22,6 +22,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 	lcav1alpha1 "github.com/openshift-kni/lifecycle-agent/api/v1alpha1"
@@ -31,12 +32,20 @@ import (
 	"github.com/openshift-kni/lifecycle-agent/internal/common"
 	"github.com/openshift-kni/lifecycle-agent/internal/extramanifest"
 	"github.com/openshift-kni/lifecycle-agent/internal/healthcheck"
+	"github.com/openshift-kni/lifecycle-agent/internal/hooks"
+	"github.com/openshift-kni/lifecycle-agent/internal/metrics"
+	"github.com/openshift-kni/lifecycle-agent/internal/notify"
 	"github.com/openshift-kni/lifecycle-agent/internal/ostreeclient"
+	"github.com/openshift-kni/lifecycle-agent/internal/prep"
+	"github.com/openshift-kni/lifecycle-agent/internal/progress"
 	"github.com/openshift-kni/lifecycle-agent/internal/reboot"
+	"github.com/openshift-kni/lifecycle-agent/internal/upgradewindow"
 	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
 	rpmostreeclient "github.com/openshift-kni/lifecycle-agent/lca-cli/ostreeclient"
 	lcautils "github.com/openshift-kni/lifecycle-agent/utils"
+	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -62,11 +71,61 @@ type (
 		RPMOstreeClient rpmostreeclient.IClient
 		OstreeClient    ostreeclient.IClient
 		RebootClient    reboot.RebootIntf
+		Hooks           *hooks.Runner
+
+		// stepStarts tracks when each upgrade sub-step was first run, as some steps span several reconciles
+		stepStarts map[string]time.Time
 	}
 )
 
 const TargetOcpVersionLabel = "lca.openshift.io/target-ocp-version"
 
+// ReadyToPivotMessage is the Upgrade stage status while the upgrade gate is held
+const ReadyToPivotMessage = "Ready to pivot: set spec.upgradeGate to Release to reboot to the new stateroot"
+
+// upgradeSteps defines the names of the PrePivot and PostPivot sub-steps, as reported in metrics
+var upgradeSteps = struct {
+	StaterootCheck           string
+	SeedImageDigestCheck     string
+	ClusterHealthCheck       string
+	Backup                   string
+	ExportOadpConfiguration  string
+	ExportRestores           string
+	ExportExtraManifests     string
+	FetchClusterConfig       string
+	SaveIBU                  string
+	SetDefaultDeployment     string
+	Reboot                   string
+	HealthCheck              string
+	ApplyPolicyManifests     string
+	ApplyExtraManifests      string
+	RestoreOadpConfiguration string
+	Restore                  string
+	PrePivotHook             string
+	PostPivotHook            string
+	UpgradeWindow            string
+}{
+	StaterootCheck:           "StaterootCheck",
+	SeedImageDigestCheck:     "SeedImageDigestCheck",
+	ClusterHealthCheck:       "ClusterHealthCheck",
+	Backup:                   "Backup",
+	ExportOadpConfiguration:  "ExportOadpConfiguration",
+	ExportRestores:           "ExportRestores",
+	ExportExtraManifests:     "ExportExtraManifests",
+	FetchClusterConfig:       "FetchClusterConfig",
+	SaveIBU:                  "SaveIBU",
+	SetDefaultDeployment:     "SetDefaultDeployment",
+	Reboot:                   "Reboot",
+	HealthCheck:              "HealthCheck",
+	ApplyPolicyManifests:     "ApplyPolicyManifests",
+	ApplyExtraManifests:      "ApplyExtraManifests",
+	RestoreOadpConfiguration: "RestoreOadpConfiguration",
+	Restore:                  "Restore",
+	PrePivotHook:             "PrePivotHook",
+	PostPivotHook:            "PostPivotHook",
+	UpgradeWindow:            "UpgradeWindow",
+}
+
 // handleUpgrade orchestrate main upgrade steps and update status as needed
 func (r *ImageBasedUpgradeReconciler) handleUpgrade(ctx context.Context, ibu *lcav1alpha1.ImageBasedUpgrade) (ctrl.Result, error) {
 	r.Log.Info("Starting handleUpgrade")
@@ -76,6 +135,7 @@ func (r *ImageBasedUpgradeReconciler) handleUpgrade(ctx context.Context, ibu *lc
 	if err != nil {
 		//todo: abort handler? e.g delete desired stateroot
 		utils.SetUpgradeStatusFailed(ibu, err.Error())
+		metrics.IncIBUFailure(ibu, lcav1alpha1.Stages.Upgrade, upgradeSteps.StaterootCheck)
 		return doNotRequeue(), nil
 	}
 
@@ -103,18 +163,71 @@ func (u *UpgHandler) resetProgressMessage(ctx context.Context, ibu *lcav1alpha1.
 	_ = utils.UpdateIBUStatus(ctx, u.Client, ibu)
 }
 
+// startStep records the start of an upgrade sub-step, unless it was already started in a previous reconcile
+func (u *UpgHandler) startStep(step string) {
+	if u.stepStarts == nil {
+		u.stepStarts = make(map[string]time.Time)
+	}
+	if _, exists := u.stepStarts[step]; !exists {
+		u.stepStarts[step] = time.Now()
+	}
+}
+
+// completeStep records the duration of a successful upgrade sub-step
+func (u *UpgHandler) completeStep(ibu *lcav1alpha1.ImageBasedUpgrade, phase metrics.UpgradePhase, step string) {
+	if start, exists := u.stepStarts[step]; exists {
+		metrics.ObserveIBUUpgradeStep(ibu, phase, step, start)
+		delete(u.stepStarts, step)
+	}
+}
+
+// failStep counts the failure of the Upgrade stage at the given sub-step
+func (u *UpgHandler) failStep(ibu *lcav1alpha1.ImageBasedUpgrade, step string) {
+	delete(u.stepStarts, step)
+	metrics.IncIBUFailure(ibu, lcav1alpha1.Stages.Upgrade, step)
+}
+
 // prePivot executes all the pre-upgrade steps and initiates a cluster reboot.
 //
 // Note: All decisions, including reconciles and failures, should be made within this function.
 // The caller will simply return what this function returns.
 func (u *UpgHandler) PrePivot(ctx context.Context, ibu *lcav1alpha1.ImageBasedUpgrade) (ctrl.Result, error) {
+	// The checks are run again before the backups and the reboot, which may happen long after the start of the stage
+	// with an upgrade window or gate, unless they were already run in the same reconcile
+	checked := false
 	if prog := utils.GetInProgressCondition(ibu, lcav1alpha1.Stages.Upgrade); prog == nil {
+		if proceed, result, err := u.checkReadyToUpgrade(ctx, ibu); !proceed {
+			return result, err
+		}
+		checked = true
+
 		// Set in-progress status
 		u.resetProgressMessage(ctx, ibu)
 	}
 
+	// Wait for the upgrade window to open before doing anything
+	if proceed, result := u.waitForUpgradeWindow(ibu); !proceed {
+		return result, nil
+	}
+
+	// Run the user hook before the workloads are backed up
+	if proceed, result, err := u.runUpgradeHook(ctx, ibu, hooks.Points.PrePivot, upgradeSteps.PrePivotHook,
+		metrics.UpgradePhases.PrePivot, func(hook *lcav1alpha1.Hook) (*batchv1.Job, error) {
+			return u.Hooks.LoadTemplate(ctx, hook)
+		}); !proceed {
+		return result, err
+	}
+
+	if _, started := u.stepStarts[upgradeSteps.Backup]; !checked && !started {
+		if proceed, result, err := u.checkReadyToUpgrade(ctx, ibu); !proceed {
+			return result, err
+		}
+		checked = true
+	}
+
 	// backup with OADP
 	u.Log.Info("Handling backups with OADP operator")
+	u.startStep(upgradeSteps.Backup)
 	ctrlResult, err := u.HandleBackup(ctx, ibu)
 	if err != nil {
 		if backuprestore.IsBRNotFoundError(err) ||
@@ -122,6 +235,7 @@ func (u *UpgHandler) PrePivot(ctx context.Context, ibu *lcav1alpha1.ImageBasedUp
 			backuprestore.IsBRFailedError(err) {
 
 			utils.SetUpgradeStatusFailed(ibu, err.Error())
+			u.failStep(ibu, upgradeSteps.Backup)
 			return doNotRequeue(), nil
 		}
 		return requeueWithError(fmt.Errorf("error while handling backup: %w", err))
@@ -130,6 +244,7 @@ func (u *UpgHandler) PrePivot(ctx context.Context, ibu *lcav1alpha1.ImageBasedUp
 		// The backup process has not been completed yet, requeue
 		return ctrlResult, nil
 	}
+	u.completeStep(ibu, metrics.UpgradePhases.PrePivot, upgradeSteps.Backup)
 
 	u.Log.Info("Remounting sysroot")
 	if err := u.Ops.RemountSysroot(); err != nil {
@@ -141,24 +256,31 @@ func (u *UpgHandler) PrePivot(ctx context.Context, ibu *lcav1alpha1.ImageBasedUp
 	staterootVarPath := getStaterootVarPath(stateroot)
 
 	u.Log.Info("Writing OadpConfiguration CRs into new stateroot")
+	u.startStep(upgradeSteps.ExportOadpConfiguration)
 	if err := u.BackupRestore.ExportOadpConfigurationToDir(ctx, staterootVarPath, backuprestore.OadpNs); err != nil {
 		if backuprestore.IsBRFailedError(err) {
 			utils.SetUpgradeStatusFailed(ibu, err.Error())
+			u.failStep(ibu, upgradeSteps.ExportOadpConfiguration)
 			return doNotRequeue(), nil
 		}
 		return requeueWithError(fmt.Errorf("error while exporting OADP configuration: %w", err))
 	}
+	u.completeStep(ibu, metrics.UpgradePhases.PrePivot, upgradeSteps.ExportOadpConfiguration)
 
 	u.Log.Info("Writing Restore CRs into new stateroot")
+	u.startStep(upgradeSteps.ExportRestores)
 	if err := u.BackupRestore.ExportRestoresToDir(ctx, ibu.Spec.OADPContent, staterootVarPath); err != nil {
 		if backuprestore.IsBRFailedValidationError(err) {
 			utils.SetUpgradeStatusFailed(ibu, err.Error())
+			u.failStep(ibu, upgradeSteps.ExportRestores)
 			return doNotRequeue(), nil
 		}
 		return requeueWithError(fmt.Errorf("error while exporting restores: %w", err))
 	}
+	u.completeStep(ibu, metrics.UpgradePhases.PrePivot, upgradeSteps.ExportRestores)
 
 	u.Log.Info("Writing extra-manifests into new stateroot")
+	u.startStep(upgradeSteps.ExportExtraManifests)
 	// Extract from policies can be done by matching labels on the policy or the CR itself
 	// Currently we expect user to properly label CRs with site specific content
 	// as those policies must not be applied on the seed
@@ -171,7 +293,16 @@ func (u *UpgHandler) PrePivot(ctx context.Context, ibu *lcav1alpha1.ImageBasedUp
 		return requeueWithError(fmt.Errorf("error while exporting extra manifests: %w", err))
 	}
 
+	// The hook config map can't be read before the new stateroot cluster is up, so carry its Job template along
+	if hook := ibu.Spec.Hooks.PostPivot; hook != nil {
+		if err := u.Hooks.ExportTemplate(ctx, hooks.Points.PostPivot, hook, staterootVarPath); err != nil {
+			return requeueWithError(fmt.Errorf("error while exporting post-pivot hook: %w", err))
+		}
+	}
+	u.completeStep(ibu, metrics.UpgradePhases.PrePivot, upgradeSteps.ExportExtraManifests)
+
 	u.Log.Info("Writing cluster-configuration into new stateroot")
+	u.startStep(upgradeSteps.FetchClusterConfig)
 	if err := u.ClusterConfig.FetchClusterConfig(ctx, staterootVarPath); err != nil {
 		return requeueWithError(fmt.Errorf("error while fetching cluster configuration: %w", err))
 	}
@@ -180,11 +311,33 @@ func (u *UpgHandler) PrePivot(ctx context.Context, ibu *lcav1alpha1.ImageBasedUp
 	if err := u.ClusterConfig.FetchLvmConfig(ctx, staterootVarPath); err != nil {
 		return requeueWithError(fmt.Errorf("error while fetching LVM configuration: %w", err))
 	}
+	u.completeStep(ibu, metrics.UpgradePhases.PrePivot, upgradeSteps.FetchClusterConfig)
+
+	// Pause before the reboot until the gate is released, e.g. to reboot within a maintenance window. The steps above
+	// are run again once released, with the backups already taken
+	if ibu.Spec.UpgradeGate == lcav1alpha1.UpgradeGates.Hold {
+		u.Log.Info("Upgrade gate is held, waiting for it to be released to reboot")
+		utils.SetUpgradeStatusInProgress(ibu, ReadyToPivotMessage)
+		return doNotRequeue(), nil
+	}
+
+	// The backups may have taken long, or the gate may have been released late, so check that the upgrade can still
+	// complete within the window before the reboot
+	if proceed, result := u.waitForUpgradeWindow(ibu); !proceed {
+		return result, nil
+	}
+
+	if !checked {
+		if proceed, result, err := u.checkReadyToUpgrade(ctx, ibu); !proceed {
+			return result, err
+		}
+	}
 
 	// Clear any error status that may have been previously set
 	u.resetProgressMessage(ctx, ibu)
 
 	u.Log.Info("Save the IBU CR to the new state root before pivot")
+	u.startStep(upgradeSteps.SaveIBU)
 
 	lcaConfigDir := filepath.Join(staterootPath, common.LCAConfigDir)
 	if err := os.MkdirAll(lcaConfigDir, 0o700); err != nil {
@@ -201,8 +354,15 @@ func (u *UpgHandler) PrePivot(ctx context.Context, ibu *lcav1alpha1.ImageBasedUp
 		return requeueWithError(fmt.Errorf("error while exporting for uncontrolled rollback: %w", err))
 	}
 
+	u.Log.Info("Copy the pending notifications to the new state root")
+	if err := notify.NewOutbox(notificationsPath).CopyTo(filepath.Join(staterootPath, notify.Dir)); err != nil {
+		return requeueWithError(fmt.Errorf("error while copying notifications to the new state root: %w", err))
+	}
+	u.completeStep(ibu, metrics.UpgradePhases.PrePivot, upgradeSteps.SaveIBU)
+
 	// Set the new default deployment
 	if u.OstreeClient.IsOstreeAdminSetDefaultFeatureEnabled() {
+		u.startStep(upgradeSteps.SetDefaultDeployment)
 		deploymentIndex, err := u.RPMOstreeClient.GetDeploymentIndex(stateroot)
 		if err != nil {
 			return requeueWithError(fmt.Errorf("failed to get deployment index for stateroot %s: %w", stateroot, err))
@@ -210,6 +370,7 @@ func (u *UpgHandler) PrePivot(ctx context.Context, ibu *lcav1alpha1.ImageBasedUp
 		if err := u.OstreeClient.SetDefaultDeployment(deploymentIndex); err != nil {
 			return requeueWithError(fmt.Errorf("failed to set default deployment at index %d: %w", deploymentIndex, err))
 		}
+		u.completeStep(ibu, metrics.UpgradePhases.PrePivot, upgradeSteps.SetDefaultDeployment)
 	}
 
 	// Write an event to indicate reboot attempt
@@ -219,17 +380,116 @@ func (u *UpgHandler) PrePivot(ctx context.Context, ibu *lcav1alpha1.ImageBasedUp
 		//todo: abort handler? e.g delete desired stateroot
 		u.Log.Error(err, "")
 		utils.SetUpgradeStatusFailed(ibu, err.Error())
+		u.failStep(ibu, upgradeSteps.Reboot)
 		return doNotRequeue(), nil
 	}
 	return doNotRequeue(), nil
 }
 
+// waitForUpgradeWindow returns true if the upgrade can proceed within the upgrade window, if any. Otherwise, the
+// Upgrade stage waits for the next window, reporting when it opens, or fails if no window is left
+func (u *UpgHandler) waitForUpgradeWindow(ibu *lcav1alpha1.ImageBasedUpgrade) (bool, ctrl.Result) {
+	if ibu.Status.NextUpgradeWindow != nil {
+		// The window was waited for, clear the waiting status
+		ibu.Status.NextUpgradeWindow = nil
+		utils.SetUpgradeStatusInProgress(ibu, "In progress")
+	}
+	if ibu.Spec.UpgradeWindow == nil {
+		return true, doNotRequeue()
+	}
+
+	schedule, err := upgradewindow.New(ibu.Spec.UpgradeWindow)
+	if err != nil {
+		utils.SetUpgradeStatusFailed(ibu, err.Error())
+		u.failStep(ibu, upgradeSteps.UpgradeWindow)
+		return false, doNotRequeue()
+	}
+
+	now := time.Now()
+	ok, next := schedule.CanUpgrade(now)
+	if ok {
+		return true, doNotRequeue()
+	}
+	if next == nil {
+		utils.SetUpgradeStatusFailed(ibu, fmt.Sprintf("No upgrade window left with at least %s for the upgrade",
+			ibu.Spec.UpgradeWindow.ExpectedDuration.Duration))
+		u.failStep(ibu, upgradeSteps.UpgradeWindow)
+		return false, doNotRequeue()
+	}
+
+	untilOpen := next.Start.Sub(now)
+	u.Log.Info("Waiting for the upgrade window to open", "start", next.Start, "end", next.End)
+	ibu.Status.NextUpgradeWindow = &metav1.Time{Time: next.Start}
+	utils.SetUpgradeStatusInProgress(ibu, fmt.Sprintf("Waiting for the upgrade window opening at %s, in %s",
+		next.Start.UTC().Format(time.RFC3339), untilOpen.Round(time.Minute)))
+
+	// Requeue periodically to keep the time to the window up to date
+	if untilOpen > 5*time.Minute {
+		return false, requeueWithLongInterval()
+	}
+	return false, requeueWithCustomInterval(untilOpen)
+}
+
+// checkReadyToUpgrade refuses to upgrade if the seed image tag was moved since Prep, or if the cluster is not healthy, as
+// the failure would otherwise be blamed on the new stateroot and trigger a rollback
+func (u *UpgHandler) checkReadyToUpgrade(ctx context.Context, ibu *lcav1alpha1.ImageBasedUpgrade) (bool, ctrl.Result, error) {
+	if err := u.checkSeedImageDigest(ctx, ibu); err != nil {
+		if prep.IsSeedImageDigestMismatchError(err) {
+			utils.SetUpgradeStatusFailed(ibu, err.Error())
+			u.failStep(ibu, upgradeSteps.SeedImageDigestCheck)
+			return false, doNotRequeue(), nil
+		}
+		result, err := requeueWithError(err)
+		return false, result, err
+	}
+
+	if ibu.Spec.HealthChecks.DisabledPreUpgrade {
+		return true, doNotRequeue(), nil
+	}
+	u.startStep(upgradeSteps.ClusterHealthCheck)
+	if err := CheckClusterHealth(ctx, u.Client, u.Log, &ibu.Spec.HealthChecks); err != nil {
+		if healthcheck.IsNotHealthyError(err) {
+			utils.SetUpgradeStatusFailedWithReason(ibu, utils.ConditionReasons.ClusterNotHealthy, err.Error())
+			u.failStep(ibu, upgradeSteps.ClusterHealthCheck)
+			return false, doNotRequeue(), nil
+		}
+		result, err := requeueWithError(fmt.Errorf("error while checking cluster health: %w", err))
+		return false, result, err
+	}
+	u.completeStep(ibu, metrics.UpgradePhases.PrePivot, upgradeSteps.ClusterHealthCheck)
+	return true, doNotRequeue(), nil
+}
+
 // exportForUncontrolledRollback Save a copy of the IBU in the current stateroot in case of uncontrolled rollback, with Upgrade set to failed
 var ibuPreStaterootPath = common.PathOutsideChroot(utils.IBUFilePath)
 
+// notificationsPath is the notification outbox of the current stateroot, carried over to the other stateroot with the IBU
+var notificationsPath = common.PathOutsideChroot(notify.Dir)
+
+// postPivotProgressPath is the progress journal written by the post-pivot configuration on the new stateroot
+var postPivotProgressPath = common.PathOutsideChroot(progress.JournalFile)
+
+// checkSeedImageDigest checks that the seed image tag still points to the digest pulled during Prep
+func (u *UpgHandler) checkSeedImageDigest(ctx context.Context, ibu *lcav1alpha1.ImageBasedUpgrade) error {
+	if ibu.Status.SeedImageDigest == "" {
+		u.Log.Info("No seed image digest recorded by the Prep stage, skipping the seed image digest check")
+		return nil
+	}
+
+	digest, err := resolveSeedImageDigest(ctx, u.Client, u.Ops, ibu)
+	if err != nil {
+		return fmt.Errorf("failed to check seed image digest: %w", err)
+	}
+	if digest != ibu.Status.SeedImageDigest {
+		return &prep.SeedImageDigestMismatchError{Image: ibu.Spec.SeedImageRef.Image, Expected: ibu.Status.SeedImageDigest, Actual: digest}
+	}
+	return nil
+}
+
 func exportForUncontrolledRollback(ibu *lcav1alpha1.ImageBasedUpgrade) error {
 	ibuCopy := ibu.DeepCopy()
 	utils.SetUpgradeStatusFailed(ibuCopy, "Uncontrolled rollback")
+	utils.SetHistoryRollbackReason(ibuCopy, "Uncontrolled rollback")
 	if err := lcautils.MarshalToFile(ibuCopy, ibuPreStaterootPath); err != nil {
 		return fmt.Errorf("failed to save copy of IBU CR for rollback: %w", err)
 	}
@@ -247,7 +507,10 @@ var getStaterootVarPath = func(stateroot string) string {
 // CheckHealth helper func to call HealthChecks
 var CheckHealth = healthcheck.HealthChecks
 
-func (u *UpgHandler) autoRollbackIfEnabled(ibu *lcav1alpha1.ImageBasedUpgrade, msg string) {
+// CheckClusterHealth helper func to call the pre-upgrade CheckClusterHealth
+var CheckClusterHealth = healthcheck.CheckClusterHealth
+
+func (u *UpgHandler) autoRollbackIfEnabled(ibu *lcav1alpha1.ImageBasedUpgrade, reason, msg string) {
 	// Check whether auto-rollback is desired
 	if ibu.Spec.AutoRollbackOnFailure.DisabledForUpgradeCompletion {
 		// Auto-rollback is not enabled, so do nothing
@@ -255,6 +518,7 @@ func (u *UpgHandler) autoRollbackIfEnabled(ibu *lcav1alpha1.ImageBasedUpgrade, m
 	}
 
 	u.Log.Info("Automatically rolling back due to failure")
+	metrics.IncIBUAutoRollback(ibu, reason)
 
 	if err := u.RebootClient.InitiateRollback(msg); err != nil {
 		u.Log.Info(fmt.Sprintf("Unable to auto rollback: %s", err))
@@ -271,52 +535,66 @@ func (u *UpgHandler) autoRollbackIfEnabled(ibu *lcav1alpha1.ImageBasedUpgrade, m
 // The caller will simply return what this function returns.
 func (u *UpgHandler) PostPivot(ctx context.Context, ibu *lcav1alpha1.ImageBasedUpgrade) (ctrl.Result, error) {
 	u.Log.Info("Starting health check for different components")
-	err := CheckHealth(u.Client, u.Log)
+	u.startStep(upgradeSteps.HealthCheck)
+	err := CheckHealth(u.Client, u.Log, &ibu.Spec.HealthChecks)
 	if err != nil {
 		utils.SetUpgradeStatusFailed(ibu, err.Error())
-		u.autoRollbackIfEnabled(ibu, fmt.Sprintf("Rollback due to health check failure: %s", err))
+		u.failStep(ibu, upgradeSteps.HealthCheck)
+		u.autoRollbackIfEnabled(ibu, upgradeSteps.HealthCheck, fmt.Sprintf("Rollback due to health check failure: %s", err))
 		return doNotRequeue(), nil
 	}
+	u.completeStep(ibu, metrics.UpgradePhases.PostPivot, upgradeSteps.HealthCheck)
 
 	// Applying extra manifests
+	u.startStep(upgradeSteps.ApplyPolicyManifests)
 	err = u.ExtraManifest.ApplyExtraManifests(ctx, common.PathOutsideChroot(extramanifest.PolicyManifestPath))
 	if err != nil {
 		if extramanifest.IsEMFailedError(err) {
 			utils.SetUpgradeStatusFailed(ibu, err.Error())
-			u.autoRollbackIfEnabled(ibu, fmt.Sprintf("Rollback due to failure applying policy extra-manifests: %s", err))
+			u.failStep(ibu, upgradeSteps.ApplyPolicyManifests)
+			u.autoRollbackIfEnabled(ibu, upgradeSteps.ApplyPolicyManifests, fmt.Sprintf("Rollback due to failure applying policy extra-manifests: %s", err))
 			return doNotRequeue(), nil
 		}
 		return requeueWithError(fmt.Errorf("error while applying policy extra manifests: %w", err))
 	}
+	u.completeStep(ibu, metrics.UpgradePhases.PostPivot, upgradeSteps.ApplyPolicyManifests)
 
+	u.startStep(upgradeSteps.ApplyExtraManifests)
 	err = u.ExtraManifest.ApplyExtraManifests(ctx, common.PathOutsideChroot(extramanifest.ExtraManifestPath))
 	if err != nil {
 		if extramanifest.IsEMFailedError(err) {
 			utils.SetUpgradeStatusFailed(ibu, err.Error())
-			u.autoRollbackIfEnabled(ibu, fmt.Sprintf("Rollback due to failure applying extra-manifests: %s", err))
+			u.failStep(ibu, upgradeSteps.ApplyExtraManifests)
+			u.autoRollbackIfEnabled(ibu, upgradeSteps.ApplyExtraManifests, fmt.Sprintf("Rollback due to failure applying extra-manifests: %s", err))
 			return doNotRequeue(), nil
 		}
 		return requeueWithError(fmt.Errorf("error while applying extra manifests: %w", err))
 	}
+	u.completeStep(ibu, metrics.UpgradePhases.PostPivot, upgradeSteps.ApplyExtraManifests)
 
 	// Recovering OADP configuration
+	u.startStep(upgradeSteps.RestoreOadpConfiguration)
 	err = u.BackupRestore.RestoreOadpConfigurations(ctx)
 	if err != nil {
 		if backuprestore.IsBRStorageBackendUnavailableError(err) {
 			utils.SetUpgradeStatusFailed(ibu, err.Error())
-			u.autoRollbackIfEnabled(ibu, fmt.Sprintf("Rollback due to backup storage failure: %s", err))
+			u.failStep(ibu, upgradeSteps.RestoreOadpConfiguration)
+			u.autoRollbackIfEnabled(ibu, upgradeSteps.RestoreOadpConfiguration, fmt.Sprintf("Rollback due to backup storage failure: %s", err))
 			return doNotRequeue(), nil
 		}
 		return requeueWithError(fmt.Errorf("error while restoring OADP configuration: %w", err))
 	}
+	u.completeStep(ibu, metrics.UpgradePhases.PostPivot, upgradeSteps.RestoreOadpConfiguration)
 
 	// Handling restores with OADP operator
+	u.startStep(upgradeSteps.Restore)
 	result, err := u.HandleRestore(ctx)
 	if err != nil {
 		// Restore failed
 		if backuprestore.IsBRFailedError(err) {
 			utils.SetUpgradeStatusFailed(ibu, err.Error())
-			u.autoRollbackIfEnabled(ibu, fmt.Sprintf("Rollback due to restore failure: %s", err))
+			u.failStep(ibu, upgradeSteps.Restore)
+			u.autoRollbackIfEnabled(ibu, upgradeSteps.Restore, fmt.Sprintf("Rollback due to restore failure: %s", err))
 			return doNotRequeue(), nil
 		}
 		return requeueWithError(fmt.Errorf("error while handling restore: %w", err))
@@ -325,6 +603,15 @@ func (u *UpgHandler) PostPivot(ctx context.Context, ibu *lcav1alpha1.ImageBasedU
 		// The restore process has not been completed yet, requeue
 		return result, nil
 	}
+	u.completeStep(ibu, metrics.UpgradePhases.PostPivot, upgradeSteps.Restore)
+
+	// Run the user hook once the workloads are restored
+	if proceed, result, err := u.runUpgradeHook(ctx, ibu, hooks.Points.PostPivot, upgradeSteps.PostPivotHook,
+		metrics.UpgradePhases.PostPivot, func(*lcav1alpha1.Hook) (*batchv1.Job, error) {
+			return hooks.LoadExportedTemplate(hooks.Points.PostPivot)
+		}); !proceed {
+		return result, err
+	}
 
 	if err := u.RebootClient.DisableInitMonitor(); err != nil {
 		// Don't fail the upgrade on failure here, just log it
@@ -336,6 +623,51 @@ func (u *UpgHandler) PostPivot(ctx context.Context, ibu *lcav1alpha1.ImageBasedU
 	return doNotRequeue(), nil
 }
 
+// runUpgradeHook runs the user hook configured at the point, if any, and returns whether the upgrade can proceed.
+// Otherwise the returned result and error are to be returned by the caller: a requeue while the hook Job is running,
+// or no requeue once the Upgrade stage is failed according to the hook failure policy
+func (u *UpgHandler) runUpgradeHook(ctx context.Context, ibu *lcav1alpha1.ImageBasedUpgrade, point hooks.Point, step string,
+	phase metrics.UpgradePhase, loadTemplate func(*lcav1alpha1.Hook) (*batchv1.Job, error)) (bool, ctrl.Result, error) {
+	hook := hooks.HookFor(ibu.Spec.Hooks, point)
+	if hook == nil {
+		return true, doNotRequeue(), nil
+	}
+
+	u.startStep(step)
+	template, err := loadTemplate(hook)
+	if err != nil {
+		result, err := requeueWithError(fmt.Errorf("error while loading %s hook job template: %w", point, err))
+		return false, result, err
+	}
+
+	done, err := u.Hooks.Run(ctx, point, hook, template, utils.CurrentAttempt(ibu))
+	if err != nil {
+		if !hooks.IsFailedError(err) {
+			result, err := requeueWithError(fmt.Errorf("error while running %s hook: %w", point, err))
+			return false, result, err
+		}
+		if hook.FailurePolicy == lcav1alpha1.HookFailurePolicies.Ignore {
+			u.Log.Info("Ignoring hook failure", "hook", point, "error", err.Error())
+			u.completeStep(ibu, phase, step)
+			return true, doNotRequeue(), nil
+		}
+
+		utils.SetUpgradeStatusFailed(ibu, err.Error())
+		u.failStep(ibu, step)
+		if hook.FailurePolicy == lcav1alpha1.HookFailurePolicies.Rollback && point == hooks.Points.PostPivot {
+			u.autoRollbackIfEnabled(ibu, step, fmt.Sprintf("Rollback due to hook failure: %s", err))
+		}
+		return false, doNotRequeue(), nil
+	}
+	if !done {
+		utils.SetUpgradeStatusInProgress(ibu, fmt.Sprintf("Waiting for %s hook job to complete", point))
+		return false, requeueWithShortInterval(), nil
+	}
+
+	u.completeStep(ibu, phase, step)
+	return true, doNotRequeue(), nil
+}
+
 // HandleBackup manages backup flow and returns with possible requeue
 func (u *UpgHandler) HandleBackup(ctx context.Context, ibu *lcav1alpha1.ImageBasedUpgrade) (ctrl.Result, error) {
 	sortedBackupGroups, err := u.BackupRestore.GetSortedBackupsFromConfigmap(ctx, ibu.Spec.OADPContent)
@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/go-logr/logr"
 	lcav1alpha1 "github.com/openshift-kni/lifecycle-agent/api/v1alpha1"
@@ -17,6 +18,7 @@ import (
 	"github.com/openshift-kni/lifecycle-agent/internal/common"
 	"github.com/openshift-kni/lifecycle-agent/internal/extramanifest"
 	mock_extramanifest "github.com/openshift-kni/lifecycle-agent/internal/extramanifest/mocks"
+	"github.com/openshift-kni/lifecycle-agent/internal/healthcheck"
 	"github.com/openshift-kni/lifecycle-agent/internal/ostreeclient"
 	"github.com/openshift-kni/lifecycle-agent/internal/reboot"
 	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
@@ -319,6 +321,10 @@ func TestImageBasedUpgradeReconciler_prePivot(t *testing.T) {
 		mockController.Finish()
 	}()
 
+	windowStart := metav1.NewTime(time.Now().Add(48 * time.Hour).Truncate(time.Second))
+	pastWindowStart := metav1.NewTime(time.Now().Add(-24 * time.Hour))
+	openWindowStart := metav1.NewTime(time.Now().Add(-time.Hour))
+
 	type args struct {
 		ctx context.Context
 		ibu lcav1alpha1.ImageBasedUpgrade
@@ -339,10 +345,126 @@ func TestImageBasedUpgradeReconciler_prePivot(t *testing.T) {
 		exportIBUCROrig                                 bool
 		rebootToNewStateRootReturn                      func() error
 		isOstreeAdminSetDefaultFeatureEnabledReturn     *bool
+		checkClusterHealthReturn                        func() error
 		want                                            controllerruntime.Result
 		wantErr                                         assert.ErrorAssertionFunc
 		wantConditions                                  []metav1.Condition
 	}{
+		{
+			name: "cluster not healthy",
+			args: args{
+				ibu: lcav1alpha1.ImageBasedUpgrade{},
+			},
+			checkClusterHealthReturn: func() error {
+				return &healthcheck.NotHealthyError{NotReady: map[lcav1alpha1.HealthCheckName][]string{
+					lcav1alpha1.HealthCheckNames.ClusterOperators: {"etcd (degraded)"},
+				}}
+			},
+			want:    doNotRequeue(),
+			wantErr: assert.NoError,
+			wantConditions: []metav1.Condition{
+				{
+					Type:    string(utils.ConditionTypes.UpgradeCompleted),
+					Reason:  string(utils.ConditionReasons.ClusterNotHealthy),
+					Status:  metav1.ConditionFalse,
+					Message: "Upgrade failed",
+				},
+				{
+					Type:    string(utils.ConditionTypes.UpgradeInProgress),
+					Reason:  string(utils.ConditionReasons.ClusterNotHealthy),
+					Status:  metav1.ConditionFalse,
+					Message: "cluster is not healthy: ClusterOperators not ready: etcd (degraded)",
+				},
+			},
+		},
+		{
+			name: "upgrade window not open yet",
+			args: args{
+				ibu: lcav1alpha1.ImageBasedUpgrade{
+					Spec: lcav1alpha1.ImageBasedUpgradeSpec{UpgradeWindow: &lcav1alpha1.UpgradeWindow{
+						Start: &windowStart, Duration: metav1.Duration{Duration: 4 * time.Hour},
+					}},
+				},
+			},
+			want:    requeueWithLongInterval(),
+			wantErr: assert.NoError,
+			wantConditions: []metav1.Condition{
+				{
+					Type:   string(utils.ConditionTypes.UpgradeInProgress),
+					Reason: string(utils.ConditionReasons.InProgress),
+					Status: metav1.ConditionTrue,
+					Message: fmt.Sprintf("Waiting for the upgrade window opening at %s, in 48h0m0s",
+						windowStart.UTC().Format(time.RFC3339)),
+				},
+			},
+		},
+		{
+			name: "upgrade window closed",
+			args: args{
+				ibu: lcav1alpha1.ImageBasedUpgrade{
+					Spec: lcav1alpha1.ImageBasedUpgradeSpec{UpgradeWindow: &lcav1alpha1.UpgradeWindow{
+						Start: &pastWindowStart, Duration: metav1.Duration{Duration: 4 * time.Hour},
+						ExpectedDuration: metav1.Duration{Duration: time.Hour},
+					}},
+				},
+			},
+			want:    doNotRequeue(),
+			wantErr: assert.NoError,
+			wantConditions: []metav1.Condition{
+				{
+					Type:    string(utils.ConditionTypes.UpgradeCompleted),
+					Reason:  string(utils.ConditionReasons.Failed),
+					Status:  metav1.ConditionFalse,
+					Message: "Upgrade failed",
+				},
+				{
+					Type:    string(utils.ConditionTypes.UpgradeInProgress),
+					Reason:  string(utils.ConditionReasons.Failed),
+					Status:  metav1.ConditionFalse,
+					Message: "No upgrade window left with at least 1h0m0s for the upgrade",
+				},
+			},
+		},
+		{
+			name: "cluster not healthy once the upgrade window is open",
+			args: args{
+				ibu: lcav1alpha1.ImageBasedUpgrade{
+					Spec: lcav1alpha1.ImageBasedUpgradeSpec{UpgradeWindow: &lcav1alpha1.UpgradeWindow{
+						Start: &openWindowStart, Duration: metav1.Duration{Duration: 4 * time.Hour},
+						ExpectedDuration: metav1.Duration{Duration: time.Hour},
+					}},
+					Status: lcav1alpha1.ImageBasedUpgradeStatus{
+						NextUpgradeWindow: &openWindowStart,
+						Conditions: []metav1.Condition{{
+							Type:   string(utils.ConditionTypes.UpgradeInProgress),
+							Reason: string(utils.ConditionReasons.InProgress),
+							Status: metav1.ConditionTrue,
+						}},
+					},
+				},
+			},
+			checkClusterHealthReturn: func() error {
+				return &healthcheck.NotHealthyError{NotReady: map[lcav1alpha1.HealthCheckName][]string{
+					lcav1alpha1.HealthCheckNames.ClusterOperators: {"etcd (degraded)"},
+				}}
+			},
+			want:    doNotRequeue(),
+			wantErr: assert.NoError,
+			wantConditions: []metav1.Condition{
+				{
+					Type:    string(utils.ConditionTypes.UpgradeCompleted),
+					Reason:  string(utils.ConditionReasons.ClusterNotHealthy),
+					Status:  metav1.ConditionFalse,
+					Message: "Upgrade failed",
+				},
+				{
+					Type:    string(utils.ConditionTypes.UpgradeInProgress),
+					Reason:  string(utils.ConditionReasons.ClusterNotHealthy),
+					Status:  metav1.ConditionFalse,
+					Message: "cluster is not healthy: ClusterOperators not ready: etcd (degraded)",
+				},
+			},
+		},
 		{
 			name: "backup failed request no requeue",
 			args: args{
@@ -635,6 +757,48 @@ func TestImageBasedUpgradeReconciler_prePivot(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "upgrade gate held after exports",
+			args: args{
+				ibu: lcav1alpha1.ImageBasedUpgrade{
+					Spec: lcav1alpha1.ImageBasedUpgradeSpec{UpgradeGate: lcav1alpha1.UpgradeGates.Hold},
+				},
+			},
+			getSortedBackupsFromConfigmapReturn: func() ([][]*velerov1.Backup, error) {
+				return nil, nil
+			},
+			remountSysrootReturn: func() error {
+				return nil
+			},
+			exportOadpConfigurationToDirReturn: func() error {
+				return nil
+			},
+			exportRestoresToDirReturn: func() error {
+				return nil
+			},
+			extractAndExportManifestFromPoliciesToDirReturn: func() error {
+				return nil
+			},
+			exportExtraManifestToDirReturn: func() error {
+				return nil
+			},
+			fetchClusterConfigReturn: func() error {
+				return nil
+			},
+			fetchLvmConfigReturn: func() error {
+				return nil
+			},
+			want:    doNotRequeue(),
+			wantErr: assert.NoError,
+			wantConditions: []metav1.Condition{
+				{
+					Type:    string(utils.ConditionTypes.UpgradeInProgress),
+					Reason:  string(utils.ConditionReasons.InProgress),
+					Status:  metav1.ConditionTrue,
+					Message: ReadyToPivotMessage,
+				},
+			},
+		},
 		{
 			name: "Export IBU Crs successfully and reboot fail",
 			args: args{
@@ -771,6 +935,17 @@ func TestImageBasedUpgradeReconciler_prePivot(t *testing.T) {
 				RebootClient:    mockRebootClient,
 			}
 
+			oldCCH := CheckClusterHealth
+			defer func() {
+				CheckClusterHealth = oldCCH
+			}()
+			CheckClusterHealth = func(_ context.Context, _ client.Reader, _ logr.Logger, _ *lcav1alpha1.HealthChecks) error {
+				if tt.checkClusterHealthReturn != nil {
+					return tt.checkClusterHealthReturn()
+				}
+				return nil
+			}
+
 			got, err := uh.PrePivot(context.Background(), &tt.args.ibu)
 
 			// assert
@@ -836,7 +1011,7 @@ func TestImageBasedUpgradeReconciler_postPivot(t *testing.T) {
 		args                              args
 		want                              controllerruntime.Result
 		wantErr                           assert.ErrorAssertionFunc
-		checkHealthReturn                 func(c client.Reader, l logr.Logger) error
+		checkHealthReturn                 func(c client.Reader, l logr.Logger, checks *lcav1alpha1.HealthChecks) error
 		applyExtraManifestsReturn         func() error
 		applyPolicyManifestsReturn        func() error
 		restoreOadpConfigurationsReturn   func() error
@@ -849,7 +1024,7 @@ func TestImageBasedUpgradeReconciler_postPivot(t *testing.T) {
 		{
 			name: "healthchecks return error",
 			args: args{ibu: &lcav1alpha1.ImageBasedUpgrade{}},
-			checkHealthReturn: func(c client.Reader, l logr.Logger) error {
+			checkHealthReturn: func(c client.Reader, l logr.Logger, checks *lcav1alpha1.HealthChecks) error {
 				return fmt.Errorf("any error from hc")
 			},
 			initiateRollbackReturn: func() error {
@@ -874,7 +1049,7 @@ func TestImageBasedUpgradeReconciler_postPivot(t *testing.T) {
 		{
 			name: "extraManifests return error",
 			args: args{ibu: &lcav1alpha1.ImageBasedUpgrade{}},
-			checkHealthReturn: func(c client.Reader, l logr.Logger) error {
+			checkHealthReturn: func(c client.Reader, l logr.Logger, checks *lcav1alpha1.HealthChecks) error {
 				return nil
 			},
 			applyPolicyManifestsReturn: func() error {
@@ -905,7 +1080,7 @@ func TestImageBasedUpgradeReconciler_postPivot(t *testing.T) {
 		{
 			name: "RestoreOadpConfigurations return error",
 			args: args{ibu: &lcav1alpha1.ImageBasedUpgrade{}},
-			checkHealthReturn: func(c client.Reader, l logr.Logger) error {
+			checkHealthReturn: func(c client.Reader, l logr.Logger, checks *lcav1alpha1.HealthChecks) error {
 				return nil
 			},
 			applyPolicyManifestsReturn: func() error {
@@ -939,7 +1114,7 @@ func TestImageBasedUpgradeReconciler_postPivot(t *testing.T) {
 		{
 			name: "handleRestore with restore error",
 			args: args{ibu: &lcav1alpha1.ImageBasedUpgrade{}},
-			checkHealthReturn: func(c client.Reader, l logr.Logger) error {
+			checkHealthReturn: func(c client.Reader, l logr.Logger, checks *lcav1alpha1.HealthChecks) error {
 				return nil
 			},
 			applyPolicyManifestsReturn: func() error {
@@ -979,7 +1154,7 @@ func TestImageBasedUpgradeReconciler_postPivot(t *testing.T) {
 		{
 			name: "upgrade completed",
 			args: args{ibu: &lcav1alpha1.ImageBasedUpgrade{}},
-			checkHealthReturn: func(c client.Reader, l logr.Logger) error {
+			checkHealthReturn: func(c client.Reader, l logr.Logger, checks *lcav1alpha1.HealthChecks) error {
 				return nil
 			},
 			applyPolicyManifestsReturn: func() error {
@@ -9,6 +9,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/internal/metrics"
 
 	lcav1alpha1 "github.com/openshift-kni/lifecycle-agent/api/v1alpha1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -56,31 +57,43 @@ type ConditionReason string
 
 // ConditionReasons define the different reasons that conditions will be set for
 var ConditionReasons = struct {
-	Idle              ConditionReason
-	Completed         ConditionReason
-	Failed            ConditionReason
-	TimedOut          ConditionReason
-	InProgress        ConditionReason
-	Aborting          ConditionReason
-	AbortCompleted    ConditionReason
-	AbortFailed       ConditionReason
-	Finalizing        ConditionReason
-	FinalizeCompleted ConditionReason
-	FinalizeFailed    ConditionReason
-	InvalidTransition ConditionReason
+	Idle                        ConditionReason
+	Completed                   ConditionReason
+	Failed                      ConditionReason
+	TimedOut                    ConditionReason
+	InProgress                  ConditionReason
+	Aborting                    ConditionReason
+	AbortCompleted              ConditionReason
+	AbortFailed                 ConditionReason
+	Finalizing                  ConditionReason
+	FinalizeCompleted           ConditionReason
+	FinalizeFailed              ConditionReason
+	InvalidTransition           ConditionReason
+	SeedImageVerificationFailed ConditionReason
+	UpgradePathRejected         ConditionReason
+	HookFailed                  ConditionReason
+	ClusterNotHealthy           ConditionReason
+	OperatorIncompatible        ConditionReason
+	InsufficientDiskSpace       ConditionReason
 }{
-	Idle:              "Idle",
-	Completed:         "Completed",
-	Failed:            "Failed",
-	TimedOut:          "TimedOut",
-	InProgress:        "InProgress",
-	Aborting:          "Aborting",
-	AbortCompleted:    "AbortCompleted",
-	AbortFailed:       "AbortFailed",
-	Finalizing:        "Finalizing",
-	FinalizeCompleted: "FinalizeCompleted",
-	FinalizeFailed:    "FinalizeFailed",
-	InvalidTransition: "InvalidTransition",
+	Idle:                        "Idle",
+	Completed:                   "Completed",
+	Failed:                      "Failed",
+	TimedOut:                    "TimedOut",
+	InProgress:                  "InProgress",
+	Aborting:                    "Aborting",
+	AbortCompleted:              "AbortCompleted",
+	AbortFailed:                 "AbortFailed",
+	Finalizing:                  "Finalizing",
+	FinalizeCompleted:           "FinalizeCompleted",
+	FinalizeFailed:              "FinalizeFailed",
+	InvalidTransition:           "InvalidTransition",
+	SeedImageVerificationFailed: "SeedImageVerificationFailed",
+	UpgradePathRejected:         "UpgradePathRejected",
+	HookFailed:                  "HookFailed",
+	ClusterNotHealthy:           "ClusterNotHealthy",
+	OperatorIncompatible:        "OperatorIncompatible",
+	InsufficientDiskSpace:       "InsufficientDiskSpace",
 }
 
 var SeedGenConditionReasons = struct {
@@ -262,17 +275,59 @@ func GetPreviousStage(stage lcav1alpha1.ImageBasedUpgradeStage) lcav1alpha1.Imag
 	return ""
 }
 
+// GetValidNextStages returns the stages the IBU may transition to, depending on whether the node rebooted to the new
+// stateroot
+func GetValidNextStages(ibu *lcav1alpha1.ImageBasedUpgrade, isAfterPivot bool) []lcav1alpha1.ImageBasedUpgradeStage {
+	inProgressStage := GetInProgressStage(ibu)
+	if inProgressStage == lcav1alpha1.Stages.Idle || inProgressStage == lcav1alpha1.Stages.Rollback || IsStageFailed(ibu, lcav1alpha1.Stages.Rollback) {
+		// no valid transition if abort/finalize/rollback in progress or failed
+		return []lcav1alpha1.ImageBasedUpgradeStage{}
+	}
+
+	if inProgressStage == lcav1alpha1.Stages.Prep || IsStageFailed(ibu, lcav1alpha1.Stages.Prep) {
+		return []lcav1alpha1.ImageBasedUpgradeStage{lcav1alpha1.Stages.Idle}
+	}
+
+	if inProgressStage == lcav1alpha1.Stages.Upgrade || IsStageFailed(ibu, lcav1alpha1.Stages.Upgrade) {
+		if isAfterPivot {
+			return []lcav1alpha1.ImageBasedUpgradeStage{lcav1alpha1.Stages.Rollback}
+		} else {
+			return []lcav1alpha1.ImageBasedUpgradeStage{lcav1alpha1.Stages.Idle}
+		}
+	}
+
+	// no in progress stage, check completed stages in reverse order
+	if IsStageCompleted(ibu, lcav1alpha1.Stages.Rollback) {
+		return []lcav1alpha1.ImageBasedUpgradeStage{lcav1alpha1.Stages.Idle}
+	}
+	if IsStageCompleted(ibu, lcav1alpha1.Stages.Upgrade) {
+		return []lcav1alpha1.ImageBasedUpgradeStage{lcav1alpha1.Stages.Idle, lcav1alpha1.Stages.Rollback}
+	}
+	if IsStageCompleted(ibu, lcav1alpha1.Stages.Prep) {
+		return []lcav1alpha1.ImageBasedUpgradeStage{lcav1alpha1.Stages.Idle, lcav1alpha1.Stages.Upgrade}
+	}
+	if IsStageCompleted(ibu, lcav1alpha1.Stages.Idle) {
+		return []lcav1alpha1.ImageBasedUpgradeStage{lcav1alpha1.Stages.Prep}
+	}
+	return []lcav1alpha1.ImageBasedUpgradeStage{}
+}
+
 // SetUpgradeStatusFailed updates the upgrade status to failed with message
 func SetUpgradeStatusFailed(ibu *lcav1alpha1.ImageBasedUpgrade, msg string) {
+	SetUpgradeStatusFailedWithReason(ibu, ConditionReasons.Failed, msg)
+}
+
+// SetUpgradeStatusFailedWithReason updates the upgrade status to failed with a specific reason and message
+func SetUpgradeStatusFailedWithReason(ibu *lcav1alpha1.ImageBasedUpgrade, reason ConditionReason, msg string) {
 	SetStatusCondition(&ibu.Status.Conditions,
 		GetCompletedConditionType(lcav1alpha1.Stages.Upgrade),
-		ConditionReasons.Failed,
+		reason,
 		metav1.ConditionFalse,
 		"Upgrade failed",
 		ibu.Generation)
 	SetStatusCondition(&ibu.Status.Conditions,
 		GetInProgressConditionType(lcav1alpha1.Stages.Upgrade),
-		ConditionReasons.Failed,
+		reason,
 		metav1.ConditionFalse,
 		msg,
 		ibu.Generation)
@@ -316,15 +371,24 @@ func SetPrepStatusInProgress(ibu *lcav1alpha1.ImageBasedUpgrade, msg string) {
 
 // SetPrepStatusFailed updates the prep status to failed with message
 func SetPrepStatusFailed(ibu *lcav1alpha1.ImageBasedUpgrade, msg string) {
+	SetPrepStatusFailedWithReason(ibu, ConditionReasons.Failed, msg)
+}
+
+// SetPrepStatusFailedWithReason updates the prep status to failed with a specific reason and message. The generic
+// Failed reason is used if the reason is empty
+func SetPrepStatusFailedWithReason(ibu *lcav1alpha1.ImageBasedUpgrade, reason ConditionReason, msg string) {
+	if reason == "" {
+		reason = ConditionReasons.Failed
+	}
 	SetStatusCondition(&ibu.Status.Conditions,
 		GetCompletedConditionType(lcav1alpha1.Stages.Prep),
-		ConditionReasons.Failed,
+		reason,
 		metav1.ConditionFalse,
 		"Prep failed",
 		ibu.Generation)
 	SetStatusCondition(&ibu.Status.Conditions,
 		GetInProgressConditionType(lcav1alpha1.Stages.Prep),
-		ConditionReasons.Failed,
+		reason,
 		metav1.ConditionFalse,
 		msg,
 		ibu.Generation)
@@ -395,6 +459,7 @@ func UpdateIBUStatus(ctx context.Context, c client.Client, ibu *lcav1alpha1.Imag
 	}
 
 	ibu.Status.ObservedGeneration = ibu.ObjectMeta.Generation
+	UpdateHistory(ibu)
 
 	for i := range ibu.Status.Conditions {
 		condition := &ibu.Status.Conditions[i]
@@ -411,5 +476,6 @@ func UpdateIBUStatus(ctx context.Context, c client.Client, ibu *lcav1alpha1.Imag
 		return fmt.Errorf("failed to update IBU status: %w", err)
 	}
 
+	metrics.RecordIBUStatus(ibu)
 	return nil
 }
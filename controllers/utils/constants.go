@@ -11,6 +11,8 @@ const (
 	// IBUName defines the valid name of the CR for the controller to reconcile
 	IBUName     string = "upgrade"
 	IBUFilePath string = common.LCAConfigDir + "/ibu.json"
+	// PrepCheckpointFile records the completed Prep steps so that Prep can resume after a restart
+	PrepCheckpointFile string = IBUWorkspacePath + "/prep_checkpoint.json"
 
 	ManualCleanupAnnotation string = "lca.openshift.io/manualCleanupDone"
 
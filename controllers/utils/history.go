@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"strconv"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	lcav1alpha1 "github.com/openshift-kni/lifecycle-agent/api/v1alpha1"
+)
+
+// MaxHistoryLength is the number of upgrade attempts kept in the IBU status history
+const MaxHistoryLength = 10
+
+// ManualRollbackReason is the rollback reason recorded when the user requests the Rollback stage
+const ManualRollbackReason = "Manual rollback"
+
+// currentRecord returns the record of the upgrade attempt in progress, if any
+func currentRecord(ibu *lcav1alpha1.ImageBasedUpgrade) *lcav1alpha1.UpgradeRecord {
+	if len(ibu.Status.History) == 0 || ibu.Status.History[0].CompletionTime != nil {
+		return nil
+	}
+	return &ibu.Status.History[0]
+}
+
+// CurrentAttempt identifies the upgrade attempt in progress by the start time of its record, as Unix seconds. It is
+// empty when no attempt is recorded
+func CurrentAttempt(ibu *lcav1alpha1.ImageBasedUpgrade) string {
+	record := currentRecord(ibu)
+	if record == nil {
+		return ""
+	}
+	return strconv.FormatInt(record.StartTime.Unix(), 10)
+}
+
+// UpdateHistory records the progress of the upgrade attempt from the stage conditions. A new record is started when
+// the Prep stage starts, and the start and end of each stage are recorded as they are seen
+func UpdateHistory(ibu *lcav1alpha1.ImageBasedUpgrade) {
+	record := currentRecord(ibu)
+	if record == nil {
+		prep := GetInProgressCondition(ibu, lcav1alpha1.Stages.Prep)
+		if prep == nil || prep.Status != metav1.ConditionTrue {
+			return
+		}
+		ibu.Status.History = append([]lcav1alpha1.UpgradeRecord{{StartTime: prep.LastTransitionTime}}, ibu.Status.History...)
+		if len(ibu.Status.History) > MaxHistoryLength {
+			ibu.Status.History = ibu.Status.History[:MaxHistoryLength]
+		}
+		record = &ibu.Status.History[0]
+	}
+
+	record.SeedImage = ibu.Spec.SeedImageRef.Image
+	record.ToVersion = ibu.Spec.SeedImageRef.Version
+	if ibu.Status.SeedImageDigest != "" {
+		record.SeedImageDigest = ibu.Status.SeedImageDigest
+	}
+
+	for _, stage := range []lcav1alpha1.ImageBasedUpgradeStage{lcav1alpha1.Stages.Prep, lcav1alpha1.Stages.Upgrade, lcav1alpha1.Stages.Rollback} {
+		inProgress := GetInProgressCondition(ibu, stage)
+		completed := GetCompletedCondition(ibu, stage)
+		if inProgress == nil || (inProgress.Status != metav1.ConditionTrue && completed == nil) {
+			// Not started, e.g. only rejected as an invalid transition
+			continue
+		}
+
+		var stageRecord *lcav1alpha1.StageRecord
+		for i := range record.Stages {
+			if record.Stages[i].Stage == stage {
+				stageRecord = &record.Stages[i]
+			}
+		}
+		if stageRecord == nil {
+			record.Stages = append(record.Stages, lcav1alpha1.StageRecord{Stage: stage, StartTime: inProgress.LastTransitionTime})
+			stageRecord = &record.Stages[len(record.Stages)-1]
+		}
+
+		if completed != nil && stageRecord.CompletionTime == nil {
+			completionTime := completed.LastTransitionTime
+			stageRecord.CompletionTime = &completionTime
+			if completed.Status == metav1.ConditionTrue {
+				stageRecord.Result = string(ConditionReasons.Completed)
+			} else {
+				stageRecord.Result = string(ConditionReasons.Failed)
+				stageRecord.Message = inProgress.Message
+			}
+		}
+	}
+}
+
+// SetHistoryFromVersion records the version of the cluster the upgrade attempt in progress started from
+func SetHistoryFromVersion(ibu *lcav1alpha1.ImageBasedUpgrade, version string) {
+	if record := currentRecord(ibu); record != nil && version != "" {
+		record.FromVersion = version
+	}
+}
+
+// SetHistoryPrecacheSummary records the summary of the precaching job of the upgrade attempt in progress
+func SetHistoryPrecacheSummary(ibu *lcav1alpha1.ImageBasedUpgrade, summary string) {
+	if record := currentRecord(ibu); record != nil && summary != "" {
+		record.PrecacheSummary = summary
+	}
+}
+
+// SetHistoryRollbackReason records why the upgrade attempt in progress is being rolled back, either automatically on
+// failure or on request with ManualRollbackReason
+func SetHistoryRollbackReason(ibu *lcav1alpha1.ImageBasedUpgrade, reason string) {
+	if record := currentRecord(ibu); record != nil {
+		record.RollbackReason = reason
+	}
+}
+
+// CompleteHistory closes the record of the upgrade attempt in progress when the IBU returns to Idle. It must be called
+// before the conditions are reset. A finalized attempt was either upgraded or rolled back, and an aborted attempt is
+// recorded as rolled back if it was automatically rolled back beforehand
+func CompleteHistory(ibu *lcav1alpha1.ImageBasedUpgrade, finalized bool) {
+	UpdateHistory(ibu)
+	record := currentRecord(ibu)
+	if record == nil {
+		return
+	}
+	now := metav1.Now()
+	record.CompletionTime = &now
+	switch {
+	case finalized && IsStageCompleted(ibu, lcav1alpha1.Stages.Upgrade):
+		record.Outcome = lcav1alpha1.UpgradeOutcomes.Upgraded
+	case finalized || record.RollbackReason != "":
+		record.Outcome = lcav1alpha1.UpgradeOutcomes.RolledBack
+	default:
+		record.Outcome = lcav1alpha1.UpgradeOutcomes.Aborted
+	}
+}
@@ -0,0 +1,145 @@
+package utils
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	lcav1alpha1 "github.com/openshift-kni/lifecycle-agent/api/v1alpha1"
+)
+
+func newHistoryIBU() *lcav1alpha1.ImageBasedUpgrade {
+	ibu := &lcav1alpha1.ImageBasedUpgrade{
+		Spec: lcav1alpha1.ImageBasedUpgradeSpec{
+			SeedImageRef: lcav1alpha1.SeedImageRef{Image: "quay.io/openshift-kni/seed:4.16.1", Version: "4.16.1"},
+		},
+	}
+	ResetStatusConditions(&ibu.Status.Conditions, ibu.Generation)
+	return ibu
+}
+
+func TestHistory(t *testing.T) {
+	testcases := []struct {
+		name        string
+		run         func(ibu *lcav1alpha1.ImageBasedUpgrade)
+		finalized   bool
+		wantOutcome lcav1alpha1.UpgradeOutcome
+		wantStages  map[lcav1alpha1.ImageBasedUpgradeStage]string
+		wantReason  string
+	}{
+		{
+			name: "upgraded",
+			run: func(ibu *lcav1alpha1.ImageBasedUpgrade) {
+				SetUpgradeStatusInProgress(ibu, "In progress")
+				UpdateHistory(ibu)
+				SetUpgradeStatusCompleted(ibu)
+			},
+			finalized:   true,
+			wantOutcome: lcav1alpha1.UpgradeOutcomes.Upgraded,
+			wantStages: map[lcav1alpha1.ImageBasedUpgradeStage]string{
+				lcav1alpha1.Stages.Prep:    "Completed",
+				lcav1alpha1.Stages.Upgrade: "Completed",
+			},
+		},
+		{
+			name: "rolled back on request",
+			run: func(ibu *lcav1alpha1.ImageBasedUpgrade) {
+				SetUpgradeStatusInProgress(ibu, "In progress")
+				UpdateHistory(ibu)
+				SetUpgradeStatusFailed(ibu, "Rollback requested")
+				SetRollbackStatusInProgress(ibu, "In progress")
+				SetHistoryRollbackReason(ibu, ManualRollbackReason)
+				UpdateHistory(ibu)
+				SetRollbackStatusCompleted(ibu)
+			},
+			finalized:   true,
+			wantOutcome: lcav1alpha1.UpgradeOutcomes.RolledBack,
+			wantStages: map[lcav1alpha1.ImageBasedUpgradeStage]string{
+				lcav1alpha1.Stages.Prep:     "Completed",
+				lcav1alpha1.Stages.Upgrade:  "Failed",
+				lcav1alpha1.Stages.Rollback: "Completed",
+			},
+			wantReason: ManualRollbackReason,
+		},
+		{
+			name: "automatically rolled back then aborted",
+			run: func(ibu *lcav1alpha1.ImageBasedUpgrade) {
+				SetUpgradeStatusInProgress(ibu, "In progress")
+				UpdateHistory(ibu)
+				SetUpgradeStatusFailed(ibu, "Rollback due to health check failure")
+				SetHistoryRollbackReason(ibu, "Rollback due to health check failure")
+			},
+			wantOutcome: lcav1alpha1.UpgradeOutcomes.RolledBack,
+			wantStages: map[lcav1alpha1.ImageBasedUpgradeStage]string{
+				lcav1alpha1.Stages.Prep:    "Completed",
+				lcav1alpha1.Stages.Upgrade: "Failed",
+			},
+			wantReason: "Rollback due to health check failure",
+		},
+		{
+			name: "aborted after prep",
+			run: func(ibu *lcav1alpha1.ImageBasedUpgrade) {
+				// a rejected rollback transition doesn't start the Rollback stage
+				SetStatusCondition(&ibu.Status.Conditions, ConditionTypes.RollbackInProgress, ConditionReasons.InvalidTransition,
+					metav1.ConditionFalse, "Upgrade not started or already finalized", ibu.Generation)
+			},
+			wantOutcome: lcav1alpha1.UpgradeOutcomes.Aborted,
+			wantStages: map[lcav1alpha1.ImageBasedUpgradeStage]string{
+				lcav1alpha1.Stages.Prep: "Completed",
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			ibu := newHistoryIBU()
+			ibu.Status.History = []lcav1alpha1.UpgradeRecord{{Outcome: lcav1alpha1.UpgradeOutcomes.Aborted, CompletionTime: &metav1.Time{}}}
+
+			assert.Empty(t, CurrentAttempt(ibu))
+			SetPrepStatusInProgress(ibu, "In progress")
+			UpdateHistory(ibu)
+			attempt := CurrentAttempt(ibu)
+			assert.Equal(t, strconv.FormatInt(ibu.Status.History[0].StartTime.Unix(), 10), attempt)
+			SetHistoryFromVersion(ibu, "4.14.8")
+			ibu.Status.SeedImageDigest = "sha256:0123"
+			SetHistoryPrecacheSummary(ibu, "total: 10 (pulled: 10, skipped: 0, failed: 0)")
+			SetPrepStatusCompleted(ibu, "Prep completed")
+			UpdateHistory(ibu)
+			tc.run(ibu)
+			assert.Equal(t, attempt, CurrentAttempt(ibu))
+			CompleteHistory(ibu, tc.finalized)
+			ResetStatusConditions(&ibu.Status.Conditions, ibu.Generation)
+			UpdateHistory(ibu)
+
+			assert.Len(t, ibu.Status.History, 2)
+			record := ibu.Status.History[0]
+			assert.Equal(t, tc.wantOutcome, record.Outcome)
+			assert.NotNil(t, record.CompletionTime)
+			assert.Equal(t, "4.14.8", record.FromVersion)
+			assert.Equal(t, "4.16.1", record.ToVersion)
+			assert.Equal(t, "sha256:0123", record.SeedImageDigest)
+			assert.Equal(t, "total: 10 (pulled: 10, skipped: 0, failed: 0)", record.PrecacheSummary)
+			assert.Equal(t, tc.wantReason, record.RollbackReason)
+
+			stages := make(map[lcav1alpha1.ImageBasedUpgradeStage]string)
+			for _, stage := range record.Stages {
+				assert.NotNil(t, stage.CompletionTime, "stage %s", stage.Stage)
+				stages[stage.Stage] = stage.Result
+			}
+			assert.Equal(t, tc.wantStages, stages)
+		})
+	}
+}
+
+func TestHistoryIsBounded(t *testing.T) {
+	ibu := newHistoryIBU()
+	for i := 0; i < MaxHistoryLength+2; i++ {
+		SetPrepStatusInProgress(ibu, "In progress")
+		UpdateHistory(ibu)
+		CompleteHistory(ibu, false)
+		ResetStatusConditions(&ibu.Status.Conditions, ibu.Generation)
+	}
+	assert.Len(t, ibu.Status.History, MaxHistoryLength)
+}
@@ -0,0 +1,274 @@
+package archive
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+// ManifestSuffix is appended to the name of an archive for the name of its checksum manifest. The manifest lists the
+// sha256 of every regular file of the archive in the sha256sum format, to verify the files once extracted
+const ManifestSuffix = ".sha256"
+
+// selinuxXattr is the SELinux label. It is stored in the pax record GNU tar reads with --selinux, the other xattrs
+// are stored in the SCHILY records it reads with --xattrs
+const (
+	selinuxXattr     = "security.selinux"
+	selinuxPAXRecord = "RHT." + selinuxXattr
+	xattrPAXPrefix   = "SCHILY.xattr."
+)
+
+// Archiver writes a zstd compressed tar archive, compressed with as many threads as there are CPUs
+type Archiver struct {
+	log      *logrus.Logger
+	baseDir  string
+	excludes []string
+
+	tw       *tar.Writer
+	manifest []string
+	seen     map[string]bool
+	inodes   map[inode]string
+}
+
+type inode struct {
+	dev uint64
+	ino uint64
+}
+
+// NewArchiver returns an archiver of paths relative to baseDir. The excludes are the tar patterns of the paths left
+// out: a pattern starting with / is anchored to the root, others match the end of a path, and excluding a
+// directory excludes its content
+func NewArchiver(log *logrus.Logger, baseDir string, excludes []string) *Archiver {
+	return &Archiver{log: log, baseDir: baseDir, excludes: excludes}
+}
+
+// Create archives the paths, recursively, in the file and writes its checksum manifest next to it. The paths are
+// relative to the base dir and are the names of the archive entries, e.g. "var" or "."
+func (a *Archiver) Create(file string, paths ...string) (err error) {
+	a.manifest = nil
+	a.seen = make(map[string]bool)
+	a.inodes = make(map[inode]string)
+
+	f, err := os.Create(file)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", file, err)
+	}
+	defer f.Close()
+
+	zw, err := zstd.NewWriter(f, zstd.WithEncoderConcurrency(runtime.NumCPU()))
+	if err != nil {
+		return fmt.Errorf("failed to create zstd writer: %w", err)
+	}
+	a.tw = tar.NewWriter(zw)
+
+	for _, p := range paths {
+		if err := a.addTree(p); err != nil {
+			return fmt.Errorf("failed to archive %s: %w", p, err)
+		}
+	}
+
+	if err := a.tw.Close(); err != nil {
+		return fmt.Errorf("failed to write tar archive: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to compress tar archive: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", file, err)
+	}
+
+	if err := os.WriteFile(file+ManifestSuffix, []byte(strings.Join(a.manifest, "")), 0o600); err != nil {
+		return fmt.Errorf("failed to write checksum manifest of %s: %w", file, err)
+	}
+	return nil
+}
+
+func (a *Archiver) addTree(name string) error {
+	root := filepath.Join(a.baseDir, name)
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error { //nolint:wrapcheck
+		if err != nil {
+			return err
+		}
+		if a.excluded(p) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		return a.add(p, name+strings.TrimPrefix(p, root))
+	})
+}
+
+// excluded returns true if a pattern matches the path or one of its parent directories
+func (a *Archiver) excluded(p string) bool {
+	for ; p != "/" && p != "."; p = filepath.Dir(p) {
+		for _, pattern := range a.excludes {
+			if matchPattern(pattern, p) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchPattern matches an anchored pattern against the absolute path, and an unanchored one against its ends
+func matchPattern(pattern, p string) bool {
+	if strings.HasPrefix(pattern, "/") {
+		matched, _ := path.Match(pattern, p)
+		return matched
+	}
+	for i := 0; i < len(p); i++ {
+		if i == 0 || p[i-1] == '/' {
+			if matched, _ := path.Match(pattern, p[i:]); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (a *Archiver) add(p, name string) error {
+	if a.seen[name] {
+		return nil
+	}
+	a.seen[name] = true
+
+	info, err := os.Lstat(p)
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", p, err)
+	}
+	// As with tar, sockets are not archived
+	if info.Mode()&fs.ModeSocket != 0 {
+		a.log.Debugf("Skipping socket %s", p)
+		return nil
+	}
+
+	var link string
+	if info.Mode()&fs.ModeSymlink != 0 {
+		if link, err = os.Readlink(p); err != nil {
+			return fmt.Errorf("failed to read link %s: %w", p, err)
+		}
+	}
+	hdr, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return fmt.Errorf("failed to create tar header for %s: %w", p, err)
+	}
+	hdr.Name = name
+	if info.IsDir() {
+		hdr.Name += "/"
+	}
+	// The entries are extracted on another node, by their numeric IDs
+	hdr.Uname, hdr.Gname = "", ""
+	hdr.Format = tar.FormatPAX
+	if hdr.PAXRecords, err = paxXattrs(p); err != nil {
+		return err
+	}
+
+	// Files with several links are archived once, the other names are hard links to the first one
+	if st, ok := info.Sys().(*syscall.Stat_t); ok && info.Mode().IsRegular() && st.Nlink > 1 {
+		key := inode{dev: uint64(st.Dev), ino: st.Ino} //nolint:unconvert
+		if target, ok := a.inodes[key]; ok {
+			hdr.Typeflag = tar.TypeLink
+			hdr.Linkname = target
+			hdr.Size = 0
+			return a.writeHeader(hdr)
+		}
+		a.inodes[key] = name
+	}
+
+	if err := a.writeHeader(hdr); err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+	return a.writeContent(p, name)
+}
+
+func (a *Archiver) writeHeader(hdr *tar.Header) error {
+	if err := a.tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("failed to write tar header for %s: %w", hdr.Name, err)
+	}
+	return nil
+}
+
+func (a *Archiver) writeContent(p, name string) error {
+	f, err := os.Open(p)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", p, err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(a.tw, h), f); err != nil {
+		return fmt.Errorf("failed to archive %s: %w", p, err)
+	}
+	a.manifest = append(a.manifest, fmt.Sprintf("%s  %s\n", hex.EncodeToString(h.Sum(nil)), name))
+	return nil
+}
+
+// paxXattrs returns the pax records of the xattrs of the file, including its SELinux label
+func paxXattrs(p string) (map[string]string, error) {
+	names, err := listXattrs(p)
+	if err != nil {
+		return nil, err
+	}
+	records := make(map[string]string, len(names))
+	for _, xattr := range names {
+		value, err := getXattr(p, xattr)
+		if err != nil {
+			return nil, err
+		}
+		if xattr == selinuxXattr {
+			// The label is a null terminated string
+			records[selinuxPAXRecord] = strings.TrimRight(value, "\x00")
+		} else {
+			records[xattrPAXPrefix+xattr] = value
+		}
+	}
+	return records, nil
+}
+
+func listXattrs(p string) ([]string, error) {
+	size, err := unix.Llistxattr(p, nil)
+	if err != nil {
+		if errors.Is(err, unix.ENOTSUP) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list xattrs of %s: %w", p, err)
+	}
+	if size == 0 {
+		return nil, nil
+	}
+	buf := make([]byte, size)
+	if size, err = unix.Llistxattr(p, buf); err != nil {
+		return nil, fmt.Errorf("failed to list xattrs of %s: %w", p, err)
+	}
+	return strings.Split(strings.TrimRight(string(buf[:size]), "\x00"), "\x00"), nil
+}
+
+func getXattr(p, xattr string) (string, error) {
+	size, err := unix.Lgetxattr(p, xattr, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to get xattr %s of %s: %w", xattr, p, err)
+	}
+	buf := make([]byte, size)
+	if size, err = unix.Lgetxattr(p, xattr, buf); err != nil {
+		return "", fmt.Errorf("failed to get xattr %s of %s: %w", xattr, p, err)
+	}
+	return string(buf[:size]), nil
+}
@@ -0,0 +1,204 @@
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/samber/lo"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchPattern(t *testing.T) {
+	testcases := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{pattern: "/var/tmp/*", path: "/var/tmp/backup", want: true},
+		{pattern: "/var/tmp/*", path: "/var/tmp", want: false},
+		{pattern: "/var/lib/lca", path: "/var/lib/lca", want: true},
+		{pattern: "/var/lib/lca", path: "/var/lib/lcall", want: false},
+		{pattern: "*/.bash_history", path: "/var/roothome/.bash_history", want: true},
+		{pattern: "*/.bash_history", path: "/var/roothome/.bash_profile", want: false},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.pattern+" "+tc.path, func(t *testing.T) {
+			assert.Equal(t, tc.want, matchPattern(tc.pattern, tc.path))
+		})
+	}
+}
+
+func TestCreate(t *testing.T) {
+	baseDir := t.TempDir()
+	files := map[string]string{
+		"var/lib/kubelet/config.json": "kubelet",
+		"var/tmp/backup/var.tar.zst":  "excluded",
+		"var/roothome/.bash_history":  "excluded",
+		"var/roothome/.bashrc":        "bashrc",
+	}
+	for name, content := range files {
+		assert.NoError(t, os.MkdirAll(filepath.Join(baseDir, filepath.Dir(name)), 0o700))
+		assert.NoError(t, os.WriteFile(filepath.Join(baseDir, name), []byte(content), 0o600))
+	}
+	assert.NoError(t, os.Link(filepath.Join(baseDir, "var/roothome/.bashrc"), filepath.Join(baseDir, "var/roothome/.bashrc.orig")))
+	assert.NoError(t, os.Symlink("../run", filepath.Join(baseDir, "var/run")))
+
+	file := filepath.Join(t.TempDir(), "var.tar.zst")
+	archiver := NewArchiver(logrus.New(), baseDir, []string{"*/.bash_history", filepath.Join(baseDir, "var/tmp/*")})
+	assert.NoError(t, archiver.Create(file, "var"))
+
+	f, err := os.Open(file)
+	assert.NoError(t, err)
+	defer f.Close()
+	zr, err := zstd.NewReader(f)
+	assert.NoError(t, err)
+	defer zr.Close()
+
+	entries := map[string]*tar.Header{}
+	tr := tar.NewReader(zr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		entries[hdr.Name] = hdr
+	}
+	assert.ElementsMatch(t, []string{
+		"var/", "var/lib/", "var/lib/kubelet/", "var/lib/kubelet/config.json", "var/roothome/",
+		"var/roothome/.bashrc", "var/roothome/.bashrc.orig", "var/run", "var/tmp/",
+	}, lo.Keys(entries))
+	assert.Equal(t, byte(tar.TypeLink), entries["var/roothome/.bashrc.orig"].Typeflag)
+	assert.Equal(t, "var/roothome/.bashrc", entries["var/roothome/.bashrc.orig"].Linkname)
+	assert.Equal(t, "../run", entries["var/run"].Linkname)
+
+	manifest, err := os.ReadFile(file + ManifestSuffix)
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("%s  var/lib/kubelet/config.json\n%s  var/roothome/.bashrc\n",
+		sha256Hex("kubelet"), sha256Hex("bashrc")), string(manifest))
+}
+
+func sha256Hex(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestExtract(t *testing.T) {
+	baseDir := t.TempDir()
+	assert.NoError(t, os.MkdirAll(filepath.Join(baseDir, "repo/objects/ab"), 0o750))
+	object := filepath.Join(baseDir, "repo/objects/ab/cdef.file")
+	assert.NoError(t, os.WriteFile(object, []byte("object"), 0o644))
+	assert.NoError(t, os.Chmod(object, os.ModeSetuid|0o755))
+	assert.NoError(t, os.Link(object, filepath.Join(baseDir, "repo/objects/ab/cdef.link")))
+	assert.NoError(t, os.Symlink("objects/ab/cdef.file", filepath.Join(baseDir, "repo/latest")))
+	mtime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.NoError(t, os.Chtimes(filepath.Join(baseDir, "repo/objects"), mtime, mtime))
+
+	file := filepath.Join(t.TempDir(), "ostree.tar.zst")
+	assert.NoError(t, NewArchiver(logrus.New(), filepath.Join(baseDir, "repo"), nil).Create(file, "."))
+
+	dest := t.TempDir()
+	// Existing files are replaced
+	assert.NoError(t, os.WriteFile(filepath.Join(dest, "latest"), []byte("stale"), 0o600))
+	progress := &Progress{}
+	assert.NoError(t, Extract(context.Background(), file, dest, progress))
+	assert.Equal(t, int64(6), progress.Files())
+	assert.Equal(t, int64(len("object")), progress.Bytes())
+
+	content, err := os.ReadFile(filepath.Join(dest, "objects/ab/cdef.file"))
+	assert.NoError(t, err)
+	assert.Equal(t, "object", string(content))
+	info, err := os.Stat(filepath.Join(dest, "objects/ab/cdef.file"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o755)|os.ModeSetuid, info.Mode())
+	link, err := os.Stat(filepath.Join(dest, "objects/ab/cdef.link"))
+	assert.NoError(t, err)
+	assert.Equal(t, info.Sys().(*syscall.Stat_t).Ino, link.Sys().(*syscall.Stat_t).Ino)
+	target, err := os.Readlink(filepath.Join(dest, "latest"))
+	assert.NoError(t, err)
+	assert.Equal(t, "objects/ab/cdef.file", target)
+	dir, err := os.Stat(filepath.Join(dest, "objects"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.ModeDir|0o750, dir.Mode())
+	assert.True(t, mtime.Equal(dir.ModTime()))
+
+	// The extraction stops once the context is canceled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, Extract(ctx, file, t.TempDir(), &Progress{}), context.Canceled)
+}
+
+func TestExtractGzip(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "etc.tgz")
+	f, err := os.Create(file)
+	assert.NoError(t, err)
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+	assert.NoError(t, tw.WriteHeader(&tar.Header{Name: "etc/hostname", Mode: 0o644, Size: 4, Typeflag: tar.TypeReg,
+		Uid: os.Getuid(), Gid: os.Getgid(), ModTime: time.Now()}))
+	_, err = tw.Write([]byte("sno1"))
+	assert.NoError(t, err)
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gw.Close())
+	assert.NoError(t, f.Close())
+
+	dest := t.TempDir()
+	assert.NoError(t, Extract(context.Background(), file, dest, &Progress{}))
+	content, err := os.ReadFile(filepath.Join(dest, "etc/hostname"))
+	assert.NoError(t, err)
+	assert.Equal(t, "sno1", string(content))
+
+	// Entries out of the destination are rejected
+	f, err = os.Create(file)
+	assert.NoError(t, err)
+	gw = gzip.NewWriter(f)
+	tw = tar.NewWriter(gw)
+	assert.NoError(t, tw.WriteHeader(&tar.Header{Name: "../hostname", Mode: 0o644, Typeflag: tar.TypeReg}))
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gw.Close())
+	assert.NoError(t, f.Close())
+	assert.ErrorContains(t, Extract(context.Background(), file, dest, &Progress{}), "outside of the destination")
+
+	// Entries beneath a symlink extracted earlier are rejected, as they would be written out of the destination
+	outside := t.TempDir()
+	for _, hdrs := range [][]*tar.Header{
+		{
+			{Name: "etc", Linkname: outside, Typeflag: tar.TypeSymlink},
+			{Name: "etc/passwd", Mode: 0o644, Typeflag: tar.TypeReg},
+		},
+		{
+			{Name: "etc", Linkname: outside, Typeflag: tar.TypeSymlink},
+			{Name: "hostname", Linkname: "etc/hostname", Typeflag: tar.TypeLink},
+		},
+	} {
+		dest = t.TempDir()
+		f, err = os.Create(file)
+		assert.NoError(t, err)
+		gw = gzip.NewWriter(f)
+		tw = tar.NewWriter(gw)
+		for _, hdr := range hdrs {
+			hdr.Uid, hdr.Gid, hdr.ModTime = os.Getuid(), os.Getgid(), time.Now()
+			assert.NoError(t, tw.WriteHeader(hdr))
+		}
+		assert.NoError(t, tw.Close())
+		assert.NoError(t, gw.Close())
+		assert.NoError(t, f.Close())
+		assert.ErrorContains(t, Extract(context.Background(), file, dest, &Progress{}), "is a symlink")
+		entries, err := os.ReadDir(outside)
+		assert.NoError(t, err)
+		assert.Empty(t, entries)
+	}
+}
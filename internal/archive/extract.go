@@ -0,0 +1,328 @@
+package archive
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"golang.org/x/sys/unix"
+)
+
+var (
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	gzipMagic = []byte{0x1f, 0x8b}
+)
+
+// Progress counts the entries and the bytes of file content extracted. It can be shared by concurrent extractions
+type Progress struct {
+	files atomic.Int64
+	bytes atomic.Int64
+}
+
+// Files returns the number of entries extracted
+func (p *Progress) Files() int64 {
+	return p.files.Load()
+}
+
+// Bytes returns the size of the file content extracted
+func (p *Progress) Bytes() int64 {
+	return p.bytes.Load()
+}
+
+// Extract extracts the tar archive, compressed with zstd or gzip, in the existing dest directory, as GNU tar does
+// with --selinux --xattrs --numeric-owner. The ownership, permissions, times, SELinux labels, xattrs and hard links
+// of the entries are preserved, and existing files are replaced. It stops when the context is canceled
+func Extract(ctx context.Context, src, dest string, progress *Progress) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", src, err)
+	}
+	defer f.Close()
+
+	r, closeReader, err := decompress(bufio.NewReaderSize(f, 1<<20))
+	if err != nil {
+		return fmt.Errorf("failed to decompress %s: %w", src, err)
+	}
+	defer closeReader()
+
+	e := &extraction{dest: filepath.Clean(dest), progress: progress, parents: map[string]bool{}}
+	if err := e.run(tar.NewReader(&contextReader{ctx: ctx, r: r})); err != nil {
+		return fmt.Errorf("failed to extract %s: %w", src, err)
+	}
+	return nil
+}
+
+// decompress selects the decompression from the magic number of the archive
+func decompress(r *bufio.Reader) (io.Reader, func(), error) {
+	magic, err := r.Peek(len(zstdMagic))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read archive header: %w", err)
+	}
+	switch {
+	case bytes.HasPrefix(magic, zstdMagic):
+		zr, err := zstd.NewReader(r)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create zstd reader: %w", err)
+		}
+		return zr, zr.Close, nil
+	case bytes.HasPrefix(magic, gzipMagic):
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create gzip reader: %w", err)
+		}
+		return gr, func() { gr.Close() }, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported compression")
+	}
+}
+
+// contextReader fails the reads once the context is canceled, to interrupt the extraction of large files
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err //nolint:wrapcheck
+	}
+	return c.r.Read(p) //nolint:wrapcheck
+}
+
+type extraction struct {
+	dest     string
+	progress *Progress
+	// The times of the directories are set last, once their content is extracted
+	dirs []*tar.Header
+	// parents caches the directories checked not to be symlinks. It is reset when a symlink is extracted, as the
+	// symlink may replace one of them
+	parents map[string]bool
+}
+
+func (e *extraction) run(tr *tar.Reader) error {
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read tar entry: %w", err)
+		}
+		if err := e.extract(tr, hdr); err != nil {
+			return err
+		}
+		e.progress.files.Add(1)
+	}
+
+	for i := len(e.dirs) - 1; i >= 0; i-- {
+		target, _ := e.target(e.dirs[i].Name)
+		if err := setTimes(target, e.dirs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// target returns the path of the entry, which must be in the dest directory. Its parent directories are resolved
+// without following symlinks, so that a symlink extracted earlier cannot redirect the entry out of dest
+func (e *extraction) target(name string) (string, error) {
+	target := filepath.Join(e.dest, name)
+	if target != e.dest && !strings.HasPrefix(target, e.dest+string(filepath.Separator)) {
+		return "", fmt.Errorf("tar entry %s is outside of the destination", name)
+	}
+	if err := e.checkParents(name, target); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// checkParents fails when a parent directory of the target, below dest, is a symlink. The missing parent
+// directories are created by the extraction, and can't be symlinks
+func (e *extraction) checkParents(name, target string) error {
+	dir := e.dest
+	for _, part := range strings.Split(strings.TrimPrefix(filepath.Dir(target), e.dest), string(filepath.Separator)) {
+		if part == "" {
+			continue
+		}
+		dir = filepath.Join(dir, part)
+		if e.parents[dir] {
+			continue
+		}
+		info, err := os.Lstat(dir)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to stat %s: %w", dir, err)
+		}
+		if info.Mode()&fs.ModeSymlink != 0 {
+			return fmt.Errorf("tar entry %s is outside of the destination: %s is a symlink", name, dir)
+		}
+		e.parents[dir] = true
+	}
+	return nil
+}
+
+func (e *extraction) extract(tr *tar.Reader, hdr *tar.Header) error {
+	target, err := e.target(hdr.Name)
+	if err != nil {
+		return err
+	}
+
+	if hdr.Typeflag == tar.TypeDir {
+		// A symlink is replaced by the directory rather than followed
+		if info, err := os.Lstat(target); err == nil && info.Mode()&fs.ModeSymlink != 0 {
+			if err := os.Remove(target); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", target, err)
+			}
+		}
+		if err := os.Mkdir(target, 0o700); err != nil && !errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("failed to create directory %s: %w", target, err)
+		}
+		e.dirs = append(e.dirs, hdr)
+		return setMetadata(target, hdr)
+	}
+
+	// Existing files are unlinked rather than overwritten, as they may be hard links to ostree objects. The parent
+	// directories may not be archived, e.g. in the ostree delta
+	if err := os.Remove(target); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove %s: %w", target, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return fmt.Errorf("failed to create the parent directory of %s: %w", target, err)
+	}
+
+	switch hdr.Typeflag {
+	case tar.TypeReg:
+		if err := e.writeFile(tr, target); err != nil {
+			return err
+		}
+	case tar.TypeLink:
+		linkTarget, err := e.target(hdr.Linkname)
+		if err != nil {
+			return err
+		}
+		if err := os.Link(linkTarget, target); err != nil {
+			return fmt.Errorf("failed to create hard link %s: %w", target, err)
+		}
+		// The metadata is the one of the linked file
+		return nil
+	case tar.TypeSymlink:
+		if err := os.Symlink(hdr.Linkname, target); err != nil {
+			return fmt.Errorf("failed to create symlink %s: %w", target, err)
+		}
+		clear(e.parents)
+	case tar.TypeChar, tar.TypeBlock, tar.TypeFifo:
+		if err := mknod(target, hdr); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported type %q of tar entry %s", hdr.Typeflag, hdr.Name)
+	}
+
+	if err := setMetadata(target, hdr); err != nil {
+		return err
+	}
+	return setTimes(target, hdr)
+}
+
+func (e *extraction) writeFile(tr *tar.Reader, target string) error {
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", target, err)
+	}
+	defer f.Close()
+
+	n, err := io.Copy(f, tr)
+	e.progress.bytes.Add(n)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", target, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", target, err)
+	}
+	return nil
+}
+
+func mknod(target string, hdr *tar.Header) error {
+	var mode uint32
+	switch hdr.Typeflag {
+	case tar.TypeChar:
+		mode = unix.S_IFCHR
+	case tar.TypeBlock:
+		mode = unix.S_IFBLK
+	default:
+		mode = unix.S_IFIFO
+	}
+	dev := unix.Mkdev(uint32(hdr.Devmajor), uint32(hdr.Devminor)) //nolint:gosec
+	if err := unix.Mknod(target, mode|0o600, int(dev)); err != nil {
+		return fmt.Errorf("failed to create special file %s: %w", target, err)
+	}
+	return nil
+}
+
+// setMetadata sets the ownership, the permissions and then the xattrs, which would be reset by a change of owner
+func setMetadata(target string, hdr *tar.Header) error {
+	if err := os.Lchown(target, hdr.Uid, hdr.Gid); err != nil {
+		return fmt.Errorf("failed to change the owner of %s: %w", target, err)
+	}
+	if hdr.Typeflag != tar.TypeSymlink {
+		mode := hdr.FileInfo().Mode() & (fs.ModePerm | fs.ModeSetuid | fs.ModeSetgid | fs.ModeSticky)
+		if err := os.Chmod(target, mode); err != nil {
+			return fmt.Errorf("failed to change the mode of %s: %w", target, err)
+		}
+	}
+
+	for record, value := range hdr.PAXRecords {
+		var xattr string
+		switch {
+		case record == selinuxPAXRecord:
+			xattr = selinuxXattr
+		case strings.HasPrefix(record, xattrPAXPrefix):
+			xattr = strings.TrimPrefix(record, xattrPAXPrefix)
+		default:
+			continue
+		}
+		if err := unix.Lsetxattr(target, xattr, []byte(value), 0); err != nil {
+			return fmt.Errorf("failed to set xattr %s of %s: %w", xattr, target, err)
+		}
+	}
+	return nil
+}
+
+func setTimes(target string, hdr *tar.Header) error {
+	times := []unix.Timespec{unix.NsecToTimespec(hdr.ModTime.UnixNano()), unix.NsecToTimespec(hdr.ModTime.UnixNano())}
+	if !hdr.AccessTime.IsZero() {
+		times[0] = unix.NsecToTimespec(hdr.AccessTime.UnixNano())
+	}
+	if err := unix.UtimesNanoAt(unix.AT_FDCWD, target, times, unix.AT_SYMLINK_NOFOLLOW); err != nil {
+		return fmt.Errorf("failed to set the times of %s: %w", target, err)
+	}
+	return nil
+}
+
+// ReportProgress calls report with the progress every interval, until the context is done
+func ReportProgress(ctx context.Context, progress *Progress, interval time.Duration, report func(files, bytes int64)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report(progress.Files(), progress.Bytes())
+		}
+	}
+}
@@ -23,6 +23,9 @@ const (
 	BackupCertsDir  = "/var/tmp/backupCertsDir"
 	BackupChecksDir = "/var/tmp/checks"
 
+	SysrootDir          = "/sysroot"
+	ContainerStorageDir = "/var/lib/containers"
+
 	// Workload partitioning annotation key and value
 	WorkloadManagementAnnotationKey   = "target.workload.openshift.io/management"
 	WorkloadManagementAnnotationValue = `{"effect": "PreferredDuringScheduling"}`
@@ -46,6 +49,7 @@ const (
 	ClusterConfigDir                  = "cluster-configuration"
 	SeedClusterInfoFileName           = "manifest.json"
 	SeedReconfigurationFileName       = "manifest.json"
+	SeedContentFileName               = "seed-content.json"
 	ManifestsDir                      = "manifests"
 	ExtraManifestsDir                 = "extra-manifests"
 	EtcdContainerName                 = "recert_etcd"
@@ -56,6 +60,8 @@ const (
 	LCAConfigDir                                    = "/var/lib/lca"
 	IBUAutoRollbackConfigFile                       = LCAConfigDir + "/autorollback_config.json"
 	IBUAutoRollbackInitMonitorTimeoutDefaultSeconds = 1800
+	IBUAutoRollbackInitMonitorTimeoutMinSeconds     = 300
+	IBUAutoRollbackInitMonitorTimeoutMaxSeconds     = 86400
 	IBUInitMonitorService                           = "lca-init-monitor.service"
 	IBUInitMonitorServiceFile                       = "/etc/systemd/system/" + IBUInitMonitorService
 
@@ -73,9 +79,27 @@ const (
 	// Env var to configure auto rollback for post-reboot config failure
 	IBUPostRebootConfigAutoRollbackOnFailureEnv = "LCA_IBU_AUTO_ROLLBACK_ON_CONFIG_FAILURE"
 
+	// Keys of the secrets and config maps used to sign and verify the seed image
+	CosignPublicKeyKey  = "cosign.pub"
+	CosignPrivateKeyKey = "cosign.key"
+	CosignPasswordKey   = "cosign.password"
+	ContainersPolicyKey = "policy.json"
+
+	// NotificationSigningKeyKey is the key of the secrets holding the HMAC keys the notifications are signed with
+	NotificationSigningKeyKey = "hmac.key"
+
 	// Bump this every time the seed format changes in a backwards incompatible way
-	SeedFormatVersion  = 3
+	SeedFormatVersion  = 5
 	SeedFormatOCILabel = "com.openshift.lifecycle-agent.seed_format_version"
+	// MinSeedFormatVersion is the oldest seed format still supported. Format 4 split the files of format 3 in several
+	// layers, the mounted image is the same. Format 5 compresses the archives with zstd instead of gzip
+	MinSeedFormatVersion = 3
+	// ZstdSeedFormatVersion is the first seed format with zstd archives
+	ZstdSeedFormatVersion = 5
+
+	// The labels of a delta seed image, holding the digest and the pinned reference of the seed image it is based on
+	SeedBaseDigestOCILabel = "com.openshift.lifecycle-agent.base_seed_digest"
+	SeedBaseImageOCILabel  = "com.openshift.lifecycle-agent.base_seed_image"
 
 	PullSecretName           = "pull-secret"
 	PullSecretEmptyData      = "{\"auths\":{\"registry.connect.redhat.com\":{\"username\":\"empty\",\"password\":\"empty\",\"auth\":\"ZW1wdHk6ZW1wdHk=\",\"email\":\"\"}}}" //nolint:gosec
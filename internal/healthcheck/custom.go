@@ -0,0 +1,166 @@
+package healthcheck
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/jsonpath"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	lcav1alpha1 "github.com/openshift-kni/lifecycle-agent/api/v1alpha1"
+)
+
+// ParseJSONPath parses the JSONPath template of a resource health check
+func ParseJSONPath(template string) (*jsonpath.JSONPath, error) {
+	jp := jsonpath.New("healthcheck").AllowMissingKeys(true)
+	if err := jp.Parse(template); err != nil {
+		return nil, fmt.Errorf("failed to parse JSONPath %s: %w", template, err)
+	}
+	return jp, nil
+}
+
+func describeResource(check lcav1alpha1.ResourceHealthCheck) string {
+	if check.Namespace == "" {
+		return fmt.Sprintf("%s %s", check.Kind, check.Name)
+	}
+	return fmt.Sprintf("%s %s/%s", check.Kind, check.Namespace, check.Name)
+}
+
+func resourceReady(c client.Reader, l logr.Logger, check lcav1alpha1.ResourceHealthCheck, timeout time.Duration) error {
+	return waitForReady(c, l, describeResource(check), notReadyResource(check), timeout)
+}
+
+// notReadyResource checks that the resource has the expected condition or value
+func notReadyResource(check lcav1alpha1.ResourceHealthCheck) notReadyFunc {
+	resource := describeResource(check)
+	return func(ctx context.Context, c client.Reader, _ logr.Logger) ([]string, error) {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(schema.FromAPIVersionAndKind(check.APIVersion, check.Kind))
+		if err := c.Get(ctx, types.NamespacedName{Name: check.Name, Namespace: check.Namespace}, obj); err != nil {
+			if k8serrors.IsNotFound(err) {
+				return []string{fmt.Sprintf("%s (not found)", resource)}, nil
+			}
+			if k8serrors.IsForbidden(err) {
+				return nil, fmt.Errorf("the lifecycle-agent is not allowed to get %s, get must be granted on %s to the "+
+					"lifecycle-agent-controller-manager service account: %w", resource, check.Kind, err)
+			}
+			return nil, fmt.Errorf("failed to get %s: %w", resource, err)
+		}
+
+		if check.Condition != "" {
+			conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+			for _, item := range conditions {
+				condition, ok := item.(map[string]any)
+				if ok && condition["type"] == check.Condition && condition["status"] == string(metav1.ConditionTrue) {
+					return nil, nil
+				}
+			}
+			return []string{fmt.Sprintf("%s (condition %s is not True)", resource, check.Condition)}, nil
+		}
+
+		jp, err := ParseJSONPath(check.JSONPath)
+		if err != nil {
+			return nil, err
+		}
+		var out bytes.Buffer
+		if err := jp.Execute(&out, obj.Object); err != nil {
+			return []string{fmt.Sprintf("%s (failed to evaluate %s: %s)", resource, check.JSONPath, err)}, nil
+		}
+		value := strings.TrimSpace(out.String())
+		if (check.Value == "" && value == "") || (check.Value != "" && value != check.Value) {
+			return []string{fmt.Sprintf("%s (%s is %q)", resource, check.JSONPath, value)}, nil
+		}
+		return nil, nil
+	}
+}
+
+func workloadsReady(c client.Reader, l logr.Logger, check lcav1alpha1.WorkloadHealthCheck, timeout time.Duration) error {
+	return waitForReady(c, l, describeWorkloads(check), notReadyWorkloads(check), timeout)
+}
+
+func describeWorkloads(check lcav1alpha1.WorkloadHealthCheck) string {
+	selector := metav1.FormatLabelSelector(&check.Selector)
+	if check.Namespace == "" {
+		return fmt.Sprintf("workloads matching %s", selector)
+	}
+	return fmt.Sprintf("workloads matching %s in %s", selector, check.Namespace)
+}
+
+// notReadyWorkloads checks that the Deployments, StatefulSets and DaemonSets matching the selector are ready
+func notReadyWorkloads(check lcav1alpha1.WorkloadHealthCheck) notReadyFunc {
+	return func(ctx context.Context, c client.Reader, _ logr.Logger) ([]string, error) {
+		selector, err := metav1.LabelSelectorAsSelector(&check.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid workload selector: %w", err)
+		}
+		opts := &client.ListOptions{LabelSelector: selector, Namespace: check.Namespace}
+
+		deployments := appsv1.DeploymentList{}
+		statefulSets := appsv1.StatefulSetList{}
+		daemonSets := appsv1.DaemonSetList{}
+		for _, list := range []client.ObjectList{&deployments, &statefulSets, &daemonSets} {
+			if err := c.List(ctx, list, opts); err != nil {
+				return nil, fmt.Errorf("failed to list workloads: %w", err)
+			}
+		}
+
+		if len(deployments.Items)+len(statefulSets.Items)+len(daemonSets.Items) == 0 {
+			return []string{fmt.Sprintf("%s (none found)", describeWorkloads(check))}, nil
+		}
+
+		var notReady []string
+		for _, deployment := range deployments.Items {
+			if !isDeploymentReady(&deployment) {
+				notReady = append(notReady, fmt.Sprintf("Deployment %s/%s", deployment.Namespace, deployment.Name))
+			}
+		}
+		for _, statefulSet := range statefulSets.Items {
+			if !isStatefulSetReady(&statefulSet) {
+				notReady = append(notReady, fmt.Sprintf("StatefulSet %s/%s", statefulSet.Namespace, statefulSet.Name))
+			}
+		}
+		for _, daemonSet := range daemonSets.Items {
+			if !isDaemonSetReady(&daemonSet) {
+				notReady = append(notReady, fmt.Sprintf("DaemonSet %s/%s", daemonSet.Namespace, daemonSet.Name))
+			}
+		}
+		return notReady, nil
+	}
+}
+
+func desiredReplicas(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+	return *replicas
+}
+
+func isDeploymentReady(deployment *appsv1.Deployment) bool {
+	replicas := desiredReplicas(deployment.Spec.Replicas)
+	return deployment.Status.ObservedGeneration >= deployment.Generation &&
+		deployment.Status.UpdatedReplicas == replicas &&
+		deployment.Status.AvailableReplicas == replicas
+}
+
+func isStatefulSetReady(statefulSet *appsv1.StatefulSet) bool {
+	replicas := desiredReplicas(statefulSet.Spec.Replicas)
+	return statefulSet.Status.ObservedGeneration >= statefulSet.Generation &&
+		statefulSet.Status.UpdatedReplicas == replicas &&
+		statefulSet.Status.ReadyReplicas == replicas
+}
+
+func isDaemonSetReady(daemonSet *appsv1.DaemonSet) bool {
+	return daemonSet.Status.ObservedGeneration >= daemonSet.Generation &&
+		daemonSet.Status.UpdatedNumberScheduled == daemonSet.Status.DesiredNumberScheduled &&
+		daemonSet.Status.NumberReady == daemonSet.Status.DesiredNumberScheduled
+}
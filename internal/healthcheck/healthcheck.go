@@ -4,13 +4,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	lcav1alpha1 "github.com/openshift-kni/lifecycle-agent/api/v1alpha1"
 	"github.com/openshift-kni/lifecycle-agent/internal/common"
 
 	"github.com/go-logr/logr"
+	"github.com/samber/lo"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -19,6 +23,7 @@ import (
 	mcv1 "github.com/openshift/api/machineconfiguration/v1"
 	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // +kubebuilder:rbac:groups=machineconfiguration.openshift.io,resources=machineconfigpools,verbs=list;watch
@@ -27,6 +32,9 @@ import (
 // +kubebuilder:rbac:groups=config.openshift.io,resources=clusterversions,verbs=list;watch
 // +kubebuilder:rbac:groups=config.openshift.io,resources=infrastructures,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=nodes,verbs=list;watch
+// +kubebuilder:rbac:groups=apps,resources=deployments;statefulsets;daemonsets,verbs=list;watch
+// +kubebuilder:rbac:groups=sriovnetwork.openshift.io,resources=sriovnetworknodestates,verbs=get
+// +kubebuilder:rbac:groups=ptp.openshift.io,resources=ptpoperatorconfigs,verbs=get
 
 var (
 	pollInterval = 30 * time.Second
@@ -39,49 +47,54 @@ const (
 	NodeRoleWorker       = "node-role.kubernetes.io/worker"
 )
 
-func HealthChecks(c client.Reader, l logr.Logger) error {
+// HealthChecks runs the built-in health checks, and the additional checks if any, in parallel. Each check is polled
+// until it passes or times out
+func HealthChecks(c client.Reader, l logr.Logger, checks *lcav1alpha1.HealthChecks) error {
 	defer common.FuncTimer(time.Now(), "healthCheck", l)
 
+	if checks == nil {
+		checks = &lcav1alpha1.HealthChecks{}
+	}
+	timeout := timeoutOrDefault(checks.Timeout, pollTimeout)
+
+	type healthCheck struct {
+		name string
+		run  func() error
+	}
+	healthChecks := []healthCheck{
+		{"clusterOperatorsReady", func() error { return clusterOperatorsReady(c, l, timeout) }},
+		{"machineConfigPoolReady", func() error { return machineConfigPoolReady(c, l, timeout) }},
+		{"clusterServiceVersionReady", func() error { return clusterServiceVersionReady(c, l, timeout) }},
+		{"clusterVersionReady", func() error { return clusterVersionReady(c, l, timeout) }},
+		{"nodesReady", func() error { return nodesReady(c, l, timeout) }},
+	}
+	for _, check := range checks.Resources {
+		check := check
+		healthChecks = append(healthChecks, healthCheck{"resourceReady", func() error {
+			return resourceReady(c, l, check, timeoutOrDefault(check.Timeout, timeout))
+		}})
+	}
+	for _, check := range checks.Workloads {
+		check := check
+		healthChecks = append(healthChecks, healthCheck{"workloadsReady", func() error {
+			return workloadsReady(c, l, check, timeoutOrDefault(check.Timeout, timeout))
+		}})
+	}
+
 	// using channel store go routine return val and WaitGroup to sync.
-	chanBufferSize := 5
-	errChn := make(chan error, chanBufferSize)
+	errChn := make(chan error, len(healthChecks))
 	var wg sync.WaitGroup
 
 	// prep and launch routines
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		defer common.FuncTimer(time.Now(), "clusterOperatorsReady", l)
-		errChn <- clusterOperatorsReady(c, l)
-	}()
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		defer common.FuncTimer(time.Now(), "machineConfigPoolReady", l)
-		errChn <- machineConfigPoolReady(c, l)
-	}()
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		defer common.FuncTimer(time.Now(), "clusterServiceVersionReady", l)
-		errChn <- clusterServiceVersionReady(c, l)
-	}()
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		defer common.FuncTimer(time.Now(), "clusterVersionReady", l)
-		errChn <- clusterVersionReady(c, l)
-	}()
-
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		defer common.FuncTimer(time.Now(), "nodesReady", l)
-		errChn <- nodesReady(c, l)
-	}()
+	for _, check := range healthChecks {
+		check := check
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer common.FuncTimer(time.Now(), check.name, l)
+			errChn <- check.run()
+		}()
+	}
 
 	// wait
 	go func() {
@@ -104,143 +117,209 @@ func HealthChecks(c client.Reader, l logr.Logger) error {
 	return nil
 }
 
-func clusterServiceVersionReady(c client.Reader, l logr.Logger) error {
-	l.Info("Waiting for all ClusterServiceVersion (csv) to be ready")
-	err := wait.PollUntilContextTimeout(context.Background(), pollInterval, pollTimeout, true, isClusterServiceVersionReady(c, l))
-	if err != nil {
-		return fmt.Errorf("failed to wait for all ClusterServiceVersion (csv) to be ready: %w", err)
+// DefaultPreUpgradeChecks are the checks run before the upgrade when spec.healthChecks.preUpgrade is empty
+var DefaultPreUpgradeChecks = []lcav1alpha1.HealthCheckName{
+	lcav1alpha1.HealthCheckNames.ClusterOperators,
+	lcav1alpha1.HealthCheckNames.ClusterVersion,
+	lcav1alpha1.HealthCheckNames.MachineConfigPools,
+	lcav1alpha1.HealthCheckNames.ClusterServiceVersions,
+	lcav1alpha1.HealthCheckNames.Nodes,
+}
+
+// NotHealthyError is returned by CheckClusterHealth when some resources are not ready
+type NotHealthyError struct {
+	// NotReady lists the resources that are not ready, by check
+	NotReady map[lcav1alpha1.HealthCheckName][]string
+}
+
+func (e *NotHealthyError) Error() string {
+	var checks []string
+	for _, name := range lo.Keys(e.NotReady) {
+		checks = append(checks, fmt.Sprintf("%s not ready: %s", name, strings.Join(e.NotReady[name], ", ")))
 	}
+	sort.Strings(checks)
+	return fmt.Sprintf("cluster is not healthy: %s", strings.Join(checks, "; "))
+}
 
-	return nil
+// IsNotHealthyError returns true if the error, or any error it wraps, is a NotHealthyError
+func IsNotHealthyError(err error) bool {
+	var notHealthyErr *NotHealthyError
+	return errors.As(err, &notHealthyErr)
 }
 
-func isClusterServiceVersionReady(c client.Reader, l logr.Logger) wait.ConditionWithContextFunc {
-	return func(ctx context.Context) (bool, error) {
-		clusterServiceVersionList := operatorsv1alpha1.ClusterServiceVersionList{}
-		err := c.List(context.Background(), &clusterServiceVersionList)
-		if err != nil {
-			l.Error(err, "failed to get csv list")
-			return false, nil
-		}
+// CheckClusterHealth runs the pre-upgrade subset of the health checks once, without waiting for the cluster to become
+// healthy. A NotHealthyError listing the resources that are not ready is returned if any check doesn't pass
+func CheckClusterHealth(ctx context.Context, c client.Reader, l logr.Logger, checks *lcav1alpha1.HealthChecks) error {
+	if checks == nil {
+		checks = &lcav1alpha1.HealthChecks{}
+	}
+	names := checks.PreUpgrade
+	if len(names) == 0 {
+		names = DefaultPreUpgradeChecks
+	}
+
+	notReadyFuncs := map[lcav1alpha1.HealthCheckName][]notReadyFunc{
+		lcav1alpha1.HealthCheckNames.ClusterOperators:       {notReadyClusterOperators},
+		lcav1alpha1.HealthCheckNames.ClusterVersion:         {notReadyClusterVersions},
+		lcav1alpha1.HealthCheckNames.MachineConfigPools:     {notReadyMachineConfigPools},
+		lcav1alpha1.HealthCheckNames.ClusterServiceVersions: {notReadyClusterServiceVersions},
+		lcav1alpha1.HealthCheckNames.Nodes:                  {notReadyNodes},
+	}
+	for _, check := range checks.Resources {
+		notReadyFuncs[lcav1alpha1.HealthCheckNames.Resources] = append(notReadyFuncs[lcav1alpha1.HealthCheckNames.Resources],
+			notReadyResource(check))
+	}
+	for _, check := range checks.Workloads {
+		notReadyFuncs[lcav1alpha1.HealthCheckNames.Workloads] = append(notReadyFuncs[lcav1alpha1.HealthCheckNames.Workloads],
+			notReadyWorkloads(check))
+	}
 
-		for _, csv := range clusterServiceVersionList.Items {
-			if strings.Contains(csv.Name, "lifecycle-agent") {
-				l.Info(fmt.Sprintf("Skipping check of %s/%s", csv.Kind, csv.Name))
-				continue
+	notHealthy := &NotHealthyError{NotReady: make(map[lcav1alpha1.HealthCheckName][]string)}
+	for _, name := range lo.Uniq(names) {
+		for _, notReady := range notReadyFuncs[name] {
+			resources, err := notReady(ctx, c, l)
+			if err != nil {
+				return fmt.Errorf("failed to check %s: %w", name, err)
 			}
-			if !(csv.Status.Phase == operatorsv1alpha1.CSVPhaseSucceeded && csv.Status.Reason == operatorsv1alpha1.CSVReasonInstallSuccessful) {
-				l.Info(fmt.Sprintf("%s not ready yet", csv.Name), "kind", csv.Kind)
-				return false, nil
+			if len(resources) != 0 {
+				notHealthy.NotReady[name] = append(notHealthy.NotReady[name], resources...)
 			}
 		}
+	}
+	if len(notHealthy.NotReady) != 0 {
+		return notHealthy
+	}
 
-		l.Info("All CSVs are ready")
-		return true, nil
+	l.Info("Cluster is healthy", "checks", names)
+	return nil
+}
+
+func timeoutOrDefault(timeout *metav1.Duration, defaultTimeout time.Duration) time.Duration {
+	if timeout == nil || timeout.Duration <= 0 {
+		return defaultTimeout
 	}
+	return timeout.Duration
 }
 
-func clusterVersionReady(c client.Reader, l logr.Logger) error {
-	l.Info("Waiting for ClusterVersion to be ready")
-	err := wait.PollUntilContextTimeout(context.Background(), pollInterval, pollTimeout, true, isClusterVersionReady(c, l))
+// notReadyFunc returns a description of each resource of a kind that is not ready yet
+type notReadyFunc func(ctx context.Context, c client.Reader, l logr.Logger) ([]string, error)
+
+// waitForReady polls until all the resources of the kind are ready
+func waitForReady(c client.Reader, l logr.Logger, kind string, notReady notReadyFunc, timeout time.Duration) error {
+	l.Info(fmt.Sprintf("Waiting for %s to be ready", kind))
+	err := wait.PollUntilContextTimeout(context.Background(), pollInterval, timeout, true, isReady(c, l, kind, notReady))
 	if err != nil {
-		return fmt.Errorf("failed to wait for ClusterVersion to be ready: %w", err)
+		return fmt.Errorf("failed to wait for %s to be ready: %w", kind, err)
 	}
 
 	return nil
 }
 
-func isClusterVersionReady(c client.Reader, l logr.Logger) wait.ConditionWithContextFunc {
+func isReady(c client.Reader, l logr.Logger, kind string, notReady notReadyFunc) wait.ConditionWithContextFunc {
 	return func(ctx context.Context) (bool, error) {
-		clusterVersionList := configv1.ClusterVersionList{}
-		err := c.List(context.Background(), &clusterVersionList)
+		names, err := notReady(ctx, c, l)
+		if k8serrors.IsForbidden(err) {
+			// Missing permissions are not granted while polling, the check fails right away
+			return false, err
+		}
 		if err != nil {
-			l.Error(err, "failed to get cv list")
+			l.Error(err, fmt.Sprintf("failed to check %s", kind))
 			return false, nil
 		}
-
-		for _, co := range clusterVersionList.Items {
-			if !getClusterOperatorStatusCondition(co.Status.Conditions, configv1.OperatorAvailable) {
-				l.Info(fmt.Sprintf("%s not ready yet", co.Name), "kind", co.Kind)
-				return false, nil
-			}
+		if len(names) != 0 {
+			l.Info(fmt.Sprintf("%s not ready yet: %s", kind, strings.Join(names, ", ")))
+			return false, nil
 		}
 
-		l.Info("Cluster version is ready")
+		l.Info(fmt.Sprintf("%s ready", kind))
 		return true, nil
 	}
+}
 
+func clusterServiceVersionReady(c client.Reader, l logr.Logger, timeout time.Duration) error {
+	return waitForReady(c, l, "ClusterServiceVersion (csv)", notReadyClusterServiceVersions, timeout)
 }
 
-func machineConfigPoolReady(c client.Reader, l logr.Logger) error {
-	l.Info("Waiting for MachineConfigPool (mcp) to be ready")
-	err := wait.PollUntilContextTimeout(context.Background(), pollInterval, pollTimeout, true, isMachineConfigPoolReady(c, l))
-	if err != nil {
-		return fmt.Errorf("failed to wait for MachineConfigPool (mcp) to be ready: %w", err)
+func notReadyClusterServiceVersions(ctx context.Context, c client.Reader, l logr.Logger) ([]string, error) {
+	clusterServiceVersionList := operatorsv1alpha1.ClusterServiceVersionList{}
+	if err := c.List(ctx, &clusterServiceVersionList); err != nil {
+		return nil, fmt.Errorf("failed to get csv list: %w", err)
 	}
 
-	return nil
-}
-
-func isMachineConfigPoolReady(c client.Reader, l logr.Logger) wait.ConditionWithContextFunc {
-	return func(ctx context.Context) (bool, error) {
-		machineConfigPoolList := mcv1.MachineConfigPoolList{}
-		err := c.List(context.Background(), &machineConfigPoolList)
-		if err != nil {
-			l.Error(err, "failed to get mcp list")
-			return false, nil
+	var notReady []string
+	for _, csv := range clusterServiceVersionList.Items {
+		if strings.Contains(csv.Name, "lifecycle-agent") {
+			l.Info(fmt.Sprintf("Skipping check of %s/%s", csv.Kind, csv.Name))
+			continue
 		}
-
-		for _, mcp := range machineConfigPoolList.Items {
-			if mcp.Status.MachineCount != mcp.Status.ReadyMachineCount {
-				l.Info(fmt.Sprintf("%s not ready yet", mcp.Name), "kind", mcp.Kind)
-				return false, nil
-			}
+		if !(csv.Status.Phase == operatorsv1alpha1.CSVPhaseSucceeded && csv.Status.Reason == operatorsv1alpha1.CSVReasonInstallSuccessful) {
+			notReady = append(notReady, fmt.Sprintf("%s/%s (%s)", csv.Namespace, csv.Name, csv.Status.Phase))
 		}
-
-		l.Info("MachineConfigPool ready")
-		return true, nil
 	}
+	return notReady, nil
 }
 
-func clusterOperatorsReady(c client.Reader, l logr.Logger) error {
-	l.Info("Waiting for all ClusterOperator (co) to be ready")
-	err := wait.PollUntilContextTimeout(context.Background(), pollInterval, pollTimeout, true, areClusterOperatorsReady(c, l))
-	if err != nil {
-		return fmt.Errorf("failed to wait for all ClusterOperator (co) to be ready: %w", err)
+func clusterVersionReady(c client.Reader, l logr.Logger, timeout time.Duration) error {
+	return waitForReady(c, l, "ClusterVersion", notReadyClusterVersions, timeout)
+}
+
+func notReadyClusterVersions(ctx context.Context, c client.Reader, _ logr.Logger) ([]string, error) {
+	clusterVersionList := configv1.ClusterVersionList{}
+	if err := c.List(ctx, &clusterVersionList); err != nil {
+		return nil, fmt.Errorf("failed to get cv list: %w", err)
 	}
 
-	return nil
+	var notReady []string
+	for _, cv := range clusterVersionList.Items {
+		if !getClusterOperatorStatusCondition(cv.Status.Conditions, configv1.OperatorAvailable) {
+			notReady = append(notReady, fmt.Sprintf("%s (not available)", cv.Name))
+		}
+	}
+	return notReady, nil
 }
 
-func areClusterOperatorsReady(c client.Reader, l logr.Logger) wait.ConditionWithContextFunc {
-	return func(ctx context.Context) (bool, error) {
-		clusterOperatorList := configv1.ClusterOperatorList{}
-		err := c.List(context.Background(), &clusterOperatorList)
-		if err != nil {
-			l.Error(err, "failed to get co list")
-			return false, nil
+func machineConfigPoolReady(c client.Reader, l logr.Logger, timeout time.Duration) error {
+	return waitForReady(c, l, "MachineConfigPool (mcp)", notReadyMachineConfigPools, timeout)
+}
+
+func notReadyMachineConfigPools(ctx context.Context, c client.Reader, _ logr.Logger) ([]string, error) {
+	machineConfigPoolList := mcv1.MachineConfigPoolList{}
+	if err := c.List(ctx, &machineConfigPoolList); err != nil {
+		return nil, fmt.Errorf("failed to get mcp list: %w", err)
+	}
+
+	var notReady []string
+	for _, mcp := range machineConfigPoolList.Items {
+		if mcp.Status.MachineCount != mcp.Status.ReadyMachineCount {
+			notReady = append(notReady, fmt.Sprintf("%s (%d of %d machines ready)",
+				mcp.Name, mcp.Status.ReadyMachineCount, mcp.Status.MachineCount))
 		}
+	}
+	return notReady, nil
+}
 
-		for _, co := range clusterOperatorList.Items {
-			if !getClusterOperatorStatusCondition(co.Status.Conditions, configv1.OperatorAvailable) {
-				l.Info(fmt.Sprintf("%s not ready yet", co.Name), "kind", co.Kind)
-				return false, nil
-			}
+func clusterOperatorsReady(c client.Reader, l logr.Logger, timeout time.Duration) error {
+	return waitForReady(c, l, "ClusterOperator (co)", notReadyClusterOperators, timeout)
+}
 
-			if getClusterOperatorStatusCondition(co.Status.Conditions, configv1.OperatorProgressing) {
-				l.Info(fmt.Sprintf("%s is in progressing state", co.Name), "kind", co.Kind)
-				return false, nil
-			}
+func notReadyClusterOperators(ctx context.Context, c client.Reader, _ logr.Logger) ([]string, error) {
+	clusterOperatorList := configv1.ClusterOperatorList{}
+	if err := c.List(ctx, &clusterOperatorList); err != nil {
+		return nil, fmt.Errorf("failed to get co list: %w", err)
+	}
 
-			if getClusterOperatorStatusCondition(co.Status.Conditions, configv1.OperatorDegraded) {
-				l.Info(fmt.Sprintf("%s is in degraded state", co.Name), "kind", co.Kind)
-				return false, nil
-			}
+	var notReady []string
+	for _, co := range clusterOperatorList.Items {
+		switch {
+		case !getClusterOperatorStatusCondition(co.Status.Conditions, configv1.OperatorAvailable):
+			notReady = append(notReady, fmt.Sprintf("%s (not available)", co.Name))
+		case getClusterOperatorStatusCondition(co.Status.Conditions, configv1.OperatorProgressing):
+			notReady = append(notReady, fmt.Sprintf("%s (progressing)", co.Name))
+		case getClusterOperatorStatusCondition(co.Status.Conditions, configv1.OperatorDegraded):
+			notReady = append(notReady, fmt.Sprintf("%s (degraded)", co.Name))
 		}
-
-		l.Info("All cluster operators are now ready")
-		return true, nil
 	}
+	return notReady, nil
 }
 
 func getClusterOperatorStatusCondition(conditions []configv1.ClusterOperatorStatusCondition, conditionType configv1.ClusterStatusConditionType) bool {
@@ -253,62 +332,50 @@ func getClusterOperatorStatusCondition(conditions []configv1.ClusterOperatorStat
 	return false
 }
 
-func nodesReady(c client.Reader, l logr.Logger) error {
-	l.Info("Waiting for Node to be ready")
-	err := wait.PollUntilContextTimeout(context.Background(), pollInterval, pollTimeout, true, isNodeReady(c, l))
-	if err != nil {
-		return fmt.Errorf("failed to watit for Node to be ready: %w", err)
+func nodesReady(c client.Reader, l logr.Logger, timeout time.Duration) error {
+	return waitForReady(c, l, "Node", notReadyNodes, timeout)
+}
+
+func notReadyNodes(ctx context.Context, c client.Reader, l logr.Logger) ([]string, error) {
+	infra := &configv1.Infrastructure{}
+	if err := c.Get(ctx, types.NamespacedName{Name: "cluster"}, infra); err != nil {
+		return nil, fmt.Errorf("failed to get infrastucture CR: %w", err)
 	}
 
-	return nil
-}
+	if infra.Status.InfrastructureTopology != configv1.SingleReplicaTopologyMode {
+		// This is likely a test environment, so skip the health check.
+		l.Info(fmt.Sprintf("Skipping Node check. InfrastructureTopology is %s. Expected %s", infra.Status.InfrastructureTopology, configv1.SingleReplicaTopologyMode))
+		return nil, nil
+	}
 
-func isNodeReady(c client.Reader, l logr.Logger) wait.ConditionWithContextFunc {
-	return func(ctx context.Context) (bool, error) {
-		infra := &configv1.Infrastructure{}
-		if err := c.Get(ctx, types.NamespacedName{Name: "cluster"}, infra); err != nil {
-			l.Error(err, "failed to get infrastucture CR")
-			return false, nil
-		}
+	nodeList := corev1.NodeList{}
+	if err := c.List(ctx, &nodeList); err != nil {
+		return nil, fmt.Errorf("failed to get node list: %w", err)
+	}
 
-		if infra.Status.InfrastructureTopology != configv1.SingleReplicaTopologyMode {
-			// This is likely a test environment, so skip the health check.
-			l.Info(fmt.Sprintf("Skipping Node check. InfrastructureTopology is %s. Expected %s", infra.Status.InfrastructureTopology, configv1.SingleReplicaTopologyMode))
-			return true, nil
+	var notReady []string
+	for _, node := range nodeList.Items {
+		if !getNodeStatusCondition(node.Status.Conditions, corev1.NodeReady) {
+			notReady = append(notReady, fmt.Sprintf("%s (not ready)", node.Name))
+			continue
 		}
 
-		nodeList := corev1.NodeList{}
-		err := c.List(context.Background(), &nodeList)
-		if err != nil {
-			l.Error(err, "failed to get node list")
-			return false, nil
+		if getNodeStatusCondition(node.Status.Conditions, corev1.NodeNetworkUnavailable) {
+			notReady = append(notReady, fmt.Sprintf("%s (network unavailable)", node.Name))
+			continue
 		}
 
-		for _, node := range nodeList.Items {
-			if !getNodeStatusCondition(node.Status.Conditions, corev1.NodeReady) {
-				l.Info(fmt.Sprintf("%s not ready yet", node.Name), "kind", node.Kind)
-				return false, nil
-			}
-
-			if getNodeStatusCondition(node.Status.Conditions, corev1.NodeNetworkUnavailable) {
-				l.Info(fmt.Sprintf("%s NodeNetworkUnavailable", node.Name), "kind", node.Kind)
-				return false, nil
-			}
-
-			// Verify the node has the expected node-role labels for SNO
-			labels := node.ObjectMeta.GetLabels()
-			requiredLabels := []string{NodeRoleControlPlane, NodeRoleMaster, NodeRoleWorker}
-			for _, label := range requiredLabels {
-				if _, found := labels[label]; !found {
-					l.Info(fmt.Sprintf("%s does not have %s label", node.Name, label), "kind", node.Kind)
-					return false, nil
-				}
+		// Verify the node has the expected node-role labels for SNO
+		labels := node.ObjectMeta.GetLabels()
+		requiredLabels := []string{NodeRoleControlPlane, NodeRoleMaster, NodeRoleWorker}
+		for _, label := range requiredLabels {
+			if _, found := labels[label]; !found {
+				notReady = append(notReady, fmt.Sprintf("%s (missing %s label)", node.Name, label))
+				break
 			}
 		}
-
-		l.Info("Node is ready")
-		return true, nil
 	}
+	return notReady, nil
 }
 
 func getNodeStatusCondition(conditions []corev1.NodeCondition, conditionType corev1.NodeConditionType) bool {
@@ -1,16 +1,23 @@
 package healthcheck
 
 import (
+	"context"
 	"github.com/go-logr/logr"
+	lcav1alpha1 "github.com/openshift-kni/lifecycle-agent/api/v1alpha1"
 	configv1 "github.com/openshift/api/config/v1"
 	mcv1 "github.com/openshift/api/machineconfiguration/v1"
 	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 	"testing"
 	"time"
 )
@@ -224,7 +231,7 @@ func Test_nodesReady(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.args.c = fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(tt.objects...).Build()
-			if err := nodesReady(tt.args.c, tt.args.l); (err != nil) != tt.wantErr {
+			if err := nodesReady(tt.args.c, tt.args.l, pollTimeout); (err != nil) != tt.wantErr {
 				t.Errorf("nodesReady() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -291,7 +298,7 @@ func Test_clusterServiceVersionReady(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.args.c = fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(tt.objects...).Build()
-			if err := clusterServiceVersionReady(tt.args.c, tt.args.l); (err != nil) != tt.wantErr {
+			if err := clusterServiceVersionReady(tt.args.c, tt.args.l, pollTimeout); (err != nil) != tt.wantErr {
 				t.Errorf("clusterOperatorsReady() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -375,7 +382,7 @@ func Test_clusterOperatorsReady(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.args.c = fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(tt.objects...).Build()
-			if err := clusterOperatorsReady(tt.args.c, tt.args.l); (err != nil) != tt.wantErr {
+			if err := clusterOperatorsReady(tt.args.c, tt.args.l, pollTimeout); (err != nil) != tt.wantErr {
 				t.Errorf("clusterOperatorsReady() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -423,7 +430,7 @@ func Test_machineConfigPoolReady(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.args.c = fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(tt.objects...).Build()
-			if err := machineConfigPoolReady(tt.args.c, tt.args.l); (err != nil) != tt.wantErr {
+			if err := machineConfigPoolReady(tt.args.c, tt.args.l, pollTimeout); (err != nil) != tt.wantErr {
 				t.Errorf("machineConfigPoolReady() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -483,7 +490,7 @@ func Test_clusterVersionReady(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.args.c = fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(tt.objects...).Build()
-			if err := clusterVersionReady(tt.args.c, tt.args.l); (err != nil) != tt.wantErr {
+			if err := clusterVersionReady(tt.args.c, tt.args.l, pollTimeout); (err != nil) != tt.wantErr {
 				t.Errorf("clusterVersionReady() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
@@ -646,10 +653,262 @@ func TestHealthChecks(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.args.c = fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(tt.objects...).Build()
-			err := HealthChecks(tt.args.c, tt.args.l)
+			err := HealthChecks(tt.args.c, tt.args.l, nil)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("HealthChecks() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
+
+func Test_resourceReady(t *testing.T) {
+	sriovNodeState := func(syncStatus string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": "sriovnetwork.openshift.io/v1",
+			"kind":       "SriovNetworkNodeState",
+			"metadata":   map[string]any{"name": "sno", "namespace": "openshift-sriov-network-operator"},
+			"status":     map[string]any{"syncStatus": syncStatus},
+		}}
+	}
+	ptpConfig := func(status string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": "ptp.openshift.io/v1",
+			"kind":       "PtpOperatorConfig",
+			"metadata":   map[string]any{"name": "default", "namespace": "openshift-ptp"},
+			"status": map[string]any{"conditions": []any{
+				map[string]any{"type": "Ready", "status": status},
+			}},
+		}}
+	}
+	sriovCheck := lcav1alpha1.ResourceHealthCheck{
+		APIVersion: "sriovnetwork.openshift.io/v1",
+		Kind:       "SriovNetworkNodeState",
+		Name:       "sno",
+		Namespace:  "openshift-sriov-network-operator",
+		JSONPath:   "{.status.syncStatus}",
+		Value:      "Succeeded",
+	}
+	ptpCheck := lcav1alpha1.ResourceHealthCheck{
+		APIVersion: "ptp.openshift.io/v1",
+		Kind:       "PtpOperatorConfig",
+		Name:       "default",
+		Namespace:  "openshift-ptp",
+		Condition:  "Ready",
+	}
+
+	tests := []struct {
+		name    string
+		check   lcav1alpha1.ResourceHealthCheck
+		objects []client.Object
+		// forbidden makes the lifecycle-agent not allowed to get the resource
+		forbidden bool
+		wantErr   bool
+	}{
+		{
+			name:    "json path matches",
+			check:   sriovCheck,
+			objects: []client.Object{sriovNodeState("Succeeded")},
+		},
+		{
+			name:    "json path does not match",
+			check:   sriovCheck,
+			objects: []client.Object{sriovNodeState("InProgress")},
+			wantErr: true,
+		},
+		{
+			name: "json path without value",
+			check: func() lcav1alpha1.ResourceHealthCheck {
+				check := sriovCheck
+				check.Value = ""
+				return check
+			}(),
+			objects: []client.Object{sriovNodeState("InProgress")},
+		},
+		{
+			name:    "resource not found",
+			check:   sriovCheck,
+			wantErr: true,
+		},
+		{
+			name:    "condition true",
+			check:   ptpCheck,
+			objects: []client.Object{ptpConfig("True")},
+		},
+		{
+			name:    "condition false",
+			check:   ptpCheck,
+			objects: []client.Object{ptpConfig("False")},
+			wantErr: true,
+		},
+		{
+			name:      "forbidden fails without waiting for the timeout",
+			check:     ptpCheck,
+			objects:   []client.Object{ptpConfig("True")},
+			forbidden: true,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := fake.NewClientBuilder().WithScheme(s).WithObjects(tt.objects...)
+			timeout := time.Microsecond
+			if tt.forbidden {
+				builder = builder.WithInterceptorFuncs(interceptor.Funcs{
+					Get: func(_ context.Context, _ client.WithWatch, key client.ObjectKey, _ client.Object, _ ...client.GetOption) error {
+						return k8serrors.NewForbidden(schema.GroupResource{Group: "ptp.openshift.io", Resource: "ptpoperatorconfigs"}, key.Name, nil)
+					},
+				})
+				timeout = time.Hour
+			}
+			c := builder.Build()
+			if err := resourceReady(c, logr.Logger{}, tt.check, timeout); (err != nil) != tt.wantErr {
+				t.Errorf("resourceReady() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_workloadsReady(t *testing.T) {
+	replicas := int32(2)
+	labels := map[string]string{"app": "du"}
+	deployment := func(available int32) *appsv1.Deployment {
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "du", Namespace: "du", Labels: labels},
+			Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			Status:     appsv1.DeploymentStatus{UpdatedReplicas: 2, AvailableReplicas: available},
+		}
+	}
+	statefulSet := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "du", Labels: labels},
+		Status:     appsv1.StatefulSetStatus{UpdatedReplicas: 1, ReadyReplicas: 1},
+	}
+	daemonSet := func(ready int32) *appsv1.DaemonSet {
+		return &appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "du", Labels: labels},
+			Status:     appsv1.DaemonSetStatus{DesiredNumberScheduled: 1, UpdatedNumberScheduled: 1, NumberReady: ready},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		selector map[string]string
+		objects  []client.Object
+		wantErr  bool
+	}{
+		{
+			name:     "all ready",
+			selector: labels,
+			objects:  []client.Object{deployment(2), statefulSet, daemonSet(1)},
+		},
+		{
+			name:     "deployment not available",
+			selector: labels,
+			objects:  []client.Object{deployment(1), statefulSet, daemonSet(1)},
+			wantErr:  true,
+		},
+		{
+			name:     "daemonset not ready",
+			selector: labels,
+			objects:  []client.Object{deployment(2), daemonSet(0)},
+			wantErr:  true,
+		},
+		{
+			name:     "not ready workload not selected",
+			selector: labels,
+			objects: []client.Object{deployment(2), &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "du"},
+				Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+			}},
+		},
+		{
+			name:     "nothing selected",
+			selector: map[string]string{"app": "missing"},
+			objects:  []client.Object{deployment(2)},
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(s).WithObjects(tt.objects...).Build()
+			check := lcav1alpha1.WorkloadHealthCheck{Namespace: "du", Selector: metav1.LabelSelector{MatchLabels: tt.selector}}
+			if err := workloadsReady(c, logr.Logger{}, check, time.Microsecond); (err != nil) != tt.wantErr {
+				t.Errorf("workloadsReady() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckClusterHealth(t *testing.T) {
+	objects := []runtime.Object{
+		&configv1.Infrastructure{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+			Status:     configv1.InfrastructureStatus{InfrastructureTopology: configv1.HighlyAvailableTopologyMode},
+		},
+		&configv1.ClusterOperator{
+			ObjectMeta: metav1.ObjectMeta{Name: "etcd"},
+			Status: configv1.ClusterOperatorStatus{Conditions: []configv1.ClusterOperatorStatusCondition{
+				{Type: configv1.OperatorAvailable, Status: configv1.ConditionTrue},
+				{Type: configv1.OperatorDegraded, Status: configv1.ConditionTrue},
+			}},
+		},
+		&configv1.ClusterOperator{
+			ObjectMeta: metav1.ObjectMeta{Name: "dns"},
+			Status: configv1.ClusterOperatorStatus{Conditions: []configv1.ClusterOperatorStatusCondition{
+				{Type: configv1.OperatorAvailable, Status: configv1.ConditionTrue},
+			}},
+		},
+		&mcv1.MachineConfigPool{
+			ObjectMeta: metav1.ObjectMeta{Name: "master"},
+			Status:     mcv1.MachineConfigPoolStatus{MachineCount: 1, ReadyMachineCount: 0},
+		},
+		&configv1.ClusterVersion{
+			ObjectMeta: metav1.ObjectMeta{Name: "version"},
+			Status: configv1.ClusterVersionStatus{Conditions: []configv1.ClusterOperatorStatusCondition{
+				{Type: configv1.OperatorAvailable, Status: configv1.ConditionTrue},
+			}},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		checks  *lcav1alpha1.HealthChecks
+		wantErr string
+	}{
+		{
+			name:    "default checks",
+			wantErr: "cluster is not healthy: ClusterOperators not ready: etcd (degraded); MachineConfigPools not ready: master (0 of 1 machines ready)",
+		},
+		{
+			name: "subset of checks",
+			checks: &lcav1alpha1.HealthChecks{PreUpgrade: []lcav1alpha1.HealthCheckName{
+				lcav1alpha1.HealthCheckNames.ClusterVersion, lcav1alpha1.HealthCheckNames.Nodes,
+			}},
+		},
+		{
+			name: "resources",
+			checks: &lcav1alpha1.HealthChecks{
+				PreUpgrade: []lcav1alpha1.HealthCheckName{lcav1alpha1.HealthCheckNames.Resources},
+				Resources: []lcav1alpha1.ResourceHealthCheck{
+					{APIVersion: "config.openshift.io/v1", Kind: "ClusterOperator", Name: "dns", Condition: "Available"},
+					{APIVersion: "config.openshift.io/v1", Kind: "ClusterOperator", Name: "network", Condition: "Available"},
+				},
+			},
+			wantErr: "cluster is not healthy: Resources not ready: ClusterOperator network (not found)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(objects...).Build()
+			err := CheckClusterHealth(context.Background(), c, logr.Logger{}, tt.checks)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("CheckClusterHealth() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr || !IsNotHealthyError(err) {
+				t.Errorf("CheckClusterHealth() error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,290 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	lcav1alpha1 "github.com/openshift-kni/lifecycle-agent/api/v1alpha1"
+	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	lcautils "github.com/openshift-kni/lifecycle-agent/utils"
+)
+
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=get;create
+
+// Point identifies when a hook is run during the upgrade
+type Point string
+
+// Points defines the points of the upgrade where hooks are run
+var Points = struct {
+	PrePrep      Point
+	PostPrep     Point
+	PrePivot     Point
+	PostPivot    Point
+	PostRollback Point
+}{
+	PrePrep:      "prePrep",
+	PostPrep:     "postPrep",
+	PrePivot:     "prePivot",
+	PostPivot:    "postPivot",
+	PostRollback: "postRollback",
+}
+
+const (
+	// JobTemplateKey is the key of the hook config map holding the Job manifest
+	JobTemplateKey = "job.yaml"
+	// ExportDir is where the Job templates of the hooks run after the pivot are saved in the new stateroot,
+	// relative to its /var directory
+	ExportDir = "/opt/lca-hooks"
+	// PointLabel is set on the hook Jobs, with the hook point as value
+	PointLabel = "lca.openshift.io/hook"
+	// AttemptLabel is set on the hook Jobs, with the upgrade attempt they were created for as value
+	AttemptLabel = "lca.openshift.io/hook-attempt"
+	// ServiceAccount is the service account the hook Jobs run as. It is created without any permission, for the
+	// user to grant it what the hooks need
+	ServiceAccount = "lifecycle-agent-hook"
+	// DefaultTimeout is how long a hook Job may run when the hook doesn't set a timeout
+	DefaultTimeout = 30 * time.Minute
+)
+
+// HookFor returns the hook configured at the point, or nil
+func HookFor(hooks lcav1alpha1.Hooks, point Point) *lcav1alpha1.Hook {
+	switch point {
+	case Points.PrePrep:
+		return hooks.PrePrep
+	case Points.PostPrep:
+		return hooks.PostPrep
+	case Points.PrePivot:
+		return hooks.PrePivot
+	case Points.PostPivot:
+		return hooks.PostPivot
+	case Points.PostRollback:
+		return hooks.PostRollback
+	}
+	return nil
+}
+
+// JobName returns the name of the Job of the hook at the point
+func JobName(point Point) string {
+	return fmt.Sprintf("lca-%s-hook", strings.ToLower(string(point)))
+}
+
+// FailedError is returned when the Job of a hook failed or timed out
+type FailedError struct {
+	Point  Point
+	Job    string
+	Reason string
+}
+
+func (e *FailedError) Error() string {
+	return fmt.Sprintf("%s hook job %s failed: %s", e.Point, e.Job, e.Reason)
+}
+
+// IsFailedError returns true if the error, or any error it wraps, is a FailedError
+func IsFailedError(err error) bool {
+	var failedErr *FailedError
+	return errors.As(err, &failedErr)
+}
+
+// ParseTemplate decodes the manifest of a hook Job
+func ParseTemplate(data string) (*batchv1.Job, error) {
+	job := &batchv1.Job{}
+	if err := yaml.Unmarshal([]byte(data), job); err != nil {
+		return nil, fmt.Errorf("failed to decode job template: %w", err)
+	}
+	if job.Kind != "" && job.Kind != "Job" {
+		return nil, fmt.Errorf("job template is a %s instead of a Job", job.Kind)
+	}
+	if len(job.Spec.Template.Spec.Containers) == 0 {
+		return nil, fmt.Errorf("job template has no container")
+	}
+	// The Job runs with the privileges granted in the LCA namespace, it may not be created anywhere else
+	if job.Namespace != "" && job.Namespace != common.LcaNamespace {
+		return nil, fmt.Errorf("job template namespace must be %s, got %s", common.LcaNamespace, job.Namespace)
+	}
+	// Nor may it run as another service account of the namespace, such as the privileged one of the LCA
+	podSpec := job.Spec.Template.Spec
+	for _, sa := range []string{podSpec.ServiceAccountName, podSpec.DeprecatedServiceAccount} {
+		if sa != "" && sa != ServiceAccount {
+			return nil, fmt.Errorf("job template service account must be %s, got %s", ServiceAccount, sa)
+		}
+	}
+	return job, nil
+}
+
+// Runner launches the hook Jobs and tracks their completion
+type Runner struct {
+	client.Client
+	Log logr.Logger
+}
+
+// LoadTemplate reads the Job template of the hook from its config map
+func (r *Runner) LoadTemplate(ctx context.Context, hook *lcav1alpha1.Hook) (*batchv1.Job, error) {
+	cm, err := common.GetConfigMap(ctx, r.Client, hook.JobTemplateRef)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	data, ok := cm.Data[JobTemplateKey]
+	if !ok {
+		return nil, fmt.Errorf("configMap %s/%s has no %s key", cm.Namespace, cm.Name, JobTemplateKey)
+	}
+	return ParseTemplate(data)
+}
+
+// ExportTemplate saves the Job template of the hook under the stateroot /var directory, for the hook to be run
+// once the node rebooted to that stateroot, where the config map doesn't exist
+func (r *Runner) ExportTemplate(ctx context.Context, point Point, hook *lcav1alpha1.Hook, staterootVarPath string) error {
+	job, err := r.LoadTemplate(ctx, hook)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Join(staterootVarPath, ExportDir)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create directory for hooks in %s: %w", dir, err)
+	}
+	filePath := filepath.Join(dir, string(point)+".yaml")
+	if err := lcautils.MarshalToYamlFile(job, filePath); err != nil {
+		return fmt.Errorf("failed to export %s hook job template: %w", point, err)
+	}
+	r.Log.Info("Exported hook job template to file", "hook", point, "path", filePath)
+	return nil
+}
+
+// LoadExportedTemplate reads the Job template of the hook saved by ExportTemplate before the reboot
+func LoadExportedTemplate(point Point) (*batchv1.Job, error) {
+	data, err := os.ReadFile(common.PathOutsideChroot(filepath.Join(ExportDir, string(point)+".yaml")))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read exported %s hook job template: %w", point, err)
+	}
+	return ParseTemplate(string(data))
+}
+
+// Run creates the Job of the hook from the template for the upgrade attempt, unless it was already created, and
+// returns true once it succeeded. A Job left from another attempt is deleted and created again. A FailedError is
+// returned if the Job failed or ran longer than the hook timeout
+func (r *Runner) Run(ctx context.Context, point Point, hook *lcav1alpha1.Hook, template *batchv1.Job, attempt string) (bool, error) {
+	job := renderJob(point, hook, template, attempt)
+
+	existing := &batchv1.Job{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: job.Name, Namespace: job.Namespace}, existing)
+	switch {
+	case k8serrors.IsNotFound(err):
+		if err := r.ensureServiceAccount(ctx); err != nil {
+			return false, err
+		}
+		r.Log.Info("Creating hook job", "hook", point, "name", job.Name, "namespace", job.Namespace, "attempt", attempt)
+		if err := r.Client.Create(ctx, job); err != nil {
+			return false, fmt.Errorf("failed to create %s hook job: %w", point, err)
+		}
+		return false, nil
+	case err != nil:
+		return false, fmt.Errorf("failed to get %s hook job: %w", point, err)
+	}
+
+	if existing.Labels[AttemptLabel] != attempt {
+		// Left from an earlier attempt whose cleanup failed, its result doesn't apply to this one
+		if existing.DeletionTimestamp == nil {
+			r.Log.Info("Deleting hook job of an earlier attempt", "hook", point, "name", job.Name,
+				"attempt", existing.Labels[AttemptLabel])
+			propagationPolicy := metav1.DeletePropagationBackground
+			if err := r.Client.Delete(ctx, existing, &client.DeleteOptions{PropagationPolicy: &propagationPolicy}); err != nil && !k8serrors.IsNotFound(err) {
+				return false, fmt.Errorf("failed to delete %s hook job of an earlier attempt: %w", point, err)
+			}
+		}
+		return false, nil
+	}
+
+	for _, condition := range existing.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			r.Log.Info("Hook job completed", "hook", point, "name", job.Name)
+			return true, nil
+		case batchv1.JobFailed:
+			return false, &FailedError{Point: point, Job: job.Name, Reason: fmt.Sprintf("%s: %s", condition.Reason, condition.Message)}
+		}
+	}
+	return false, nil
+}
+
+// Wait runs the hook for the upgrade attempt and blocks until its Job succeeded
+func (r *Runner) Wait(ctx context.Context, point Point, hook *lcav1alpha1.Hook, template *batchv1.Job, attempt string,
+	interval time.Duration) error {
+	if err := wait.PollUntilContextCancel(ctx, interval, true, func(ctx context.Context) (bool, error) {
+		return r.Run(ctx, point, hook, template, attempt)
+	}); err != nil {
+		return fmt.Errorf("failed to run %s hook: %w", point, err)
+	}
+	return nil
+}
+
+// Cleanup deletes the hook Jobs
+func (r *Runner) Cleanup(ctx context.Context) error {
+	jobs := &batchv1.JobList{}
+	if err := r.Client.List(ctx, jobs, client.HasLabels{PointLabel}); err != nil {
+		return fmt.Errorf("failed to list hook jobs: %w", err)
+	}
+
+	propagationPolicy := metav1.DeletePropagationBackground
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		if err := r.Client.Delete(ctx, job, &client.DeleteOptions{PropagationPolicy: &propagationPolicy}); err != nil && !k8serrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete hook job %s/%s: %w", job.Namespace, job.Name, err)
+		}
+	}
+	return nil
+}
+
+// ensureServiceAccount creates the service account of the hook Jobs if it doesn't exist
+func (r *Runner) ensureServiceAccount(ctx context.Context) error {
+	sa := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: ServiceAccount, Namespace: common.LcaNamespace}}
+	if err := r.Client.Create(ctx, sa); err != nil && !k8serrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create hook service account: %w", err)
+	}
+	return nil
+}
+
+// renderJob returns the Job of the hook, named after the hook point, labeled with the upgrade attempt and created in
+// the LCA namespace to run as the hook service account. The hook timeout is enforced as the Job active deadline
+func renderJob(point Point, hook *lcav1alpha1.Hook, template *batchv1.Job, attempt string) *batchv1.Job {
+	job := template.DeepCopy()
+	job.ObjectMeta = metav1.ObjectMeta{
+		Name:        JobName(point),
+		Namespace:   common.LcaNamespace,
+		Labels:      template.Labels,
+		Annotations: template.Annotations,
+	}
+	if job.Labels == nil {
+		job.Labels = make(map[string]string)
+	}
+	job.Labels[PointLabel] = string(point)
+	job.Labels[AttemptLabel] = attempt
+	job.Spec.Template.Spec.ServiceAccountName = ServiceAccount
+	job.Spec.Template.Spec.DeprecatedServiceAccount = ""
+
+	timeout := int64(DefaultTimeout.Seconds())
+	if hook.TimeoutSeconds > 0 {
+		timeout = int64(hook.TimeoutSeconds)
+	}
+	job.Spec.ActiveDeadlineSeconds = &timeout
+	job.Status = batchv1.JobStatus{}
+	return job
+}
@@ -0,0 +1,201 @@
+package hooks
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	lcav1alpha1 "github.com/openshift-kni/lifecycle-agent/api/v1alpha1"
+	"github.com/openshift-kni/lifecycle-agent/internal/common"
+)
+
+const jobTemplate = `apiVersion: batch/v1
+kind: Job
+metadata:
+  name: drain-app
+  labels:
+    app: drain
+spec:
+  template:
+    spec:
+      restartPolicy: Never
+      containers:
+      - name: drain
+        image: quay.io/example/drain:latest
+`
+
+func TestParseTemplate(t *testing.T) {
+	testcases := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{
+			name: "valid job",
+			data: jobTemplate,
+		},
+		{
+			name:    "not a job",
+			data:    "apiVersion: v1\nkind: Pod\nspec:\n  containers:\n  - name: drain\n",
+			wantErr: true,
+		},
+		{
+			name: "lca namespace",
+			data: strings.Replace(jobTemplate, "  name: drain-app\n", "  name: drain-app\n  namespace: openshift-lifecycle-agent\n", 1),
+		},
+		{
+			name:    "other namespace",
+			data:    strings.Replace(jobTemplate, "  name: drain-app\n", "  name: drain-app\n  namespace: kube-system\n", 1),
+			wantErr: true,
+		},
+		{
+			name: "hook service account",
+			data: strings.Replace(jobTemplate, "      restartPolicy: Never\n", "      restartPolicy: Never\n      serviceAccountName: lifecycle-agent-hook\n", 1),
+		},
+		{
+			name:    "other service account",
+			data:    strings.Replace(jobTemplate, "      restartPolicy: Never\n", "      restartPolicy: Never\n      serviceAccountName: lifecycle-agent-controller-manager\n", 1),
+			wantErr: true,
+		},
+		{
+			name:    "deprecated service account field",
+			data:    strings.Replace(jobTemplate, "      restartPolicy: Never\n", "      restartPolicy: Never\n      serviceAccount: lifecycle-agent-controller-manager\n", 1),
+			wantErr: true,
+		},
+		{
+			name:    "no container",
+			data:    "apiVersion: batch/v1\nkind: Job\n",
+			wantErr: true,
+		},
+		{
+			name:    "malformed",
+			data:    "kind: [",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseTemplate(tc.data)
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	template, err := ParseTemplate(jobTemplate)
+	assert.NoError(t, err)
+	hook := &lcav1alpha1.Hook{TimeoutSeconds: 600}
+
+	testcases := []struct {
+		name       string
+		conditions []batchv1.JobCondition
+		wantDone   bool
+		wantFailed bool
+	}{
+		{
+			name: "running",
+		},
+		{
+			name:       "completed",
+			conditions: []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}},
+			wantDone:   true,
+		},
+		{
+			name:       "failed",
+			conditions: []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, Reason: "DeadlineExceeded"}},
+			wantFailed: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			c := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+			r := &Runner{Client: c, Log: logr.Discard()}
+
+			// the first run creates the job
+			done, err := r.Run(ctx, Points.PrePivot, hook, template, "1718000000")
+			assert.NoError(t, err)
+			assert.False(t, done)
+
+			job := &batchv1.Job{}
+			assert.NoError(t, c.Get(ctx, types.NamespacedName{Name: "lca-prepivot-hook", Namespace: common.LcaNamespace}, job))
+			assert.Equal(t, string(Points.PrePivot), job.Labels[PointLabel])
+			assert.Equal(t, "1718000000", job.Labels[AttemptLabel])
+			assert.Equal(t, "drain", job.Labels["app"])
+			assert.Equal(t, int64(600), *job.Spec.ActiveDeadlineSeconds)
+			assert.Equal(t, ServiceAccount, job.Spec.Template.Spec.ServiceAccountName)
+			assert.NoError(t, c.Get(ctx, types.NamespacedName{Name: ServiceAccount, Namespace: common.LcaNamespace}, &corev1.ServiceAccount{}))
+
+			job.Status.Conditions = tc.conditions
+			assert.NoError(t, c.Update(ctx, job))
+
+			done, err = r.Run(ctx, Points.PrePivot, hook, template, "1718000000")
+			assert.Equal(t, tc.wantDone, done)
+			assert.Equal(t, tc.wantFailed, IsFailedError(err))
+			if !tc.wantFailed {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRunEarlierAttempt(t *testing.T) {
+	ctx := context.Background()
+	template, err := ParseTemplate(jobTemplate)
+	assert.NoError(t, err)
+	hook := &lcav1alpha1.Hook{}
+
+	// A completed job left from an earlier attempt, e.g. when its cleanup failed
+	leftover := renderJob(Points.PrePrep, hook, template, "1717000000")
+	leftover.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}}
+	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(leftover).Build()
+	r := &Runner{Client: c, Log: logr.Discard()}
+
+	// the leftover job is deleted rather than counted as done
+	done, err := r.Run(ctx, Points.PrePrep, hook, template, "1718000000")
+	assert.NoError(t, err)
+	assert.False(t, done)
+	job := &batchv1.Job{}
+	assert.True(t, k8serrors.IsNotFound(c.Get(ctx, types.NamespacedName{Name: "lca-preprep-hook", Namespace: common.LcaNamespace}, job)))
+
+	// and created again for the current attempt
+	done, err = r.Run(ctx, Points.PrePrep, hook, template, "1718000000")
+	assert.NoError(t, err)
+	assert.False(t, done)
+	assert.NoError(t, c.Get(ctx, types.NamespacedName{Name: "lca-preprep-hook", Namespace: common.LcaNamespace}, job))
+	assert.Equal(t, "1718000000", job.Labels[AttemptLabel])
+	assert.Empty(t, job.Status.Conditions)
+}
+
+func TestCleanup(t *testing.T) {
+	ctx := context.Background()
+	other := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: common.LcaNamespace}}
+	hookJob := &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: JobName(Points.PostPivot), Namespace: common.LcaNamespace,
+		Labels: map[string]string{PointLabel: string(Points.PostPivot)}}}
+	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(other, hookJob).Build()
+	r := &Runner{Client: c, Log: logr.Discard()}
+
+	assert.NoError(t, r.Cleanup(ctx))
+
+	jobs := &batchv1.JobList{}
+	assert.NoError(t, c.List(ctx, jobs, client.InNamespace(common.LcaNamespace)))
+	assert.Len(t, jobs.Items, 1)
+	assert.Equal(t, "other", jobs.Items[0].Name)
+}
@@ -0,0 +1,237 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	seedgenv1alpha1 "github.com/openshift-kni/lifecycle-agent/api/seedgenerator/v1alpha1"
+	lcav1alpha1 "github.com/openshift-kni/lifecycle-agent/api/v1alpha1"
+)
+
+const (
+	namespace = "lca"
+
+	labelStage       = "stage"
+	labelCondition   = "condition"
+	labelReason      = "reason"
+	labelResult      = "result"
+	labelPhase       = "phase"
+	labelStep        = "step"
+	labelStatus      = "status"
+	labelSeedVersion = "seed_version"
+)
+
+// Result label values for the stage and seed generation duration histograms
+const (
+	ResultCompleted = "completed"
+	ResultFailed    = "failed"
+)
+
+// UpgradePhase identifies the part of the Upgrade stage a sub-step belongs to
+type UpgradePhase string
+
+// UpgradePhases defines the phases of the Upgrade stage, before and after the pivot to the new stateroot
+var UpgradePhases = struct {
+	PrePivot  UpgradePhase
+	PostPivot UpgradePhase
+}{
+	PrePivot:  "PrePivot",
+	PostPivot: "PostPivot",
+}
+
+var (
+	ibuStage = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "ibu",
+			Name:      "stage",
+			Help:      "Desired stage of the ImageBasedUpgrade. Set to 1 for the current stage",
+		},
+		[]string{labelStage, labelSeedVersion},
+	)
+
+	ibuCondition = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "ibu",
+			Name:      "condition",
+			Help:      "Status conditions of the ImageBasedUpgrade. Set to 1 if the condition is true and 0 otherwise",
+		},
+		[]string{labelCondition, labelReason, labelSeedVersion},
+	)
+
+	ibuStageDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "ibu",
+			Name:      "stage_duration_seconds",
+			Help:      "Duration of the Prep, Upgrade and Rollback stages, from start to completion or failure",
+			// 1 minute to ~8.5 hours
+			Buckets: prometheus.ExponentialBuckets(60, 2, 10),
+		},
+		[]string{labelStage, labelResult, labelSeedVersion},
+	)
+
+	ibuUpgradeStepDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "ibu",
+			Name:      "upgrade_step_duration_seconds",
+			Help:      "Duration of the successful PrePivot and PostPivot sub-steps of the Upgrade stage",
+			// 1 second to ~2.3 hours
+			Buckets: prometheus.ExponentialBuckets(1, 2, 14),
+		},
+		[]string{labelPhase, labelStep, labelSeedVersion},
+	)
+
+	ibuFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "ibu",
+			Name:      "failures_total",
+			Help:      "Number of ImageBasedUpgrade stage failures",
+		},
+		[]string{labelStage, labelReason, labelSeedVersion},
+	)
+
+	ibuAutoRollbacks = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "ibu",
+			Name:      "auto_rollbacks_total",
+			Help:      "Number of automatic rollbacks initiated after an Upgrade failure",
+		},
+		[]string{labelReason, labelSeedVersion},
+	)
+
+	ibuPrecacheImages = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "ibu",
+			Name:      "precache_images",
+			Help:      "Number of images handled by the Prep stage precaching job, including additional images, by status",
+		},
+		[]string{labelStatus, labelSeedVersion},
+	)
+
+	seedGenCondition = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "seedgen",
+			Name:      "condition",
+			Help:      "Status conditions of the SeedGenerator. Set to 1 if the condition is true and 0 otherwise",
+		},
+		[]string{labelCondition, labelReason, labelSeedVersion},
+	)
+
+	seedGenDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "seedgen",
+			Name:      "duration_seconds",
+			Help:      "Duration of the seed image generation, from start to completion or failure",
+			// 1 minute to ~8.5 hours
+			Buckets: prometheus.ExponentialBuckets(60, 2, 10),
+		},
+		[]string{labelResult, labelSeedVersion},
+	)
+
+	seedGenFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "seedgen",
+			Name:      "failures_total",
+			Help:      "Number of seed image generation failures",
+		},
+		[]string{labelReason, labelSeedVersion},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		ibuStage,
+		ibuCondition,
+		ibuStageDuration,
+		ibuUpgradeStepDuration,
+		ibuFailures,
+		ibuAutoRollbacks,
+		ibuPrecacheImages,
+		seedGenCondition,
+		seedGenDuration,
+		seedGenFailures,
+	)
+}
+
+// RecordIBUStatus updates the stage and condition gauges from the IBU status. Series from a previous
+// seed version or reason are dropped so that only the current state is reported
+func RecordIBUStatus(ibu *lcav1alpha1.ImageBasedUpgrade) {
+	seedVersion := ibu.Spec.SeedImageRef.Version
+
+	ibuStage.Reset()
+	if ibu.Spec.Stage != "" {
+		ibuStage.WithLabelValues(string(ibu.Spec.Stage), seedVersion).Set(1)
+	}
+
+	recordConditions(ibuCondition, ibu.Status.Conditions, seedVersion)
+}
+
+// ObserveIBUStageDuration records the duration of a stage that just completed or failed
+func ObserveIBUStageDuration(ibu *lcav1alpha1.ImageBasedUpgrade, stage lcav1alpha1.ImageBasedUpgradeStage, result string, start time.Time) {
+	ibuStageDuration.WithLabelValues(string(stage), result, ibu.Spec.SeedImageRef.Version).Observe(time.Since(start).Seconds())
+}
+
+// ObserveIBUUpgradeStep records the duration of a PrePivot or PostPivot sub-step
+func ObserveIBUUpgradeStep(ibu *lcav1alpha1.ImageBasedUpgrade, phase UpgradePhase, step string, start time.Time) {
+	ibuUpgradeStepDuration.WithLabelValues(string(phase), step, ibu.Spec.SeedImageRef.Version).Observe(time.Since(start).Seconds())
+}
+
+// IncIBUFailure counts a stage failure
+func IncIBUFailure(ibu *lcav1alpha1.ImageBasedUpgrade, stage lcav1alpha1.ImageBasedUpgradeStage, reason string) {
+	ibuFailures.WithLabelValues(string(stage), reason, ibu.Spec.SeedImageRef.Version).Inc()
+}
+
+// IncIBUAutoRollback counts an automatic rollback
+func IncIBUAutoRollback(ibu *lcav1alpha1.ImageBasedUpgrade, reason string) {
+	ibuAutoRollbacks.WithLabelValues(reason, ibu.Spec.SeedImageRef.Version).Inc()
+}
+
+// SetIBUPrecacheImages updates the precaching gauges. The counts cover the seed and additional image lists
+func SetIBUPrecacheImages(ibu *lcav1alpha1.ImageBasedUpgrade, total, pulled, skipped, failed int) {
+	seedVersion := ibu.Spec.SeedImageRef.Version
+
+	ibuPrecacheImages.Reset()
+	ibuPrecacheImages.WithLabelValues("total", seedVersion).Set(float64(total))
+	ibuPrecacheImages.WithLabelValues("pulled", seedVersion).Set(float64(pulled))
+	ibuPrecacheImages.WithLabelValues("skipped", seedVersion).Set(float64(skipped))
+	ibuPrecacheImages.WithLabelValues("failed", seedVersion).Set(float64(failed))
+}
+
+// RecordSeedGenStatus updates the condition gauge from the SeedGenerator status. The seed version is
+// the OCP version of the cluster the seed image is generated from
+func RecordSeedGenStatus(seedgen *seedgenv1alpha1.SeedGenerator, seedVersion string) {
+	recordConditions(seedGenCondition, seedgen.Status.Conditions, seedVersion)
+}
+
+// ObserveSeedGenDuration records the duration of a seed generation that just completed or failed
+func ObserveSeedGenDuration(seedVersion, result string, start time.Time) {
+	seedGenDuration.WithLabelValues(result, seedVersion).Observe(time.Since(start).Seconds())
+}
+
+// IncSeedGenFailure counts a seed generation failure
+func IncSeedGenFailure(seedVersion, reason string) {
+	seedGenFailures.WithLabelValues(reason, seedVersion).Inc()
+}
+
+func recordConditions(gauge *prometheus.GaugeVec, conditions []metav1.Condition, seedVersion string) {
+	gauge.Reset()
+	for _, condition := range conditions {
+		value := 0.0
+		if condition.Status == metav1.ConditionTrue {
+			value = 1
+		}
+		gauge.WithLabelValues(condition.Type, condition.Reason, seedVersion).Set(value)
+	}
+}
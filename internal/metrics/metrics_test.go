@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	lcav1alpha1 "github.com/openshift-kni/lifecycle-agent/api/v1alpha1"
+)
+
+func newIBU(stage lcav1alpha1.ImageBasedUpgradeStage, seedVersion string, conditions ...metav1.Condition) *lcav1alpha1.ImageBasedUpgrade {
+	return &lcav1alpha1.ImageBasedUpgrade{
+		Spec: lcav1alpha1.ImageBasedUpgradeSpec{
+			Stage:        stage,
+			SeedImageRef: lcav1alpha1.SeedImageRef{Version: seedVersion},
+		},
+		Status: lcav1alpha1.ImageBasedUpgradeStatus{Conditions: conditions},
+	}
+}
+
+// collect returns the metrics currently exported by the collector
+func collect(c prometheus.Collector) []*dto.Metric {
+	ch := make(chan prometheus.Metric, 100)
+	c.Collect(ch)
+	close(ch)
+
+	var metrics []*dto.Metric
+	for m := range ch {
+		metric := &dto.Metric{}
+		_ = m.Write(metric)
+		metrics = append(metrics, metric)
+	}
+	return metrics
+}
+
+func labels(metric *dto.Metric) map[string]string {
+	result := make(map[string]string)
+	for _, label := range metric.GetLabel() {
+		result[label.GetName()] = label.GetValue()
+	}
+	return result
+}
+
+func TestRecordIBUStatus(t *testing.T) {
+	RecordIBUStatus(newIBU(lcav1alpha1.Stages.Prep, "4.15.1",
+		metav1.Condition{Type: "Idle", Status: metav1.ConditionFalse, Reason: "InProgress"},
+		metav1.Condition{Type: "PrepInProgress", Status: metav1.ConditionTrue, Reason: "InProgress"},
+	))
+
+	stages := collect(ibuStage)
+	assert.Len(t, stages, 1)
+	assert.Equal(t, map[string]string{"stage": "Prep", "seed_version": "4.15.1"}, labels(stages[0]))
+
+	conditions := collect(ibuCondition)
+	assert.Len(t, conditions, 2)
+	for _, condition := range conditions {
+		if labels(condition)["condition"] == "PrepInProgress" {
+			assert.Equal(t, 1.0, condition.GetGauge().GetValue())
+		} else {
+			assert.Equal(t, 0.0, condition.GetGauge().GetValue())
+		}
+	}
+
+	// series of the previous stage and conditions are dropped
+	RecordIBUStatus(newIBU(lcav1alpha1.Stages.Upgrade, "4.15.1",
+		metav1.Condition{Type: "PrepCompleted", Status: metav1.ConditionTrue, Reason: "Completed"},
+	))
+
+	stages = collect(ibuStage)
+	assert.Len(t, stages, 1)
+	assert.Equal(t, "Upgrade", labels(stages[0])["stage"])
+
+	conditions = collect(ibuCondition)
+	assert.Len(t, conditions, 1)
+	assert.Equal(t, map[string]string{"condition": "PrepCompleted", "reason": "Completed", "seed_version": "4.15.1"}, labels(conditions[0]))
+	assert.Equal(t, 1.0, conditions[0].GetGauge().GetValue())
+}
+
+func TestObserveIBUStageDuration(t *testing.T) {
+	ibu := newIBU(lcav1alpha1.Stages.Upgrade, "4.15.2")
+	ObserveIBUStageDuration(ibu, lcav1alpha1.Stages.Upgrade, ResultCompleted, time.Now().Add(-10*time.Minute))
+
+	var histogram *dto.Histogram
+	for _, metric := range collect(ibuStageDuration) {
+		if labels(metric)["seed_version"] == "4.15.2" && labels(metric)["stage"] == "Upgrade" {
+			histogram = metric.GetHistogram()
+		}
+	}
+	if assert.NotNil(t, histogram) {
+		assert.Equal(t, uint64(1), histogram.GetSampleCount())
+		assert.InDelta(t, 600, histogram.GetSampleSum(), 5)
+	}
+}
+
+func TestSetIBUPrecacheImages(t *testing.T) {
+	SetIBUPrecacheImages(newIBU(lcav1alpha1.Stages.Prep, "4.15.1"), 10, 7, 2, 1)
+
+	values := make(map[string]float64)
+	for _, metric := range collect(ibuPrecacheImages) {
+		values[labels(metric)["status"]] = metric.GetGauge().GetValue()
+	}
+	assert.Equal(t, map[string]float64{"total": 10, "pulled": 7, "skipped": 2, "failed": 1}, values)
+}
@@ -0,0 +1,360 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-logr/logr"
+	configv1 "github.com/openshift/api/config/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	lcav1alpha1 "github.com/openshift-kni/lifecycle-agent/api/v1alpha1"
+	"github.com/openshift-kni/lifecycle-agent/controllers/utils"
+	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	lcautils "github.com/openshift-kni/lifecycle-agent/utils"
+)
+
+const (
+	// Dir holds the outbox of the notifications and the state they are computed from. It is copied to the other
+	// stateroot along with the IBU before each reboot, so that the notifications survive the pivot and the rollback
+	Dir = common.LCAConfigDir + "/notifications"
+
+	// Headers sent with every notification
+	EventHeader     = "X-Lca-Event"
+	DeliveryHeader  = "X-Lca-Delivery"
+	SignatureHeader = "X-Lca-Signature"
+
+	pollInterval   = 30 * time.Second
+	requestTimeout = 10 * time.Second
+	initialBackoff = 10 * time.Second
+	maxBackoff     = 10 * time.Minute
+	// maxAge is how long a notification is retried before it is dropped. It must cover the reboot to the other
+	// stateroot and the time the cluster takes to come back
+	maxAge = 24 * time.Hour
+)
+
+// EventType is the type of a notification
+type EventType string
+
+// EventTypes defines the string values for the notification types
+var EventTypes = struct {
+	PrepStarted           EventType
+	PrepCompleted         EventType
+	PrepFailed            EventType
+	UpgradeStarted        EventType
+	UpgradeCompleted      EventType
+	UpgradeFailed         EventType
+	RollbackStarted       EventType
+	RollbackCompleted     EventType
+	RollbackFailed        EventType
+	AutoRollbackTriggered EventType
+}{
+	PrepStarted:           "PrepStarted",
+	PrepCompleted:         "PrepCompleted",
+	PrepFailed:            "PrepFailed",
+	UpgradeStarted:        "UpgradeStarted",
+	UpgradeCompleted:      "UpgradeCompleted",
+	UpgradeFailed:         "UpgradeFailed",
+	RollbackStarted:       "RollbackStarted",
+	RollbackCompleted:     "RollbackCompleted",
+	RollbackFailed:        "RollbackFailed",
+	AutoRollbackTriggered: "AutoRollbackTriggered",
+}
+
+// Event is the JSON payload POSTed to the endpoints
+type Event struct {
+	// ID identifies the event. A notification may be delivered more than once, e.g. when the node reboots before
+	// its delivery is recorded, and receivers should use the ID to drop duplicates
+	ID        string                             `json:"id"`
+	Type      EventType                          `json:"type"`
+	Time      metav1.Time                        `json:"time"`
+	ClusterID string                             `json:"clusterID,omitempty"`
+	Stage     lcav1alpha1.ImageBasedUpgradeStage `json:"stage"`
+	Message   string                             `json:"message,omitempty"`
+	// SeedImage and SeedVersion are taken from spec.seedImageRef
+	SeedImage   string                              `json:"seedImage,omitempty"`
+	SeedVersion string                              `json:"seedVersion,omitempty"`
+	Status      lcav1alpha1.ImageBasedUpgradeStatus `json:"status"`
+}
+
+// transitions defines the condition changes notified for each stage
+var transitions = []struct {
+	stage     lcav1alpha1.ImageBasedUpgradeStage
+	started   EventType
+	completed EventType
+	failed    EventType
+}{
+	{lcav1alpha1.Stages.Prep, EventTypes.PrepStarted, EventTypes.PrepCompleted, EventTypes.PrepFailed},
+	{lcav1alpha1.Stages.Upgrade, EventTypes.UpgradeStarted, EventTypes.UpgradeCompleted, EventTypes.UpgradeFailed},
+	{lcav1alpha1.Stages.Rollback, EventTypes.RollbackStarted, EventTypes.RollbackCompleted, EventTypes.RollbackFailed},
+}
+
+// changedTo returns the condition of the given type if it has the given status in conditions, and didn't have it
+// in previous, or has transitioned again since
+func changedTo(previous, conditions []metav1.Condition, conditionType utils.ConditionType, status metav1.ConditionStatus) *metav1.Condition {
+	condition := meta.FindStatusCondition(conditions, string(conditionType))
+	if condition == nil || condition.Status != status {
+		return nil
+	}
+	old := meta.FindStatusCondition(previous, string(conditionType))
+	// The transition times are compared to the second, as they are saved
+	if old == nil || old.Status != status || old.LastTransitionTime.Unix() != condition.LastTransitionTime.Unix() {
+		return condition
+	}
+	return nil
+}
+
+// Transitions returns the events for the stages that started, completed or failed between the previous and the
+// current conditions of the IBU
+func Transitions(previous []metav1.Condition, ibu *lcav1alpha1.ImageBasedUpgrade) []Event {
+	var events []Event
+	for _, t := range transitions {
+		inProgressType := utils.GetInProgressConditionType(t.stage)
+		completedType := utils.GetCompletedConditionType(t.stage)
+		if condition := changedTo(previous, ibu.Status.Conditions, inProgressType, metav1.ConditionTrue); condition != nil {
+			events = append(events, newEvent(ibu, t.started, t.stage, condition.Message))
+		}
+		if condition := changedTo(previous, ibu.Status.Conditions, completedType, metav1.ConditionTrue); condition != nil {
+			events = append(events, newEvent(ibu, t.completed, t.stage, condition.Message))
+		}
+		if condition := changedTo(previous, ibu.Status.Conditions, completedType, metav1.ConditionFalse); condition != nil {
+			// The in-progress condition holds the reason of the failure
+			message := condition.Message
+			if inProgress := meta.FindStatusCondition(ibu.Status.Conditions, string(inProgressType)); inProgress != nil {
+				message = inProgress.Message
+			}
+			events = append(events, newEvent(ibu, t.failed, t.stage, message))
+		}
+	}
+	return events
+}
+
+func newEvent(ibu *lcav1alpha1.ImageBasedUpgrade, eventType EventType, stage lcav1alpha1.ImageBasedUpgradeStage, message string) Event {
+	return Event{
+		ID:          string(uuid.NewUUID()),
+		Type:        eventType,
+		Time:        metav1.Now(),
+		Stage:       stage,
+		Message:     message,
+		SeedImage:   ibu.Spec.SeedImageRef.Image,
+		SeedVersion: ibu.Spec.SeedImageRef.Version,
+		Status:      *ibu.Status.DeepCopy(),
+	}
+}
+
+// Sign returns the value of the signature header of a payload signed with the key
+func Sign(key, payload []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// EnqueueAutoRollback queues an AutoRollbackTriggered notification in the outbox of the directory, for the saved
+// IBU that is restored after the rollback. It relies on the cluster ID cached by the Notifier, since the cluster may
+// not be reachable
+func EnqueueAutoRollback(dir string, ibu *lcav1alpha1.ImageBasedUpgrade, msg string) error {
+	if ibu.Spec.Notifications == nil {
+		return nil
+	}
+	outbox := NewOutbox(dir)
+	s, err := outbox.loadState()
+	if err != nil {
+		return err
+	}
+	return outbox.enqueue(ibu.Spec.Notifications, s, []Event{newEvent(ibu, EventTypes.AutoRollbackTriggered, lcav1alpha1.Stages.Upgrade, msg)})
+}
+
+// Notifier POSTs the stage transitions of the IBU to the endpoints of spec.notifications
+type Notifier struct {
+	Client     client.Client
+	Log        logr.Logger
+	Outbox     *Outbox
+	HTTPClient *http.Client
+	wake       chan struct{}
+}
+
+// NewNotifier returns a Notifier with its outbox in the given directory
+func NewNotifier(c client.Client, log logr.Logger, dir string) *Notifier {
+	return &Notifier{
+		Client:     c,
+		Log:        log,
+		Outbox:     NewOutbox(dir),
+		HTTPClient: &http.Client{Timeout: requestTimeout},
+		wake:       make(chan struct{}, 1),
+	}
+}
+
+// Notify queues the notifications for the transitions of the IBU since it was last called, and wakes up the
+// delivery. The conditions are tracked even without endpoints, so that configuring them doesn't replay the past
+// transitions
+func (n *Notifier) Notify(ctx context.Context, ibu *lcav1alpha1.ImageBasedUpgrade) error {
+	s, err := n.Outbox.loadState()
+	if err != nil {
+		return err
+	}
+
+	if s.Conditions != nil && ibu.Spec.Notifications != nil {
+		if events := Transitions(s.Conditions, ibu); len(events) > 0 {
+			n.refresh(ctx, s)
+			if err := n.Outbox.enqueue(ibu.Spec.Notifications, s, events); err != nil {
+				// The transitions are not notified again, so record them as notified for the other endpoints
+				n.Log.Error(err, "Failed to queue notifications")
+			}
+			for _, event := range events {
+				n.Log.Info("Queued notification", "type", event.Type, "id", event.ID)
+			}
+			n.Wake()
+		}
+	}
+
+	s.Conditions = ibu.Status.Conditions
+	if s.Conditions == nil {
+		s.Conditions = []metav1.Condition{}
+	}
+	return n.Outbox.saveState(s)
+}
+
+// refresh caches the cluster ID in the state. The cached value is used when it can't be read, e.g. when the
+// notifications are queued without the cluster
+func (n *Notifier) refresh(ctx context.Context, s *state) {
+	clusterVersion := &configv1.ClusterVersion{}
+	if err := n.Client.Get(ctx, types.NamespacedName{Name: "version"}, clusterVersion); err != nil {
+		n.Log.Info("Failed to get the cluster ID for the notifications, using the cached one", "error", err.Error())
+		return
+	}
+	s.ClusterID = string(clusterVersion.Spec.ClusterID)
+}
+
+// Wake triggers a delivery of the pending notifications
+func (n *Notifier) Wake() {
+	select {
+	case n.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start delivers the pending notifications until the context is cancelled. It implements manager.Runnable
+func (n *Notifier) Start(ctx context.Context) error {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		n.Deliver(ctx)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		case <-n.wake:
+		}
+	}
+}
+
+// Deliver POSTs the notifications that are due, in the order they were queued. A failed notification is retried
+// with an exponential backoff, and holds back the later ones for the same endpoint. A signed notification is held
+// back until its signing key secret can be read, as the secrets are only restored late after the pivot
+func (n *Notifier) Deliver(ctx context.Context) {
+	names, err := n.Outbox.pending()
+	if err != nil {
+		n.Log.Error(err, "Failed to list pending notifications")
+		return
+	}
+
+	now := time.Now()
+	blocked := map[string]bool{}
+	keys := map[string][]byte{}
+	for _, name := range names {
+		d, err := n.Outbox.load(name)
+		if err != nil {
+			n.Log.Error(err, "Dropping unreadable notification", "file", name)
+			n.Outbox.remove(name)
+			continue
+		}
+		if blocked[d.URL] {
+			continue
+		}
+		if now.Sub(d.Created) > maxAge {
+			n.Log.Info("Dropping expired notification", "type", d.Type, "id", d.ID, "url", d.URL, "attempts", d.Attempts)
+			n.Outbox.remove(name)
+			continue
+		}
+		if now.Before(d.NextAttempt) {
+			blocked[d.URL] = true
+			continue
+		}
+
+		var signature string
+		if d.SigningKeyRef != "" {
+			key, ok := keys[d.SigningKeyRef]
+			if !ok {
+				data, err := lcautils.GetSecretData(ctx, d.SigningKeyRef, common.LcaNamespace, common.NotificationSigningKeyKey, n.Client)
+				if err != nil {
+					n.Log.Info("Waiting for the notification signing key", "type", d.Type, "id", d.ID, "url", d.URL,
+						"secret", d.SigningKeyRef, "error", err.Error())
+					blocked[d.URL] = true
+					continue
+				}
+				key = []byte(data)
+				keys[d.SigningKeyRef] = key
+			}
+			signature = Sign(key, d.Payload)
+		}
+
+		if err := n.post(ctx, d, signature); err != nil {
+			d.Attempts++
+			d.NextAttempt = now.Add(backoff(d.Attempts))
+			n.Log.Info("Failed to deliver notification", "type", d.Type, "id", d.ID, "url", d.URL,
+				"attempts", d.Attempts, "nextAttempt", d.NextAttempt, "error", err.Error())
+			if err := n.Outbox.save(name, d); err != nil {
+				n.Log.Error(err, "Failed to save notification", "file", name)
+			}
+			blocked[d.URL] = true
+			continue
+		}
+		n.Log.Info("Delivered notification", "type", d.Type, "id", d.ID, "url", d.URL)
+		n.Outbox.remove(name)
+	}
+}
+
+func (n *Notifier) post(ctx context.Context, d *delivery, signature string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(d.Type))
+	req.Header.Set(DeliveryHeader, d.ID)
+	if signature != "" {
+		req.Header.Set(SignatureHeader, signature)
+	}
+
+	resp, err := n.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post notification: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+	return nil
+}
+
+// backoff returns the delay before the next attempt, doubling from initialBackoff up to maxBackoff
+func backoff(attempts int) time.Duration {
+	delay := initialBackoff
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoff {
+		return maxBackoff
+	}
+	return delay
+}
@@ -0,0 +1,277 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	lcav1alpha1 "github.com/openshift-kni/lifecycle-agent/api/v1alpha1"
+	"github.com/openshift-kni/lifecycle-agent/controllers/utils"
+	"github.com/openshift-kni/lifecycle-agent/internal/common"
+)
+
+func condition(conditionType utils.ConditionType, status metav1.ConditionStatus, message string, at time.Time) metav1.Condition {
+	return metav1.Condition{Type: string(conditionType), Status: status, Message: message, LastTransitionTime: metav1.NewTime(at)}
+}
+
+func TestTransitions(t *testing.T) {
+	t1 := time.Date(2024, 3, 2, 2, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+	idle := condition(utils.ConditionTypes.Idle, metav1.ConditionFalse, "In progress", t1)
+
+	testcases := []struct {
+		name       string
+		previous   []metav1.Condition
+		conditions []metav1.Condition
+		want       []EventType
+		wantMsg    string
+	}{
+		{
+			name:       "prep started",
+			previous:   []metav1.Condition{condition(utils.ConditionTypes.Idle, metav1.ConditionTrue, "Idle", t1)},
+			conditions: []metav1.Condition{idle, condition(utils.ConditionTypes.PrepInProgress, metav1.ConditionTrue, "In progress", t1)},
+			want:       []EventType{EventTypes.PrepStarted},
+			wantMsg:    "In progress",
+		},
+		{
+			name:     "prep completed",
+			previous: []metav1.Condition{idle, condition(utils.ConditionTypes.PrepInProgress, metav1.ConditionTrue, "In progress", t1)},
+			conditions: []metav1.Condition{idle,
+				condition(utils.ConditionTypes.PrepInProgress, metav1.ConditionFalse, "Prep completed", t2),
+				condition(utils.ConditionTypes.PrepCompleted, metav1.ConditionTrue, "Prep stage completed successfully", t2)},
+			want:    []EventType{EventTypes.PrepCompleted},
+			wantMsg: "Prep stage completed successfully",
+		},
+		{
+			name:     "upgrade failed",
+			previous: []metav1.Condition{idle, condition(utils.ConditionTypes.UpgradeInProgress, metav1.ConditionTrue, "In progress", t1)},
+			conditions: []metav1.Condition{idle,
+				condition(utils.ConditionTypes.UpgradeInProgress, metav1.ConditionFalse, "health check failed", t2),
+				condition(utils.ConditionTypes.UpgradeCompleted, metav1.ConditionFalse, "Upgrade failed", t2)},
+			want:    []EventType{EventTypes.UpgradeFailed},
+			wantMsg: "health check failed",
+		},
+		{
+			name:     "rollback started after the upgrade completed",
+			previous: []metav1.Condition{idle, condition(utils.ConditionTypes.UpgradeCompleted, metav1.ConditionTrue, "Upgrade completed", t1)},
+			conditions: []metav1.Condition{idle,
+				condition(utils.ConditionTypes.UpgradeCompleted, metav1.ConditionTrue, "Upgrade completed", t1),
+				condition(utils.ConditionTypes.RollbackInProgress, metav1.ConditionTrue, "In progress", t2)},
+			want:    []EventType{EventTypes.RollbackStarted},
+			wantMsg: "In progress",
+		},
+		{
+			name:       "no transition",
+			previous:   []metav1.Condition{idle, condition(utils.ConditionTypes.PrepInProgress, metav1.ConditionTrue, "In progress", t1)},
+			conditions: []metav1.Condition{idle, condition(utils.ConditionTypes.PrepInProgress, metav1.ConditionTrue, "Pulling seed image", t1)},
+		},
+		{
+			name:       "prep completed again after the upgrade was aborted",
+			previous:   []metav1.Condition{idle, condition(utils.ConditionTypes.PrepCompleted, metav1.ConditionTrue, "Prep completed", t1)},
+			conditions: []metav1.Condition{idle, condition(utils.ConditionTypes.PrepCompleted, metav1.ConditionTrue, "Prep completed", t2)},
+			want:       []EventType{EventTypes.PrepCompleted},
+			wantMsg:    "Prep completed",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			ibu := &lcav1alpha1.ImageBasedUpgrade{Status: lcav1alpha1.ImageBasedUpgradeStatus{Conditions: tc.conditions}}
+			var types []EventType
+			for _, event := range Transitions(tc.previous, ibu) {
+				types = append(types, event.Type)
+				assert.Equal(t, tc.wantMsg, event.Message)
+			}
+			assert.Equal(t, tc.want, types)
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, 10*time.Second, backoff(1))
+	assert.Equal(t, 20*time.Second, backoff(2))
+	assert.Equal(t, 80*time.Second, backoff(4))
+	assert.Equal(t, maxBackoff, backoff(10))
+	assert.Equal(t, maxBackoff, backoff(100))
+}
+
+type request struct {
+	header  http.Header
+	payload []byte
+}
+
+func TestNotifyAndDeliver(t *testing.T) {
+	var received []request
+	failing := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.URL.Path == "/failing" && failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		received = append(received, request{header: r.Header, payload: body})
+	}))
+	defer server.Close()
+
+	testscheme := runtime.NewScheme()
+	assert.NoError(t, configv1.AddToScheme(testscheme))
+	assert.NoError(t, corev1.AddToScheme(testscheme))
+	c := fake.NewClientBuilder().WithScheme(testscheme).WithObjects(
+		&configv1.ClusterVersion{ObjectMeta: metav1.ObjectMeta{Name: "version"}, Spec: configv1.ClusterVersionSpec{ClusterID: "cluster-1"}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "noc-key", Namespace: common.LcaNamespace},
+			Data: map[string][]byte{common.NotificationSigningKeyKey: []byte("secret")}},
+	).Build()
+
+	dir := t.TempDir()
+	n := NewNotifier(c, logr.Discard(), dir)
+
+	ibu := &lcav1alpha1.ImageBasedUpgrade{
+		Spec: lcav1alpha1.ImageBasedUpgradeSpec{
+			SeedImageRef: lcav1alpha1.SeedImageRef{Image: "quay.io/openshift-kni/seed:4.16.0", Version: "4.16.0"},
+			Notifications: &lcav1alpha1.Notifications{Endpoints: []lcav1alpha1.NotificationEndpoint{
+				{URL: server.URL + "/noc", SigningKeyRef: &lcav1alpha1.SecretRef{Name: "noc-key"}},
+				{URL: server.URL + "/failing"},
+			}},
+		},
+	}
+	utils.SetStatusCondition(&ibu.Status.Conditions, utils.ConditionTypes.Idle, utils.ConditionReasons.Idle, metav1.ConditionTrue, "Idle", 0)
+
+	// The first call only records the conditions
+	assert.NoError(t, n.Notify(context.Background(), ibu))
+	names, err := n.Outbox.pending()
+	assert.NoError(t, err)
+	assert.Empty(t, names)
+
+	utils.SetPrepStatusInProgress(ibu, "In progress")
+	assert.NoError(t, n.Notify(context.Background(), ibu))
+	names, err = n.Outbox.pending()
+	assert.NoError(t, err)
+	assert.Len(t, names, 2)
+
+	// Nothing new to notify
+	assert.NoError(t, n.Notify(context.Background(), ibu))
+	names, err = n.Outbox.pending()
+	assert.NoError(t, err)
+	assert.Len(t, names, 2)
+
+	n.Deliver(context.Background())
+	if assert.Len(t, received, 1) {
+		assert.Equal(t, string(EventTypes.PrepStarted), received[0].header.Get(EventHeader))
+		assert.Equal(t, Sign([]byte("secret"), received[0].payload), received[0].header.Get(SignatureHeader))
+		event := Event{}
+		assert.NoError(t, json.Unmarshal(received[0].payload, &event))
+		assert.Equal(t, EventTypes.PrepStarted, event.Type)
+		assert.Equal(t, event.ID, received[0].header.Get(DeliveryHeader))
+		assert.Equal(t, "cluster-1", event.ClusterID)
+		assert.Equal(t, lcav1alpha1.Stages.Prep, event.Stage)
+		assert.Equal(t, "4.16.0", event.SeedVersion)
+		assert.Len(t, event.Status.Conditions, 2)
+	}
+
+	// The failed notification is kept with a backoff
+	names, err = n.Outbox.pending()
+	assert.NoError(t, err)
+	if assert.Len(t, names, 1) {
+		d, err := n.Outbox.load(names[0])
+		assert.NoError(t, err)
+		assert.Equal(t, 1, d.Attempts)
+		assert.True(t, d.NextAttempt.After(time.Now()))
+		assert.Empty(t, d.SigningKeyRef)
+
+		// Not retried before it is due
+		failing = false
+		n.Deliver(context.Background())
+		assert.Len(t, received, 1)
+
+		d.NextAttempt = time.Now()
+		assert.NoError(t, n.Outbox.save(names[0], d))
+		n.Deliver(context.Background())
+		assert.Len(t, received, 2)
+	}
+
+	names, err = n.Outbox.pending()
+	assert.NoError(t, err)
+	assert.Empty(t, names)
+}
+
+func TestEnqueueAutoRollback(t *testing.T) {
+	dir := t.TempDir()
+	current := filepath.Join(dir, "current")
+	original := filepath.Join(dir, "original")
+
+	outbox := NewOutbox(current)
+	assert.NoError(t, outbox.saveState(&state{Conditions: []metav1.Condition{}, ClusterID: "cluster-1"}))
+
+	// A stale outbox in the original stateroot is replaced
+	assert.NoError(t, NewOutbox(original).save("stale.json", &delivery{ID: "stale"}))
+
+	ibu := &lcav1alpha1.ImageBasedUpgrade{Spec: lcav1alpha1.ImageBasedUpgradeSpec{
+		Notifications: &lcav1alpha1.Notifications{Endpoints: []lcav1alpha1.NotificationEndpoint{
+			{URL: "https://noc.example.com/ibu", SigningKeyRef: &lcav1alpha1.SecretRef{Name: "noc-key"}}}},
+	}}
+	assert.NoError(t, EnqueueAutoRollback(current, ibu, "Rollback due to postpivot failure"))
+	assert.NoError(t, outbox.CopyTo(original))
+
+	names, err := NewOutbox(original).pending()
+	assert.NoError(t, err)
+	if assert.Len(t, names, 1) {
+		d, err := NewOutbox(original).load(names[0])
+		assert.NoError(t, err)
+		assert.Equal(t, EventTypes.AutoRollbackTriggered, d.Type)
+		assert.Equal(t, "noc-key", d.SigningKeyRef)
+		event := Event{}
+		assert.NoError(t, json.Unmarshal(d.Payload, &event))
+		assert.Equal(t, "cluster-1", event.ClusterID)
+		assert.Equal(t, "Rollback due to postpivot failure", event.Message)
+	}
+	_, err = os.Stat(filepath.Join(original, stateFile))
+	assert.NoError(t, err)
+}
+
+func TestDeliverWaitsForSigningKey(t *testing.T) {
+	var received []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, request{header: r.Header, payload: body})
+	}))
+	defer server.Close()
+
+	testscheme := runtime.NewScheme()
+	assert.NoError(t, corev1.AddToScheme(testscheme))
+	c := fake.NewClientBuilder().WithScheme(testscheme).Build()
+
+	n := NewNotifier(c, logr.Discard(), t.TempDir())
+	assert.NoError(t, n.Outbox.save("1.json", &delivery{ID: "1", Type: EventTypes.UpgradeCompleted, URL: server.URL,
+		Payload: []byte(`{"id":"1"}`), SigningKeyRef: "noc-key", Created: time.Now()}))
+
+	// The secret isn't restored yet, the notification is held back without counting an attempt
+	n.Deliver(context.Background())
+	assert.Empty(t, received)
+	d, err := n.Outbox.load("1.json")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, d.Attempts)
+
+	assert.NoError(t, c.Create(context.Background(), &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "noc-key", Namespace: common.LcaNamespace},
+		Data: map[string][]byte{common.NotificationSigningKeyKey: []byte("secret")}}))
+	n.Deliver(context.Background())
+	if assert.Len(t, received, 1) {
+		assert.Equal(t, Sign([]byte("secret"), received[0].payload), received[0].header.Get(SignatureHeader))
+	}
+	names, err := n.Outbox.pending()
+	assert.NoError(t, err)
+	assert.Empty(t, names)
+}
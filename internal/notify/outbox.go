@@ -0,0 +1,175 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	cp "github.com/otiai10/copy"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	lcav1alpha1 "github.com/openshift-kni/lifecycle-agent/api/v1alpha1"
+)
+
+const (
+	stateFile = "state.json"
+	outboxDir = "outbox"
+)
+
+// state is what the notifications are computed from
+type state struct {
+	// Conditions are the IBU conditions when the transitions were last notified, nil if they never were
+	Conditions []metav1.Condition `json:"conditions"`
+	// ClusterID is cached from the cluster
+	ClusterID string `json:"clusterID,omitempty"`
+}
+
+// delivery is a notification queued for an endpoint
+type delivery struct {
+	ID      string    `json:"id"`
+	Type    EventType `json:"type"`
+	URL     string    `json:"url"`
+	Payload []byte    `json:"payload"`
+	// SigningKeyRef is the name of the secret holding the key the payload is signed with when it is delivered
+	SigningKeyRef string    `json:"signingKeyRef,omitempty"`
+	Created       time.Time `json:"created"`
+	Attempts      int       `json:"attempts,omitempty"`
+	NextAttempt   time.Time `json:"nextAttempt,omitempty"`
+}
+
+// Outbox persists the notifications in a directory until they are delivered, one file per notification and
+// endpoint. Only the name of the signing key secret is persisted, the payloads are signed when they are delivered
+type Outbox struct {
+	dir string
+}
+
+// NewOutbox returns the outbox in the given directory
+func NewOutbox(dir string) *Outbox {
+	return &Outbox{dir: dir}
+}
+
+// CopyTo replaces the outbox in the given directory with a copy of this one, e.g. in the other stateroot before a
+// reboot
+func (o *Outbox) CopyTo(dir string) error {
+	if _, err := os.Stat(o.dir); os.IsNotExist(err) {
+		return nil
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to remove notifications in %s: %w", dir, err)
+	}
+	if err := cp.Copy(o.dir, dir); err != nil {
+		return fmt.Errorf("failed to copy notifications from %s to %s: %w", o.dir, dir, err)
+	}
+	return nil
+}
+
+func (o *Outbox) loadState() (*state, error) {
+	s := &state{}
+	data, err := os.ReadFile(filepath.Join(o.dir, stateFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("failed to read notification state: %w", err)
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("failed to decode notification state: %w", err)
+	}
+	return s, nil
+}
+
+func (o *Outbox) saveState(s *state) error {
+	return o.write(filepath.Join(o.dir, stateFile), s)
+}
+
+// enqueue queues the events for each endpoint
+func (o *Outbox) enqueue(notifications *lcav1alpha1.Notifications, s *state, events []Event) error {
+	for _, event := range events {
+		event.ClusterID = s.ClusterID
+		data, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("failed to marshal notification: %w", err)
+		}
+
+		for i, endpoint := range notifications.Endpoints {
+			d := &delivery{
+				ID:      event.ID,
+				Type:    event.Type,
+				URL:     endpoint.URL,
+				Payload: data,
+				Created: event.Time.Time,
+			}
+			if endpoint.SigningKeyRef != nil {
+				d.SigningKeyRef = endpoint.SigningKeyRef.Name
+			}
+			// The names sort in the order the notifications are queued
+			name := fmt.Sprintf("%020d-%s-%d.json", event.Time.UnixNano(), event.ID, i)
+			if err := o.save(name, d); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// pending returns the names of the queued notifications, oldest first
+func (o *Outbox) pending() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(o.dir, outboxDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read notification outbox: %w", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".json") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (o *Outbox) load(name string) (*delivery, error) {
+	data, err := os.ReadFile(filepath.Join(o.dir, outboxDir, name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read notification %s: %w", name, err)
+	}
+	d := &delivery{}
+	if err := json.Unmarshal(data, d); err != nil {
+		return nil, fmt.Errorf("failed to decode notification %s: %w", name, err)
+	}
+	return d, nil
+}
+
+func (o *Outbox) save(name string, d *delivery) error {
+	return o.write(filepath.Join(o.dir, outboxDir, name), d)
+}
+
+func (o *Outbox) remove(name string) {
+	_ = os.Remove(filepath.Join(o.dir, outboxDir, name))
+}
+
+// write saves the data through a temporary file, so that a reboot or a concurrent delivery never sees a partial file
+func (o *Outbox) write(filename string, data any) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0o700); err != nil {
+		return fmt.Errorf("failed to create notification dir: %w", err)
+	}
+	marshaled, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", filename, err)
+	}
+	tmp := filename + ".tmp"
+	if err := os.WriteFile(tmp, marshaled, 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", tmp, err)
+	}
+	if err := os.Rename(tmp, filename); err != nil {
+		return fmt.Errorf("failed to rename %s: %w", tmp, err)
+	}
+	return nil
+}
@@ -110,17 +110,22 @@ func (mr *MockIClientMockRecorder) OSInit(osname any) *gomock.Call {
 }
 
 // PullLocal mocks base method.
-func (m *MockIClient) PullLocal(repoPath string) error {
+func (m *MockIClient) PullLocal(repoPath string, refs ...string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "PullLocal", repoPath)
+	varargs := []any{repoPath}
+	for _, a := range refs {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "PullLocal", varargs...)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // PullLocal indicates an expected call of PullLocal.
-func (mr *MockIClientMockRecorder) PullLocal(repoPath any) *gomock.Call {
+func (mr *MockIClientMockRecorder) PullLocal(repoPath any, refs ...any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PullLocal", reflect.TypeOf((*MockIClient)(nil).PullLocal), repoPath)
+	varargs := append([]any{repoPath}, refs...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PullLocal", reflect.TypeOf((*MockIClient)(nil).PullLocal), varargs...)
 }
 
 // SetDefaultDeployment mocks base method.
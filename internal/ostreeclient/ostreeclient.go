@@ -12,7 +12,7 @@ import (
 
 //go:generate mockgen -source=ostreeclient.go -package=ostreeclient -destination=mock_ostreeclient.go
 type IClient interface {
-	PullLocal(repoPath string) error
+	PullLocal(repoPath string, refs ...string) error
 	OSInit(osname string) error
 	Deploy(osname, refsepc string, kargs []string) error
 	Undeploy(ostreeIndex int) error
@@ -34,12 +34,14 @@ func NewClient(executor ops.Execute, ibi bool) IClient {
 	}
 }
 
-func (c *Client) PullLocal(repoPath string) error {
+// PullLocal pulls the refs from the repo, all of them if none is given
+func (c *Client) PullLocal(repoPath string, refs ...string) error {
 	args := []string{"pull-local"}
 	if c.ibi {
 		args = append(args, "--repo", "/mnt/ostree/repo")
 	}
-	if _, err := c.executor.Execute("ostree", append(args, repoPath)...); err != nil {
+	args = append(append(args, repoPath), refs...)
+	if _, err := c.executor.Execute("ostree", args...); err != nil {
 		return fmt.Errorf("failed to pull local ostree with args %s, %w", args, err)
 	}
 
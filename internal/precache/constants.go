@@ -32,8 +32,9 @@ const (
 
 // Image paths
 const (
-	PrecachingSpecFilepath string = "/tmp/"
-	PrecachingSpecFilename string = "images.txt"
+	PrecachingSpecFilepath           string = "/tmp/"
+	PrecachingSpecFilename           string = "images.txt"
+	PrecachingAdditionalSpecFilename string = "additional-images.txt"
 )
 
 // StatusFile is the filename for persisting the precaching progress tracker
@@ -41,10 +42,11 @@ const StatusFile = utils.IBUWorkspacePath + "/precache_status.json"
 
 // Environment variable names
 const (
-	EnvLcaPrecacheImage   string = "PRECACHE_WORKLOAD_IMG"
-	EnvPrecacheSpecFile   string = "PRECACHE_SPEC_FILE"
-	EnvMaxPullThreads     string = "MAX_PULL_THREADS"
-	EnvPrecacheBestEffort string = "PRECACHE_BEST_EFFORT"
+	EnvLcaPrecacheImage           string = "PRECACHE_WORKLOAD_IMG"
+	EnvPrecacheSpecFile           string = "PRECACHE_SPEC_FILE"
+	EnvPrecacheAdditionalSpecFile string = "PRECACHE_ADDITIONAL_SPEC_FILE"
+	EnvMaxPullThreads             string = "MAX_PULL_THREADS"
+	EnvPrecacheBestEffort         string = "PRECACHE_BEST_EFFORT"
 )
 
 // Precaching job specs
@@ -54,9 +54,12 @@ func getJob(ctx context.Context, c client.Client, name, namespace string) (*batc
 	return job, nil
 }
 
-func renderConfigMap(imageList []string) *corev1.ConfigMap {
+func renderConfigMap(imageList, additionalImageList []string) *corev1.ConfigMap {
 	data := make(map[string]string)
 	data[PrecachingSpecFilename] = strings.Join(imageList, "\n") + "\n"
+	if len(additionalImageList) > 0 {
+		data[PrecachingAdditionalSpecFilename] = strings.Join(additionalImageList, "\n") + "\n"
+	}
 
 	configMap := &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
@@ -166,6 +169,12 @@ func renderJob(config *Config, log logr.Logger) (*batchv1.Job, error) {
 			Value: strconv.Itoa(numConcurrentPulls),
 		},
 	}...)
+	if len(config.AdditionalImageList) > 0 {
+		precacheEnvVars = append(precacheEnvVars, corev1.EnvVar{
+			Name:  EnvPrecacheAdditionalSpecFile,
+			Value: filepath.Join(PrecachingSpecFilepath, PrecachingAdditionalSpecFilename),
+		})
+	}
 
 	job := &batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
@@ -56,10 +56,11 @@ func generateImageList() ([]string, string) {
 func TestRenderConfigMap(t *testing.T) {
 	imageList, imageListStr := generateImageList()
 	testCases := []struct {
-		name               string
-		inputConfigMapName string
-		inputImageList     []string
-		expectedConfigMap  *corev1.ConfigMap
+		name                     string
+		inputConfigMapName       string
+		inputImageList           []string
+		inputAdditionalImageList []string
+		expectedConfigMap        *corev1.ConfigMap
 	}{
 		{
 			name:               "Empty data list",
@@ -89,10 +90,26 @@ func TestRenderConfigMap(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:                     "Image data list with additional images",
+			inputConfigMapName:       LcaPrecacheConfigMapName,
+			inputImageList:           imageList,
+			inputAdditionalImageList: imageList[2:],
+			expectedConfigMap: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      LcaPrecacheConfigMapName,
+					Namespace: common.LcaNamespace,
+				},
+				Data: map[string]string{
+					PrecachingSpecFilename:           imageListStr,
+					PrecachingAdditionalSpecFilename: "precache-test-image3:latest\n",
+				},
+			},
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			cm := renderConfigMap(tc.inputImageList)
+			cm := renderConfigMap(tc.inputImageList, tc.inputAdditionalImageList)
 			assert.NotNil(t, cm)
 
 			// Validate ConfigMap
@@ -311,6 +328,23 @@ func TestRenderJob(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:          "Image list with additional images provided in precaching config",
+			config:        NewConfig([]string{"image1", "image2"}, []corev1.EnvVar{}, "AdditionalImageList", []string{"image2"}),
+			expectedError: nil,
+			expectedArgs: []string{fmt.Sprintf("nice -n %d ionice -c %d -n %d precache",
+				DefaultNicePriority, DefaultIoNiceClass, DefaultIoNicePriority)},
+			expectedEnvVars: []corev1.EnvVar{
+				{
+					Name:  EnvMaxPullThreads,
+					Value: strconv.Itoa(DefaultMaxConcurrentPulls),
+				},
+				{
+					Name:  EnvPrecacheAdditionalSpecFile,
+					Value: filepath.Join(PrecachingSpecFilepath, PrecachingAdditionalSpecFilename),
+				},
+			},
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
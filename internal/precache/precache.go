@@ -47,6 +47,9 @@ type Config struct {
 	ImageList          []string
 	NumConcurrentPulls int
 
+	// Subset of ImageList requested by the user through spec.additionalImages, tracked separately in the progress report
+	AdditionalImageList []string
+
 	// To run pre-caching job with an adjusted niceness, which affects process scheduling.
 	// Niceness values range from -20 (most favorable to the process) to 19 (least favorable to the process).
 	NicePriority int
@@ -66,6 +69,7 @@ type Config struct {
 //   - "NicePriority" (int): Nice priority for pre-caching.
 //   - "IoNiceClass" (int): I/O nice class for pre-caching.
 //   - "IoNicePriority" (int): I/O nice priority for pre-caching.
+//   - "AdditionalImageList" ([]string): Subset of imageList that comes from spec.additionalImages.
 //
 // Example usage:
 //
@@ -100,6 +104,10 @@ func NewConfig(imageList []string, envVars []corev1.EnvVar, args ...any) *Config
 			if IoNicePriority, ok := value.(int); ok {
 				instance.IoNicePriority = IoNicePriority
 			}
+		case "AdditionalImageList":
+			if AdditionalImageList, ok := value.([]string); ok {
+				instance.AdditionalImageList = AdditionalImageList
+			}
 		}
 	}
 
@@ -121,7 +129,7 @@ func (h *PHandler) CreateJob(ctx context.Context, config *Config) error {
 	}
 
 	// Generate ConfigMap for list of images to be pre-cached
-	cm := renderConfigMap(config.ImageList)
+	cm := renderConfigMap(config.ImageList, config.AdditionalImageList)
 	err := h.Client.Create(ctx, cm)
 	if err != nil {
 		return fmt.Errorf("failed to create configMap for precache: %w", err)
@@ -183,6 +191,10 @@ func (h *PHandler) QueryJobStatus(ctx context.Context) (*Status, error) {
 			} else {
 				status.Message = fmt.Sprintf("total: %d (pulled: %d, skipped: %d, failed: %d)",
 					status.Progress.Total, status.Progress.Pulled, status.Progress.Skipped, status.Progress.Failed)
+				if additional := status.Progress.Additional; additional != nil && additional.Total > 0 {
+					status.Message += fmt.Sprintf(", additional images total: %d (pulled: %d, skipped: %d, failed: %d)",
+						additional.Total, additional.Pulled, additional.Skipped, additional.Failed)
+				}
 			}
 		} else {
 			h.Log.Info("Unable to read precaching progress file", "StatusFile", StatusFile)
@@ -149,7 +149,7 @@ func TestCreateJob(t *testing.T) {
 
 			// Inject ConfigMap, Job
 			if tc.inputConfigMapName != "" {
-				cm := renderConfigMap(tc.config.ImageList)
+				cm := renderConfigMap(tc.config.ImageList, tc.config.AdditionalImageList)
 				objs = append(objs, cm)
 			}
 			if tc.inputJobName != "" {
@@ -259,7 +259,7 @@ func TestQueryJobStatus(t *testing.T) {
 			objs := []client.Object{}
 
 			// Inject ConfigMap, Job
-			cm := renderConfigMap(config.ImageList)
+			cm := renderConfigMap(config.ImageList, config.AdditionalImageList)
 			objs = append(objs, cm)
 
 			Log := ctrl.Log.WithName("Precache")
@@ -335,7 +335,7 @@ func TestCleanup(t *testing.T) {
 
 			// Inject ConfigMap, Job
 			if tc.inputConfigMapName != "" {
-				cm := renderConfigMap(config.ImageList)
+				cm := renderConfigMap(config.ImageList, config.AdditionalImageList)
 				objs = append(objs, cm)
 			}
 			if tc.inputJobName != "" {
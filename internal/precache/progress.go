@@ -31,7 +31,47 @@ type Progress struct {
 	Failed         int      `json:"failed"`
 	Skipped        int      `json:"skipped"`
 	FailedPullList []string `json:"failed_pulls"`
-	mux            sync.Mutex
+	// Additional tracks the images requested through spec.additionalImages. These are also included in the totals above
+	Additional *AdditionalProgress `json:"additional,omitempty"`
+	mux        sync.Mutex
+}
+
+// AdditionalProgress represents the progress tracking data for the additional images of the precaching job
+type AdditionalProgress struct {
+	Total   int `json:"total"`
+	Pulled  int `json:"pulled"`
+	Failed  int `json:"failed"`
+	Skipped int `json:"skipped"`
+	images  map[string]bool
+}
+
+// NewProgress returns a progress tracker for the given image list, with the additional images tracked separately
+func NewProgress(imageList, additionalImageList []string) *Progress {
+	progress := &Progress{
+		Total:   len(imageList),
+		Pulled:  0,
+		Skipped: 0,
+		Failed:  0,
+	}
+
+	if len(additionalImageList) > 0 {
+		progress.Additional = &AdditionalProgress{images: make(map[string]bool)}
+		for _, image := range additionalImageList {
+			progress.Additional.images[image] = true
+		}
+		for _, image := range imageList {
+			if progress.Additional.images[image] {
+				progress.Additional.Total++
+			}
+		}
+	}
+
+	return progress
+}
+
+// isAdditional reports whether the image was requested through spec.additionalImages
+func (p *Progress) isAdditional(image string) bool {
+	return p.Additional != nil && p.Additional.images[image]
 }
 
 func (p *Progress) Update(success bool, image string) {
@@ -40,12 +80,29 @@ func (p *Progress) Update(success bool, image string) {
 
 	if success {
 		p.Pulled++
+		if p.isAdditional(image) {
+			p.Additional.Pulled++
+		}
 	} else {
 		p.Failed++
 		if p.FailedPullList == nil {
 			p.FailedPullList = []string{}
 		}
 		p.FailedPullList = append(p.FailedPullList, image)
+		if p.isAdditional(image) {
+			p.Additional.Failed++
+		}
+	}
+}
+
+// Skip records an image that is already present locally and doesn't need to be pulled
+func (p *Progress) Skip(image string) {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+
+	p.Skipped++
+	if p.isAdditional(image) {
+		p.Additional.Skipped++
 	}
 }
 
@@ -54,6 +111,10 @@ func (p *Progress) Log() {
 	logrus.Infof("Images Pulled Successfully: %d", p.Pulled)
 	logrus.Infof("Images Skipped: %d", p.Skipped)
 	logrus.Infof("Images Failed to Pull: %d", p.Failed)
+	if p.Additional != nil {
+		logrus.Infof("Additional Images: %d (pulled: %d, skipped: %d, failed: %d)",
+			p.Additional.Total, p.Additional.Pulled, p.Additional.Skipped, p.Additional.Failed)
+	}
 	for _, img := range p.FailedPullList {
 		logrus.Infof("failed: %s", img)
 	}
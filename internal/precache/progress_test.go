@@ -0,0 +1,87 @@
+/*
+ * Copyright 2023 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this inputFilePath except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package precache
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgressAdditionalImages(t *testing.T) {
+	testCases := []struct {
+		name               string
+		imageList          []string
+		additionalImages   []string
+		skipped            []string
+		pulled             []string
+		failed             []string
+		expectedProgress   *Progress
+		expectedAdditional *AdditionalProgress
+	}{
+		{
+			name:             "No additional images",
+			imageList:        []string{"image1", "image2"},
+			additionalImages: nil,
+			skipped:          []string{"image1"},
+			pulled:           []string{"image2"},
+			expectedProgress: &Progress{Total: 2, Pulled: 1, Skipped: 1},
+		},
+		{
+			name:               "Additional images tracked separately",
+			imageList:          []string{"image1", "image2", "image3", "image4"},
+			additionalImages:   []string{"image3", "image4"},
+			skipped:            []string{"image1", "image3"},
+			pulled:             []string{"image2"},
+			failed:             []string{"image4"},
+			expectedProgress:   &Progress{Total: 4, Pulled: 1, Skipped: 2, Failed: 1, FailedPullList: []string{"image4"}},
+			expectedAdditional: &AdditionalProgress{Total: 2, Skipped: 1, Failed: 1},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			progress := NewProgress(tc.imageList, tc.additionalImages)
+			for _, image := range tc.skipped {
+				progress.Skip(image)
+			}
+			for _, image := range tc.pulled {
+				progress.Update(true, image)
+			}
+			for _, image := range tc.failed {
+				progress.Update(false, image)
+			}
+
+			assert.Equal(t, tc.expectedProgress.Total, progress.Total)
+			assert.Equal(t, tc.expectedProgress.Pulled, progress.Pulled)
+			assert.Equal(t, tc.expectedProgress.Skipped, progress.Skipped)
+			assert.Equal(t, tc.expectedProgress.Failed, progress.Failed)
+			assert.Equal(t, tc.expectedProgress.FailedPullList, progress.FailedPullList)
+
+			// Verify the persisted format, which is what the controller reads back
+			data, err := json.Marshal(progress)
+			assert.NoError(t, err)
+			loaded := &Progress{}
+			assert.NoError(t, json.Unmarshal(data, loaded))
+			if tc.expectedAdditional == nil {
+				assert.Nil(t, loaded.Additional)
+			} else {
+				assert.Equal(t, tc.expectedAdditional, loaded.Additional)
+			}
+		})
+	}
+}
@@ -107,16 +107,12 @@ func GetAuthFile() (string, error) {
 	return authFile, nil
 }
 
-// PullImages pulls a list of images using podman
-func PullImages(precacheSpec []string, authFile string) *precache.Progress {
+// PullImages pulls a list of images using podman. The additionalSpec images, if any, must also be part of the
+// precacheSpec and are reported separately in the progress tracker
+func PullImages(precacheSpec, additionalSpec []string, authFile string) *precache.Progress {
 
 	// Initialize progress tracking
-	progress := &precache.Progress{
-		Total:   len(precacheSpec),
-		Pulled:  0,
-		Skipped: 0,
-		Failed:  0,
-	}
+	progress := precache.NewProgress(precacheSpec, additionalSpec)
 
 	var pullSpec = make([]string, 0, len(precacheSpec))
 	// Sift through image list to determine which images exist, and which need to be pulled
@@ -130,7 +126,7 @@ func PullImages(precacheSpec []string, authFile string) *precache.Progress {
 			pullSpec = append(pullSpec, image)
 		} else {
 			log.Infof("%s exists, skipping it...", image)
-			progress.Skipped++
+			progress.Skip(image)
 		}
 	}
 	log.Infof("Check complete: %d images need to be pulled!", len(pullSpec))
@@ -191,9 +187,9 @@ func ValidatePrecache(status *precache.Progress, bestEffort bool) error {
 	return nil
 }
 
-func Precache(precacheSpec []string, authFile string, bestEffort bool) error {
+func Precache(precacheSpec, additionalSpec []string, authFile string, bestEffort bool) error {
 	// Pre-cache images
-	status := PullImages(precacheSpec, authFile)
+	status := PullImages(precacheSpec, additionalSpec, authFile)
 	log.Info("Completed executing pre-caching")
 
 	if err := ValidatePrecache(status, bestEffort); err != nil {
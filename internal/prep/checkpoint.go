@@ -0,0 +1,150 @@
+package prep
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Step identifies a Prep stage step that is checkpointed on disk, so that the
+// Prep stage can be resumed if the lifecycle-agent pod is restarted
+type Step string
+
+// Steps defines the checkpointed Prep stage steps, in the order they are run
+var Steps = struct {
+	ClusterHealthCheck     Step
+	PrePrepHook            Step
+	DiskSpaceCheck         Step
+	SeedImagePull          Step
+	SeedImageCompatibility Step
+	StaterootSetup         Step
+	PrecacheLaunch         Step
+	PrecacheWait           Step
+	PostPrepHook           Step
+}{
+	ClusterHealthCheck:     "ClusterHealthCheck",
+	PrePrepHook:            "PrePrepHook",
+	DiskSpaceCheck:         "DiskSpaceCheck",
+	SeedImagePull:          "SeedImagePull",
+	SeedImageCompatibility: "SeedImageCompatibility",
+	StaterootSetup:         "StaterootSetup",
+	PrecacheLaunch:         "PrecacheLaunch",
+	PrecacheWait:           "PrecacheWait",
+	PostPrepHook:           "PostPrepHook",
+}
+
+// StepState is the recorded state of a checkpointed step
+type StepState string
+
+const (
+	// StepStarted is recorded before a step runs. A step found in this state
+	// after a restart was interrupted and must be cleaned up and rerun
+	StepStarted StepState = "Started"
+	// StepCompleted is recorded once a step finishes successfully
+	StepCompleted StepState = "Completed"
+)
+
+// Checkpoint tracks the progress of the Prep stage for a given seed image
+type Checkpoint struct {
+	SeedImage   string             `json:"seedImage"`
+	SeedVersion string             `json:"seedVersion"`
+	Steps       map[Step]StepState `json:"steps"`
+	// SeedImageDigest is the digest the seed image resolved to when it was pulled
+	SeedImageDigest string `json:"seedImageDigest,omitempty"`
+
+	path string
+}
+
+// LoadCheckpoint reads the Prep checkpoint from the given file. A new empty
+// checkpoint is returned if the file doesn't exist or if it was recorded for a
+// different seed image or version
+func LoadCheckpoint(path, seedImage, seedVersion string) (*Checkpoint, error) {
+	checkpoint := &Checkpoint{
+		SeedImage:   seedImage,
+		SeedVersion: seedVersion,
+		Steps:       make(map[Step]StepState),
+		path:        path,
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return checkpoint, nil
+		}
+		return nil, fmt.Errorf("failed to read prep checkpoint %s: %w", path, err)
+	}
+
+	stored := &Checkpoint{}
+	if err := json.Unmarshal(data, stored); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal prep checkpoint %s: %w", path, err)
+	}
+	if stored.SeedImage != seedImage || stored.SeedVersion != seedVersion {
+		return checkpoint, nil
+	}
+	for step, state := range stored.Steps {
+		checkpoint.Steps[step] = state
+	}
+	checkpoint.SeedImageDigest = stored.SeedImageDigest
+
+	return checkpoint, nil
+}
+
+// IsCompleted returns true if the step was completed
+func (c *Checkpoint) IsCompleted(step Step) bool {
+	return c.Steps[step] == StepCompleted
+}
+
+// IsInterrupted returns true if the step was started but never completed
+func (c *Checkpoint) IsInterrupted(step Step) bool {
+	return c.Steps[step] == StepStarted
+}
+
+// InterruptedStep returns the step that was started but never completed, if any. Steps run one at a
+// time, so there is at most one such step
+func (c *Checkpoint) InterruptedStep() (Step, bool) {
+	for step, state := range c.Steps {
+		if state == StepStarted {
+			return step, true
+		}
+	}
+	return "", false
+}
+
+// MarkStarted records that the step is about to run
+func (c *Checkpoint) MarkStarted(step Step) error {
+	return c.set(step, StepStarted)
+}
+
+// MarkCompleted records that the step finished successfully
+func (c *Checkpoint) MarkCompleted(step Step) error {
+	return c.set(step, StepCompleted)
+}
+
+// SetSeedImageDigest records the digest of the pulled seed image, which the following steps use
+func (c *Checkpoint) SetSeedImageDigest(digest string) error {
+	c.SeedImageDigest = digest
+	return c.save()
+}
+
+func (c *Checkpoint) set(step Step, state StepState) error {
+	c.Steps[step] = state
+	return c.save()
+}
+
+func (c *Checkpoint) save() error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to marshal prep checkpoint: %w", err)
+	}
+
+	// Write to a temporary file first so that a restart never leaves a truncated checkpoint behind
+	tmpFile := filepath.Join(filepath.Dir(c.path), "."+filepath.Base(c.path)+".tmp")
+	if err := os.WriteFile(tmpFile, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write prep checkpoint %s: %w", tmpFile, err)
+	}
+	if err := os.Rename(tmpFile, c.path); err != nil {
+		return fmt.Errorf("failed to rename prep checkpoint %s: %w", tmpFile, err)
+	}
+	return nil
+}
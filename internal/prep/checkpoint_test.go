@@ -0,0 +1,45 @@
+package prep
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckpoint(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prep_checkpoint.json")
+
+	// no checkpoint file yet
+	checkpoint, err := LoadCheckpoint(path, "quay.io/seed:4.15.1", "4.15.1")
+	assert.NoError(t, err)
+	assert.False(t, checkpoint.IsCompleted(Steps.SeedImagePull))
+	assert.False(t, checkpoint.IsInterrupted(Steps.SeedImagePull))
+
+	assert.NoError(t, checkpoint.MarkStarted(Steps.SeedImagePull))
+	assert.NoError(t, checkpoint.SetSeedImageDigest("sha256:0123"))
+	assert.NoError(t, checkpoint.MarkCompleted(Steps.SeedImagePull))
+	assert.NoError(t, checkpoint.MarkStarted(Steps.StaterootSetup))
+
+	// simulate a restart by reloading the checkpoint from file
+	checkpoint, err = LoadCheckpoint(path, "quay.io/seed:4.15.1", "4.15.1")
+	assert.NoError(t, err)
+	assert.True(t, checkpoint.IsCompleted(Steps.SeedImagePull))
+	assert.False(t, checkpoint.IsInterrupted(Steps.SeedImagePull))
+	assert.Equal(t, "sha256:0123", checkpoint.SeedImageDigest)
+	assert.False(t, checkpoint.IsCompleted(Steps.StaterootSetup))
+	assert.True(t, checkpoint.IsInterrupted(Steps.StaterootSetup))
+	assert.False(t, checkpoint.IsCompleted(Steps.PrecacheLaunch))
+	step, interrupted := checkpoint.InterruptedStep()
+	assert.True(t, interrupted)
+	assert.Equal(t, Steps.StaterootSetup, step)
+
+	// a checkpoint recorded for another seed image is discarded
+	checkpoint, err = LoadCheckpoint(path, "quay.io/seed:4.15.2", "4.15.2")
+	assert.NoError(t, err)
+	assert.False(t, checkpoint.IsCompleted(Steps.SeedImagePull))
+	assert.False(t, checkpoint.IsInterrupted(Steps.StaterootSetup))
+	assert.Empty(t, checkpoint.SeedImageDigest)
+	_, interrupted = checkpoint.InterruptedStep()
+	assert.False(t, interrupted)
+}
@@ -0,0 +1,163 @@
+package prep
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/go-logr/logr"
+	"github.com/samber/lo"
+
+	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
+	rpmostreeclient "github.com/openshift-kni/lifecycle-agent/lca-cli/ostreeclient"
+)
+
+// The files of a delta seed image. Instead of the ostree, var and etc archives, it holds an archive of the ostree
+// objects that are not in the base seed image, and rsync batches of the binary changes from the base var and etc
+const (
+	SeedDeltaFile = "delta.json"
+	VarDeltaBatch = "var.batch"
+	EtcDeltaBatch = "etc.batch"
+)
+
+// RsyncBatchArgs are the rsync options the var and etc batches are written and read with. They preserve the
+// hardlinks, ACLs and xattrs, including the SELinux labels
+var RsyncBatchArgs = []string{"-aHAX", "--numeric-ids", "--delete"}
+
+// SeedDelta describes the base seed image of a delta seed image
+type SeedDelta struct {
+	// BaseSeedImage is the reference of the base seed image, pinned to its digest
+	BaseSeedImage  string `json:"baseSeedImage"`
+	BaseSeedDigest string `json:"baseSeedDigest"`
+	// BaseOstreeCommit is the ostree commit booted in the base seed, which holds the objects the delta relies on
+	BaseOstreeCommit string `json:"baseOstreeCommit"`
+	// BaseSeedFormatVersion selects the archives of the base seed. It is unset in the delta seed images of format 4,
+	// whose base seed images have gzip archives
+	BaseSeedFormatVersion int `json:"baseSeedFormatVersion,omitempty"`
+}
+
+// seedContent is a mounted seed image, and the names of its archives
+type seedContent struct {
+	mountpoint string
+	archives   SeedArchives
+}
+
+func (s seedContent) path(name string) string {
+	return filepath.Join(s.mountpoint, name)
+}
+
+// SeedDeltaBaseMissingError is returned when the base seed image of a delta seed image is not present on the node
+type SeedDeltaBaseMissingError struct {
+	BaseSeedImage string
+}
+
+func (e *SeedDeltaBaseMissingError) Error() string {
+	return fmt.Sprintf("the base seed image %s of the delta seed image is not present on the node. "+
+		"Pull it on the node, or use a full seed image", e.BaseSeedImage)
+}
+
+// IsSeedDeltaBaseMissingError returns true if the error, or any error it wraps, is a SeedDeltaBaseMissingError
+func IsSeedDeltaBaseMissingError(err error) bool {
+	var missingErr *SeedDeltaBaseMissingError
+	return errors.As(err, &missingErr)
+}
+
+// readSeedDelta returns the description of the base of the seed image mounted at the mountpoint, nil if it is a
+// full seed image
+func readSeedDelta(mountpoint string) (*SeedDelta, error) {
+	path := filepath.Join(common.PathOutsideChroot(mountpoint), SeedDeltaFile)
+	data, err := osReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed reading %s: %w", path, err)
+	}
+	delta := &SeedDelta{}
+	if err := json.Unmarshal(data, delta); err != nil {
+		return nil, fmt.Errorf("failed unmarshalling %s: %w", path, err)
+	}
+	return delta, nil
+}
+
+// mountBaseSeedImage mounts the base seed image of the delta, which must have been pulled beforehand. The booted
+// deployment can't replace it, as the var and etc batches only apply to the var and etc of the base seed
+func mountBaseSeedImage(hostOps ops.Ops, delta *SeedDelta) (string, error) {
+	exists, err := hostOps.ImageExists(delta.BaseSeedImage)
+	if err != nil {
+		return "", fmt.Errorf("failed to check for the base seed image: %w", err)
+	}
+	if !exists {
+		return "", &SeedDeltaBaseMissingError{BaseSeedImage: delta.BaseSeedImage}
+	}
+	mountpoint, err := hostOps.RunInHostNamespace("podman", "image", "mount", delta.BaseSeedImage)
+	if err != nil {
+		return "", fmt.Errorf("failed to mount base seed image: %w", err)
+	}
+	return mountpoint, nil
+}
+
+// restoreOstreeDelta sets up the ostree repo with the objects of the base seed and the new ones of the delta. The
+// base objects are already in the system repo when the base commit is deployed, e.g. when the node was upgraded
+// with the base seed image, saving the extraction of the base repo
+func restoreOstreeDelta(log logr.Logger, hostOps ops.Ops, rpmOstreeClient rpmostreeclient.IClient, extract extractFunc,
+	delta *SeedDelta, seed, base seedContent, ostreeRepo string) error {
+	status, err := rpmOstreeClient.QueryStatus()
+	if err != nil {
+		log.Info("Failed to query rpm-ostree status, extracting the base ostree repo", "error", err.Error())
+		status = &rpmostreeclient.Status{}
+	}
+
+	if lo.SomeBy(status.Deployments, func(d rpmostreeclient.Deployment) bool { return d.Checksum == delta.BaseOstreeCommit }) {
+		log.Info("Using the objects of the deployed base ostree commit", "commit", delta.BaseOstreeCommit)
+		if _, err := hostOps.RunInHostNamespace("ostree", "init", "--repo", ostreeRepo, "--mode=bare"); err != nil {
+			return fmt.Errorf("failed to init ostree repo: %w", err)
+		}
+	} else if err := extract(base.path(base.archives.Ostree), ostreeRepo); err != nil {
+		return fmt.Errorf("failed to extract base %s: %w", base.archives.Ostree, err)
+	}
+
+	if err := extract(seed.path(seed.archives.OstreeDelta), ostreeRepo); err != nil {
+		return fmt.Errorf("failed to extract %s: %w", seed.archives.OstreeDelta, err)
+	}
+	return nil
+}
+
+// restoreVarDelta extracts the base var in the stateroot and applies the changes of the delta
+func restoreVarDelta(hostOps ops.Ops, extract extractFunc, seed, base seedContent, staterootPath string) error {
+	if err := extract(base.path(base.archives.Var), staterootPath); err != nil {
+		return fmt.Errorf("failed to extract base %s: %w", base.archives.Var, err)
+	}
+	return applyBatch(hostOps, seed.path(VarDeltaBatch), staterootPath)
+}
+
+// restoreEtcDelta applies the changes of the delta to the base etc in the workspace, and copies the result to the
+// deployment as the etc archive of a full seed image would be extracted
+func restoreEtcDelta(hostOps ops.Ops, extract extractFunc, seed, base seedContent, workspace, deploymentDir string) error {
+	etcDir := filepath.Join(workspace, "etc")
+	if err := os.Mkdir(common.PathOutsideChroot(etcDir), 0o700); err != nil {
+		return fmt.Errorf("failed to create etc directory: %w", err)
+	}
+	if err := extract(base.path(base.archives.Etc), etcDir); err != nil {
+		return fmt.Errorf("failed to extract base %s: %w", base.archives.Etc, err)
+	}
+	if err := applyBatch(hostOps, seed.path(EtcDeltaBatch), etcDir); err != nil {
+		return err
+	}
+	if _, err := hostOps.RunInHostNamespace("rsync", "-aHAX", "--numeric-ids", filepath.Join(etcDir, "etc"), deploymentDir+"/"); err != nil {
+		return fmt.Errorf("failed to copy etc to the deployment: %w", err)
+	}
+	return nil
+}
+
+// applyBatch applies the rsync batch to the tree it was written against
+func applyBatch(hostOps ops.Ops, batch, dest string) error {
+	args := append(append([]string{}, RsyncBatchArgs...), "--read-batch="+batch, dest+"/")
+	if _, err := hostOps.RunInHostNamespace("rsync", args...); err != nil {
+		return fmt.Errorf("failed to apply %s: %w", filepath.Base(batch), err)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package prep
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
+	rpmostreeclient "github.com/openshift-kni/lifecycle-agent/lca-cli/ostreeclient"
+)
+
+const baseSeedImage = "quay.io/seed/sno@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+// extractions records the archives extracted, as source and destination
+type extractions [][2]string
+
+func (e *extractions) extract(src, dest string) error {
+	*e = append(*e, [2]string{src, dest})
+	return nil
+}
+
+func TestReadSeedDelta(t *testing.T) {
+	mountpoint := t.TempDir()
+
+	delta, err := readSeedDelta(mountpoint)
+	assert.NoError(t, err)
+	assert.Nil(t, delta, "a full seed image has no delta")
+
+	assert.NoError(t, os.WriteFile(filepath.Join(mountpoint, SeedDeltaFile),
+		[]byte(fmt.Sprintf(`{"baseSeedImage":%q,"baseOstreeCommit":"abc","baseSeedFormatVersion":5}`, baseSeedImage)), 0o600))
+	delta, err = readSeedDelta(mountpoint)
+	assert.NoError(t, err)
+	assert.Equal(t, &SeedDelta{BaseSeedImage: baseSeedImage, BaseOstreeCommit: "abc", BaseSeedFormatVersion: 5}, delta)
+}
+
+func TestMountBaseSeedImage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockOps := ops.NewMockOps(ctrl)
+	delta := &SeedDelta{BaseSeedImage: baseSeedImage}
+
+	mockOps.EXPECT().ImageExists(baseSeedImage).Return(false, nil)
+	_, err := mountBaseSeedImage(mockOps, delta)
+	assert.True(t, IsSeedDeltaBaseMissingError(err))
+
+	mockOps.EXPECT().ImageExists(baseSeedImage).Return(true, nil)
+	mockOps.EXPECT().RunInHostNamespace("podman", "image", "mount", baseSeedImage).Return("/base", nil)
+	mountpoint, err := mountBaseSeedImage(mockOps, delta)
+	assert.NoError(t, err)
+	assert.Equal(t, "/base", mountpoint)
+}
+
+func TestRestoreOstreeDelta(t *testing.T) {
+	testcases := []struct {
+		name              string
+		deployedCommit    string
+		baseFormatVersion int
+		wantBaseRepo      string
+	}{
+		{
+			name:              "base commit deployed",
+			deployedCommit:    "abc",
+			baseFormatVersion: 5,
+		},
+		{
+			name:              "base commit not deployed",
+			deployedCommit:    "def",
+			baseFormatVersion: 5,
+			wantBaseRepo:      "/base/ostree.tar.zst",
+		},
+		{
+			name:              "gzip base seed",
+			deployedCommit:    "def",
+			baseFormatVersion: 4,
+			wantBaseRepo:      "/base/ostree.tgz",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockOps := ops.NewMockOps(ctrl)
+			mockRpmOstreeClient := rpmostreeclient.NewMockIClient(ctrl)
+
+			mockRpmOstreeClient.EXPECT().QueryStatus().Return(&rpmostreeclient.Status{
+				Deployments: []rpmostreeclient.Deployment{{Checksum: tc.deployedCommit, Booted: true}},
+			}, nil)
+			want := extractions{{"/seed/ostree-delta.tar.zst", "/workspace/ostree"}}
+			if tc.wantBaseRepo != "" {
+				want = append(extractions{{tc.wantBaseRepo, "/workspace/ostree"}}, want...)
+			} else {
+				mockOps.EXPECT().RunInHostNamespace("ostree", "init", "--repo", "/workspace/ostree", "--mode=bare").Return("", nil)
+			}
+
+			delta := &SeedDelta{BaseSeedImage: baseSeedImage, BaseOstreeCommit: "abc", BaseSeedFormatVersion: tc.baseFormatVersion}
+			seed := seedContent{mountpoint: "/seed", archives: GetSeedArchives(5)}
+			base := seedContent{mountpoint: "/base", archives: GetSeedArchives(tc.baseFormatVersion)}
+			var got extractions
+			assert.NoError(t, restoreOstreeDelta(logr.Discard(), mockOps, mockRpmOstreeClient, got.extract, delta, seed, base,
+				"/workspace/ostree"))
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestRestoreVarDelta(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockOps := ops.NewMockOps(ctrl)
+
+	mockOps.EXPECT().RunInHostNamespace("rsync", "-aHAX", "--numeric-ids", "--delete",
+		"--read-batch=/seed/"+VarDeltaBatch, "/ostree/deploy/rhcos_4.16.0/").Return("", fmt.Errorf("failed verification"))
+	seed := seedContent{mountpoint: "/seed", archives: GetSeedArchives(5)}
+	base := seedContent{mountpoint: "/base", archives: GetSeedArchives(4)}
+	var got extractions
+	assert.ErrorContains(t, restoreVarDelta(mockOps, got.extract, seed, base, "/ostree/deploy/rhcos_4.16.0"), "failed to apply var.batch")
+	assert.Equal(t, extractions{{"/base/var.tgz", "/ostree/deploy/rhcos_4.16.0"}}, got)
+}
@@ -0,0 +1,64 @@
+package prep
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/distribution/reference"
+	"github.com/opencontainers/go-digest"
+
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
+)
+
+// ResolveImageDigest returns the digest of the manifest the image reference currently points to in the registry.
+// For a multi-arch image, this is the digest of the manifest list, which is also what podman records when pulling it
+func ResolveImageDigest(hostOps ops.Ops, image, authFile string) (string, error) {
+	output, err := hostOps.RunInHostNamespace("skopeo", "inspect", "--no-tags", "--authfile", authFile,
+		"--format", "{{.Digest}}", "docker://"+image)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve digest of image %s: %w", image, err)
+	}
+
+	d, err := digest.Parse(strings.TrimSpace(output))
+	if err != nil {
+		return "", fmt.Errorf("invalid digest for image %s: %w", image, err)
+	}
+	return d.String(), nil
+}
+
+// PinImage returns the reference to the image by digest, replacing its tag or digest if any
+func PinImage(image, imageDigest string) (string, error) {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return "", fmt.Errorf("invalid image reference %s: %w", image, err)
+	}
+	d, err := digest.Parse(imageDigest)
+	if err != nil {
+		return "", fmt.Errorf("invalid digest %s: %w", imageDigest, err)
+	}
+
+	pinned, err := reference.WithDigest(reference.TrimNamed(named), d)
+	if err != nil {
+		return "", fmt.Errorf("failed to pin image %s to digest %s: %w", image, imageDigest, err)
+	}
+	return pinned.String(), nil
+}
+
+// SeedImageDigestMismatchError is returned when the seed image tag no longer points to the digest pulled during Prep
+type SeedImageDigestMismatchError struct {
+	Image    string
+	Expected string
+	Actual   string
+}
+
+func (e *SeedImageDigestMismatchError) Error() string {
+	return fmt.Sprintf("seed image %s now points to digest %s instead of %s, which was pulled during Prep. "+
+		"Abort the upgrade and restart it from Prep to use the new image", e.Image, e.Actual, e.Expected)
+}
+
+// IsSeedImageDigestMismatchError returns true if the error, or any error it wraps, is a SeedImageDigestMismatchError
+func IsSeedImageDigestMismatchError(err error) bool {
+	var mismatchErr *SeedImageDigestMismatchError
+	return errors.As(err, &mismatchErr)
+}
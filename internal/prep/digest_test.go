@@ -0,0 +1,104 @@
+package prep
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
+)
+
+const testDigest = "sha256:2cf52f0ce9ab6e1ff4c4ee51f5b2b06a0a5b1a1a9e0d3c1f0ecb6e1a37b2e3a4"
+
+func TestPinImage(t *testing.T) {
+	testcases := []struct {
+		name    string
+		image   string
+		digest  string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "tagged image",
+			image:  "quay.io/openshift-kni/seed:4.16.0",
+			digest: testDigest,
+			want:   "quay.io/openshift-kni/seed@" + testDigest,
+		},
+		{
+			name:   "image with a registry port",
+			image:  "registry.example.com:5000/seed:4.16.0",
+			digest: testDigest,
+			want:   "registry.example.com:5000/seed@" + testDigest,
+		},
+		{
+			name:   "image already pinned to another digest",
+			image:  "quay.io/openshift-kni/seed@sha256:" + fmt.Sprintf("%064d", 0),
+			digest: testDigest,
+			want:   "quay.io/openshift-kni/seed@" + testDigest,
+		},
+		{
+			name:    "invalid digest",
+			image:   "quay.io/openshift-kni/seed:4.16.0",
+			digest:  "sha256:abc",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			pinned, err := PinImage(tc.image, tc.digest)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, pinned)
+		})
+	}
+}
+
+func TestResolveImageDigest(t *testing.T) {
+	const image = "quay.io/openshift-kni/seed:4.16.0"
+
+	testcases := []struct {
+		name    string
+		output  string
+		err     error
+		wantErr bool
+	}{
+		{
+			name:   "digest resolved",
+			output: testDigest + "\n",
+		},
+		{
+			name:    "inspect failure",
+			err:     fmt.Errorf("manifest unknown"),
+			wantErr: true,
+		},
+		{
+			name:    "invalid digest",
+			output:  "latest\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockOps := ops.NewMockOps(ctrl)
+			mockOps.EXPECT().RunInHostNamespace("skopeo", "inspect", "--no-tags", "--authfile", "/auth.json",
+				"--format", "{{.Digest}}", "docker://"+image).Return(tc.output, tc.err)
+
+			digest, err := ResolveImageDigest(mockOps, image, "/auth.json")
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, testDigest, digest)
+		})
+	}
+}
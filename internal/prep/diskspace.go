@@ -0,0 +1,180 @@
+package prep
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/samber/lo"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/seedcontent"
+)
+
+const (
+	// staterootSpaceFactor accounts for the seed content being written more than once to the sysroot while
+	// setting up the stateroot: ostree.tgz is extracted to a workspace, then pulled into the sysroot repo, and
+	// var.tgz and etc.tgz are extracted into the new deployment
+	staterootSpaceFactor = 3
+	// layerExpansionFactor is the expected ratio between the size of an image layer in the container storage
+	// and its compressed size in the registry
+	layerExpansionFactor = 2
+	// precacheInspectWorkers is the number of images inspected concurrently for the precaching disk space estimate
+	precacheInspectWorkers = 8
+	// precacheInspectImageTimeout bounds the inspection of each image to precache, e.g. on an unreachable registry
+	precacheInspectImageTimeout = time.Minute
+)
+
+// precacheInspectTimeout bounds the inspection of all the images to precache. The images not inspected by then are
+// left out of the estimate
+var precacheInspectTimeout = 5 * time.Minute
+
+// ImageLayer is a layer of a container image, as reported by skopeo inspect
+type ImageLayer struct {
+	Digest string `json:"Digest"`
+	Size   uint64 `json:"Size"`
+}
+
+// InspectImageLayers returns the layers of an image in the registry, without pulling it
+func InspectImageLayers(ops ops.Ops, image, authFile string) ([]ImageLayer, error) {
+	return inspectImageLayers(ops, image, authFile, 0)
+}
+
+// inspectImageLayers returns the layers of an image in the registry, failing after the timeout if it is set
+func inspectImageLayers(ops ops.Ops, image, authFile string, timeout time.Duration) ([]ImageLayer, error) {
+	var args []string
+	if timeout > 0 {
+		args = append(args, "--command-timeout", timeout.String())
+	}
+	args = append(args, "inspect", "--no-tags", "--authfile", authFile, "docker://"+image)
+	output, err := ops.RunInHostNamespace("skopeo", args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect image %s: %w", image, err)
+	}
+
+	var inspect struct {
+		LayersData []ImageLayer `json:"LayersData"`
+	}
+	if err := json.Unmarshal([]byte(output), &inspect); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal inspect output of image %s: %w", image, err)
+	}
+	return inspect.LayersData, nil
+}
+
+// layersSize returns the compressed size of the layers, counting layers shared between images once
+func layersSize(layers []ImageLayer) uint64 {
+	var size uint64
+	seen := make(map[string]bool)
+	for _, layer := range layers {
+		if seen[layer.Digest] {
+			continue
+		}
+		seen[layer.Digest] = true
+		size += layer.Size
+	}
+	return size
+}
+
+// InspectSeedContent returns the content held in the manifest annotations of a seed image in the registry, without
+// pulling it. It is nil for the seed images created by an older lca-cli, without the content annotations
+func InspectSeedContent(hostOps ops.Ops, seedImage, authFile string) (*seedcontent.SeedContent, error) {
+	output, err := hostOps.RunInHostNamespace("skopeo", "inspect", "--raw", "--authfile", authFile, "docker://"+seedImage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get manifest of seed image %s: %w", seedImage, err)
+	}
+	var manifest struct {
+		Annotations map[string]string `json:"annotations"`
+	}
+	if err := json.Unmarshal([]byte(output), &manifest); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal manifest of seed image %s: %w", seedImage, err)
+	}
+	content, err := seedcontent.FromAnnotations(manifest.Annotations)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read seed content annotations: %w", err)
+	}
+	return content, nil
+}
+
+// CheckPrepDiskSpace checks, before anything is pulled, that the host has room to pull the seed image, to set up the
+// new stateroot from its content and to precache the images that are not yet present on the host. The seed image is
+// inspected with the seedAuthFile, and the images to precache with the authFile
+func CheckPrepDiskSpace(log logr.Logger, hostOps ops.Ops, seedImage, seedAuthFile string, precacheImages []string, authFile string) error {
+	layers, err := InspectImageLayers(hostOps, seedImage, seedAuthFile)
+	if err != nil {
+		return err
+	}
+	// The seed image content is already compressed, so the layer takes about the same space once pulled
+	seedSize := layersSize(layers)
+	precacheSize, missing, err := estimatePrecacheSize(log, hostOps, precacheImages, authFile)
+	if err != nil {
+		return err
+	}
+	log.Info("Checking disk space for the seed image and precaching", "seedImageSize", ops.FormatBytes(seedSize),
+		"missingImages", missing, "estimatedPrecacheSize", ops.FormatBytes(precacheSize))
+
+	if err := ops.CheckDiskSpace(hostOps, map[string]uint64{
+		common.ContainerStorageDir: seedSize + precacheSize,
+		common.SysrootDir:          seedSize * staterootSpaceFactor,
+	}); err != nil {
+		return fmt.Errorf("not enough disk space for seed image %s and %d images to precache: %w", seedImage, missing, err)
+	}
+	return nil
+}
+
+// CheckPrecacheDiskSpace checks that the container storage has room for the images that are not yet present on
+// the host
+func CheckPrecacheDiskSpace(log logr.Logger, hostOps ops.Ops, images []string, authFile string) error {
+	size, missing, err := estimatePrecacheSize(log, hostOps, images, authFile)
+	if err != nil {
+		return err
+	}
+	log.Info("Checking disk space for precaching", "missingImages", missing, "estimatedSize", ops.FormatBytes(size))
+
+	if err := ops.CheckDiskSpace(hostOps, map[string]uint64{common.ContainerStorageDir: size}); err != nil {
+		return fmt.Errorf("not enough disk space to precache %d images: %w", missing, err)
+	}
+	return nil
+}
+
+// estimatePrecacheSize returns the space the images that are not yet present on the host take once pulled, and their
+// number. The missing images are inspected concurrently, each within a timeout, and those that can't be inspected
+// before precacheInspectTimeout are left out of the estimate. The precaching job reports them if they can't be pulled
+// either
+func estimatePrecacheSize(log logr.Logger, hostOps ops.Ops, images []string, authFile string) (uint64, int, error) {
+	var missing []string
+	for _, image := range images {
+		exists, err := hostOps.ImageExists(image)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to check if image %s exists: %w", image, err)
+		}
+		if !exists {
+			missing = append(missing, image)
+		}
+	}
+
+	deadline := time.Now().Add(precacheInspectTimeout)
+	imageLayers := make([][]ImageLayer, len(missing))
+	var group errgroup.Group
+	group.SetLimit(precacheInspectWorkers)
+	for i, image := range missing {
+		group.Go(func() error {
+			if time.Now().After(deadline) {
+				log.Info("Skipping image in the precaching disk space estimate", "image", image, "error", "inspect timeout expired")
+				return nil
+			}
+			layers, err := inspectImageLayers(hostOps, image, authFile, precacheInspectImageTimeout)
+			if err != nil {
+				log.Info("Skipping image in the precaching disk space estimate", "image", image, "error", err.Error())
+				return nil
+			}
+			imageLayers[i] = layers
+			return nil
+		})
+	}
+	_ = group.Wait()
+
+	return layersSize(lo.Flatten(imageLayers)) * layerExpansionFactor, len(missing), nil
+}
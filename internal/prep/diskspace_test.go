@@ -0,0 +1,169 @@
+package prep
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
+)
+
+func TestCheckPrecacheDiskSpace(t *testing.T) {
+	const mib = 1024 * 1024
+
+	// The ubi and ubi-minimal images share their base layer
+	inspectOutput := map[string]string{
+		"quay.io/app/ubi:1":         `{"LayersData":[{"Digest":"sha256:base","Size":104857600},{"Digest":"sha256:ubi","Size":52428800}]}`,
+		"quay.io/app/ubi-minimal:1": `{"LayersData":[{"Digest":"sha256:base","Size":104857600},{"Digest":"sha256:minimal","Size":10485760}]}`,
+	}
+
+	testcases := []struct {
+		name      string
+		images    []string
+		present   []string
+		available uint64
+		// expired makes the inspect timeout expire before the images are inspected
+		expired bool
+		wantErr bool
+	}{
+		{
+			name:      "shared layers are counted once",
+			images:    []string{"quay.io/app/ubi:1", "quay.io/app/ubi-minimal:1"},
+			available: 330 * mib,
+		},
+		{
+			name:      "not enough space",
+			images:    []string{"quay.io/app/ubi:1", "quay.io/app/ubi-minimal:1"},
+			available: 300 * mib,
+			wantErr:   true,
+		},
+		{
+			name:    "images already present are skipped",
+			images:  []string{"quay.io/app/ubi:1", "quay.io/app/ubi-minimal:1"},
+			present: []string{"quay.io/app/ubi:1"},
+			// 2 * (100 + 10) MiB
+			available: 220 * mib,
+		},
+		{
+			name:      "images that can't be inspected are skipped",
+			images:    []string{"quay.io/app/ubi:1", "quay.io/app/unknown:1"},
+			available: 300 * mib,
+		},
+		{
+			name:      "images are skipped once the inspect timeout expires",
+			images:    []string{"quay.io/app/ubi:1", "quay.io/app/ubi-minimal:1"},
+			available: 0,
+			expired:   true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockOps := ops.NewMockOps(ctrl)
+
+			for _, image := range tc.images {
+				present := false
+				for _, p := range tc.present {
+					present = present || p == image
+				}
+				mockOps.EXPECT().ImageExists(image).Return(present, nil)
+				if present || tc.expired {
+					continue
+				}
+				output, ok := inspectOutput[image]
+				var err error
+				if !ok {
+					err = fmt.Errorf("manifest unknown")
+				}
+				mockOps.EXPECT().RunInHostNamespace("skopeo", "--command-timeout", "1m0s", "inspect", "--no-tags",
+					"--authfile", common.ImageRegistryAuthFile, "docker://"+image).Return(output, err)
+			}
+			mockOps.EXPECT().GetDiskSpace(common.ContainerStorageDir).Return(&ops.DiskSpace{MountPoint: "/sysroot", Available: tc.available}, nil)
+
+			if tc.expired {
+				defer func(timeout time.Duration) { precacheInspectTimeout = timeout }(precacheInspectTimeout)
+				precacheInspectTimeout = -time.Second
+			}
+			err := CheckPrecacheDiskSpace(logr.Discard(), mockOps, tc.images, common.ImageRegistryAuthFile)
+			if tc.wantErr {
+				assert.True(t, ops.IsInsufficientDiskSpaceError(err))
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCheckPrepDiskSpace(t *testing.T) {
+	const (
+		mib       = 1024 * 1024
+		seedImage = "quay.io/example/seed:4.16.1"
+	)
+
+	testcases := []struct {
+		name      string
+		available uint64
+		wantErr   bool
+	}{
+		{
+			// 1 GiB for the seed image, 3 GiB for the stateroot and 2 * 150 MiB for the images to precache
+			name:      "enough space for the seed image and precaching",
+			available: 4396 * mib,
+		},
+		{
+			name:      "not enough space once the images to precache are counted",
+			available: 4200 * mib,
+			wantErr:   true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockOps := ops.NewMockOps(ctrl)
+
+			mockOps.EXPECT().RunInHostNamespace("skopeo", "inspect", "--no-tags", "--authfile", "/seed-auth.json",
+				"docker://"+seedImage).Return(`{"LayersData":[{"Digest":"sha256:seed","Size":1073741824}]}`, nil)
+			mockOps.EXPECT().ImageExists("quay.io/app/ubi:1").Return(false, nil)
+			mockOps.EXPECT().RunInHostNamespace("skopeo", "--command-timeout", "1m0s", "inspect", "--no-tags",
+				"--authfile", common.ImageRegistryAuthFile, "docker://quay.io/app/ubi:1").Return(
+				`{"LayersData":[{"Digest":"sha256:base","Size":104857600},{"Digest":"sha256:ubi","Size":52428800}]}`, nil)
+			mockOps.EXPECT().GetDiskSpace(common.ContainerStorageDir).Return(&ops.DiskSpace{MountPoint: "/sysroot", Available: tc.available}, nil)
+			mockOps.EXPECT().GetDiskSpace(common.SysrootDir).Return(&ops.DiskSpace{MountPoint: "/sysroot", Available: tc.available}, nil)
+
+			err := CheckPrepDiskSpace(logr.Discard(), mockOps, seedImage, "/seed-auth.json", []string{"quay.io/app/ubi:1"},
+				common.ImageRegistryAuthFile)
+			if tc.wantErr {
+				assert.True(t, ops.IsInsufficientDiskSpaceError(err))
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestInspectSeedContent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockOps := ops.NewMockOps(ctrl)
+
+	mockOps.EXPECT().RunInHostNamespace("skopeo", "inspect", "--raw", "--authfile", "/seed-auth.json", "docker://quay.io/example/seed:4.16.1").Return(
+		`{"annotations":{"com.openshift.lifecycle-agent.seed.release_image":"quay.io/openshift-release-dev/ocp-release:4.16.1",`+
+			`"com.openshift.lifecycle-agent.seed.images":"[\"quay.io/app/ubi:1\"]"}}`, nil)
+	content, err := InspectSeedContent(mockOps, "quay.io/example/seed:4.16.1", "/seed-auth.json")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"quay.io/app/ubi:1"}, content.Images)
+
+	// Seed images created by an older lca-cli have no content annotations
+	mockOps.EXPECT().RunInHostNamespace("skopeo", "inspect", "--raw", "--authfile", "/seed-auth.json", "docker://quay.io/example/seed:4.15.0").Return(
+		`{"layers":[]}`, nil)
+	content, err = InspectSeedContent(mockOps, "quay.io/example/seed:4.15.0", "/seed-auth.json")
+	assert.NoError(t, err)
+	assert.Nil(t, content)
+}
@@ -0,0 +1,44 @@
+package prep
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/openshift-kni/lifecycle-agent/internal/archive"
+	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
+)
+
+// extractReportInterval is how often the progress of the extraction of the seed archives is reported
+var extractReportInterval = 10 * time.Second
+
+// extractFunc extracts the archive of the seed image in the dest directory, both paths being on the host
+type extractFunc func(src, dest string) error
+
+// seedExtractor extracts the archives of the seed image, counting the files and bytes extracted
+type seedExtractor struct {
+	progress archive.Progress
+}
+
+// extractFunc returns the function extracting the archives until the context is canceled
+func (e *seedExtractor) extractFunc(ctx context.Context) extractFunc {
+	return func(src, dest string) error {
+		if err := archive.Extract(ctx, common.PathOutsideChroot(src), common.PathOutsideChroot(dest), &e.progress); err != nil {
+			return fmt.Errorf("failed to extract %s: %w", filepath.Base(src), err)
+		}
+		return nil
+	}
+}
+
+// reportProgress reports the progress of the extraction until the context is done
+func (e *seedExtractor) reportProgress(ctx context.Context, report func(string)) {
+	archive.ReportProgress(ctx, &e.progress, extractReportInterval, func(_, _ int64) {
+		report(e.summary())
+	})
+}
+
+func (e *seedExtractor) summary() string {
+	return fmt.Sprintf("extracted %d files, %s", e.progress.Files(), ops.FormatBytes(uint64(e.progress.Bytes())))
+}
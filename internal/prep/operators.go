@@ -0,0 +1,69 @@
+package prep
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/samber/lo"
+
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/seedcontent"
+)
+
+// OperatorIncompatibilityError is returned when the operators of the cluster don't match those of the seed image
+type OperatorIncompatibilityError struct {
+	Incompatibilities []string
+}
+
+func (e *OperatorIncompatibilityError) Error() string {
+	return fmt.Sprintf("the seed image operators are incompatible with the cluster: %s", strings.Join(e.Incompatibilities, "; "))
+}
+
+// IsOperatorIncompatibilityError returns true if the error, or any error it wraps, is an OperatorIncompatibilityError
+func IsOperatorIncompatibilityError(err error) bool {
+	var incompatibilityErr *OperatorIncompatibilityError
+	return errors.As(err, &incompatibilityErr)
+}
+
+// CheckOperatorCompatibility returns the operators of the cluster that would not be the same after the pivot to the
+// seed image: the operators missing from the seed image, those installed in an older version, and those subscribed
+// to another channel. The operators of the seed image that are not installed in the cluster are fine
+func CheckOperatorCompatibility(cluster, seed []seedcontent.Operator) []string {
+	seedOperators := lo.KeyBy(seed, func(operator seedcontent.Operator) string {
+		return operator.Namespace + "/" + operator.PackageName()
+	})
+
+	var incompatibilities []string
+	for _, operator := range cluster {
+		name := operator.Namespace + "/" + operator.PackageName()
+		seedOperator, ok := seedOperators[name]
+		if !ok {
+			incompatibilities = append(incompatibilities, fmt.Sprintf("operator %s is missing from the seed image", name))
+			continue
+		}
+		if isDowngrade(operator.Version, seedOperator.Version) {
+			incompatibilities = append(incompatibilities, fmt.Sprintf("operator %s would be downgraded from %s to %s",
+				name, operator.Version, seedOperator.Version))
+		}
+		if operator.Channel != seedOperator.Channel {
+			incompatibilities = append(incompatibilities, fmt.Sprintf("operator %s is subscribed to channel %s, %s in the seed image",
+				name, operator.Channel, seedOperator.Channel))
+		}
+	}
+	return incompatibilities
+}
+
+// isDowngrade returns true if the seed version is lower than the cluster version. Versions that aren't semantic
+// versions are not compared
+func isDowngrade(clusterVersion, seedVersion string) bool {
+	clusterVer, err := semver.NewVersion(clusterVersion)
+	if err != nil {
+		return false
+	}
+	seedVer, err := semver.NewVersion(seedVersion)
+	if err != nil {
+		return false
+	}
+	return seedVer.LessThan(*clusterVer)
+}
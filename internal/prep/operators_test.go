@@ -0,0 +1,74 @@
+package prep
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/seedcontent"
+)
+
+func TestCheckOperatorCompatibility(t *testing.T) {
+	lca := seedcontent.Operator{Name: "lifecycle-agent.v4.16.1", Namespace: "openshift-lifecycle-agent", Version: "4.16.1",
+		Package: "lifecycle-agent", Channel: "stable"}
+	sriov := seedcontent.Operator{Name: "sriov-network-operator.v4.16.0", Namespace: "openshift-sriov-network-operator",
+		Version: "4.16.0", Package: "sriov-network-operator", Channel: "stable"}
+
+	withVersion := func(operator seedcontent.Operator, version string) seedcontent.Operator {
+		operator.Name = fmt.Sprintf("%s.v%s", operator.Package, version)
+		operator.Version = version
+		return operator
+	}
+	withChannel := func(operator seedcontent.Operator, channel string) seedcontent.Operator {
+		operator.Channel = channel
+		return operator
+	}
+
+	testcases := []struct {
+		name    string
+		cluster []seedcontent.Operator
+		seed    []seedcontent.Operator
+		want    []string
+	}{
+		{
+			name:    "same operators",
+			cluster: []seedcontent.Operator{lca, sriov},
+			seed:    []seedcontent.Operator{lca, sriov},
+		},
+		{
+			name:    "upgraded operator and additional seed operator",
+			cluster: []seedcontent.Operator{withVersion(lca, "4.16.0")},
+			seed:    []seedcontent.Operator{lca, sriov},
+		},
+		{
+			name:    "missing operator",
+			cluster: []seedcontent.Operator{lca, sriov},
+			seed:    []seedcontent.Operator{lca},
+			want:    []string{"operator openshift-sriov-network-operator/sriov-network-operator is missing from the seed image"},
+		},
+		{
+			name:    "downgraded operator",
+			cluster: []seedcontent.Operator{withVersion(lca, "4.16.2")},
+			seed:    []seedcontent.Operator{lca},
+			want:    []string{"operator openshift-lifecycle-agent/lifecycle-agent would be downgraded from 4.16.2 to 4.16.1"},
+		},
+		{
+			name:    "different channel",
+			cluster: []seedcontent.Operator{withChannel(sriov, "4.16")},
+			seed:    []seedcontent.Operator{sriov},
+			want:    []string{"operator openshift-sriov-network-operator/sriov-network-operator is subscribed to channel 4.16, stable in the seed image"},
+		},
+		{
+			name:    "operator without subscription",
+			cluster: []seedcontent.Operator{{Name: "ptp-operator.v4.16.0", Namespace: "openshift-ptp", Version: "4.16.0"}},
+			seed:    []seedcontent.Operator{{Name: "ptp-operator.v4.16.1", Namespace: "openshift-ptp", Version: "4.16.1"}},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, CheckOperatorCompatibility(tc.cluster, tc.seed))
+		})
+	}
+}
@@ -2,6 +2,7 @@ package prep
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/go-logr/logr"
 	mcfgv1 "github.com/openshift/api/machineconfiguration/v1"
+	"golang.org/x/sync/errgroup"
 
 	"github.com/openshift-kni/lifecycle-agent/internal/common"
 	"github.com/openshift-kni/lifecycle-agent/internal/ostreeclient"
@@ -112,8 +114,11 @@ func getDeploymentFromDeploymentID(deploymentID string) (string, error) {
 	return splitted[len(splitted)-1], nil
 }
 
-func SetupStateroot(log logr.Logger, ops ops.Ops, ostreeClient ostreeclient.IClient,
-	rpmOstreeClient rpmostreeclient.IClient, seedImage, expectedVersion, imageListFile string, ibi bool) error {
+// SetupStateroot creates the new stateroot from the seed image. The seed archives are extracted until the context is
+// canceled, and the progress of the extraction is reported periodically
+func SetupStateroot(ctx context.Context, log logr.Logger, ops ops.Ops, ostreeClient ostreeclient.IClient,
+	rpmOstreeClient rpmostreeclient.IClient, seedImage, expectedVersion, imageListFile string, ibi bool,
+	report func(progress string)) error {
 	log.Info("Start setupstateroot")
 
 	defer ops.UnmountAndRemoveImage(seedImage)
@@ -142,20 +147,54 @@ func SetupStateroot(log logr.Logger, ops ops.Ops, ostreeClient ostreeclient.ICli
 
 	}
 
+	// The files of the seed image are at the root of the mounted image, whether it has a single layer, as in seed
+	// format 3, or a layer per part of the seed
 	mountpoint, err := ops.RunInHostNamespace("podman", "image", "mount", seedImage)
 	if err != nil {
 		return fmt.Errorf("failed to mount seed image: %w", err)
 	}
 
+	formatVersion, err := GetSeedFormatVersion(ops, seedImage)
+	if err != nil {
+		return err
+	}
+	seed := seedContent{mountpoint: mountpoint, archives: GetSeedArchives(formatVersion)}
+
+	// A delta seed image only holds the changes from its base seed image, which must be present on the node
+	delta, err := readSeedDelta(mountpoint)
+	if err != nil {
+		return fmt.Errorf("failed to read seed delta: %w", err)
+	}
+	var base seedContent
+	if delta != nil {
+		log.Info("Seed image is a delta", "base", delta.BaseSeedImage)
+		if base.mountpoint, err = mountBaseSeedImage(ops, delta); err != nil {
+			return err
+		}
+		defer func() {
+			if _, err := ops.RunInHostNamespace("podman", "image", "umount", delta.BaseSeedImage); err != nil {
+				log.Error(err, "failed to unmount base seed image")
+			}
+		}()
+		base.archives = GetSeedArchives(delta.BaseSeedFormatVersion)
+	}
+
 	ostreeRepo := filepath.Join(workspace, "ostree")
 	if err = os.Mkdir(common.PathOutsideChroot(ostreeRepo), 0o700); err != nil {
 		return fmt.Errorf("failed to create ostree repo directory: %w", err)
 	}
 
-	if err := ops.ExtractTarWithSELinux(
-		fmt.Sprintf("%s/ostree.tgz", mountpoint), ostreeRepo,
-	); err != nil {
-		return fmt.Errorf("failed to extract ostree.tgz: %w", err)
+	extractor := &seedExtractor{}
+	reportCtx, stopReport := context.WithCancel(ctx)
+	defer stopReport()
+	go extractor.reportProgress(reportCtx, report)
+
+	if delta != nil {
+		if err := restoreOstreeDelta(log, ops, rpmOstreeClient, extractor.extractFunc(ctx), delta, seed, base, ostreeRepo); err != nil {
+			return err
+		}
+	} else if err := extractor.extractFunc(ctx)(seed.path(seed.archives.Ostree), ostreeRepo); err != nil {
+		return err
 	}
 
 	// example:
@@ -184,7 +223,12 @@ func SetupStateroot(log logr.Logger, ops ops.Ops, ostreeClient ostreeclient.ICli
 
 	osname := common.GetStaterootName(expectedVersion)
 
-	if err = ostreeClient.PullLocal(ostreeRepo); err != nil {
+	// The repo of a delta seed image may not hold all the objects of the other refs of the base seed
+	var refs []string
+	if delta != nil {
+		refs = []string{seedBootedRef}
+	}
+	if err = ostreeClient.PullLocal(ostreeRepo, refs...); err != nil {
 		return fmt.Errorf("failed ostree pull-local: %w", err)
 	}
 
@@ -213,19 +257,27 @@ func SetupStateroot(log logr.Logger, ops ops.Ops, ostreeClient ostreeclient.ICli
 		return fmt.Errorf("failed to restore origin file: %w", err)
 	}
 
-	if err = ops.ExtractTarWithSELinux(
-		filepath.Join(mountpoint, "var.tgz"),
-		common.GetStaterootPath(osname),
-	); err != nil {
-		return fmt.Errorf("failed to restore var directory: %w", err)
-	}
-
-	if err := ops.ExtractTarWithSELinux(
-		filepath.Join(mountpoint, "etc.tgz"),
-		deploymentDir,
-	); err != nil {
-		return fmt.Errorf("failed to extract seed etc: %w", err)
+	// var and etc are extracted in the stateroot and the deployment concurrently, the first failure cancels the other
+	group, groupCtx := errgroup.WithContext(ctx)
+	extract := extractor.extractFunc(groupCtx)
+	staterootPath := common.GetStaterootPath(osname)
+	group.Go(func() error {
+		if delta != nil {
+			return restoreVarDelta(ops, extract, seed, base, staterootPath)
+		}
+		return extract(seed.path(seed.archives.Var), staterootPath)
+	})
+	group.Go(func() error {
+		if delta != nil {
+			return restoreEtcDelta(ops, extract, seed, base, workspace, deploymentDir)
+		}
+		return extract(seed.path(seed.archives.Etc), deploymentDir)
+	})
+	if err := group.Wait(); err != nil {
+		return fmt.Errorf("failed to restore var and etc: %w", err)
 	}
+	stopReport()
+	report(extractor.summary())
 
 	if err = removeETCDeletions(mountpoint, deploymentDir); err != nil {
 		return fmt.Errorf("failed to process etc.deletions: %w", err)
@@ -245,11 +297,15 @@ func ReadPrecachingList(imageListFile, clusterRegistry, seedRegistry string, ove
 		return
 	}
 
-	lines := strings.Split(string(content), "\n")
-	// Filter out empty lines
-	for _, line := range lines {
-		image := line
-		if line == "" {
+	return BuildPrecachingList(strings.Split(string(content), "\n"), clusterRegistry, seedRegistry, overrideSeedRegistry)
+}
+
+// BuildPrecachingList filters out empty entries from the given image list and, if requested, replaces the seed
+// registry with the cluster registry in each of the remaining images
+func BuildPrecachingList(images []string, clusterRegistry, seedRegistry string, overrideSeedRegistry bool) (imageList []string, err error) {
+	for _, line := range images {
+		image := strings.TrimSpace(line)
+		if image == "" {
 			continue
 		}
 		if overrideSeedRegistry {
@@ -0,0 +1,66 @@
+package prep
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
+)
+
+// SeedArchives are the names of the archives of a seed image
+type SeedArchives struct {
+	Ostree      string
+	OstreeDelta string
+	Var         string
+	Etc         string
+}
+
+// GetSeedArchives returns the names of the archives of a seed image of the format version, gzip archives before
+// the zstd format
+func GetSeedArchives(formatVersion int) SeedArchives {
+	ext := ".tgz"
+	if formatVersion >= common.ZstdSeedFormatVersion {
+		ext = ".tar.zst"
+	}
+	return SeedArchives{
+		Ostree:      "ostree" + ext,
+		OstreeDelta: "ostree-delta" + ext,
+		Var:         "var" + ext,
+		Etc:         "etc" + ext,
+	}
+}
+
+// GetSeedFormatVersion returns the format version in the labels of the seed image, which must be present locally
+func GetSeedFormatVersion(hostOps ops.Ops, seedImage string) (int, error) {
+	output, err := hostOps.RunInHostNamespace("podman", "image", "inspect", "--format",
+		fmt.Sprintf("{{index .Labels %q}}", common.SeedFormatOCILabel), seedImage)
+	if err != nil {
+		return 0, fmt.Errorf("failed to inspect seed image %s: %w", seedImage, err)
+	}
+	version, err := strconv.Atoi(strings.TrimSpace(output))
+	if err != nil {
+		return 0, fmt.Errorf("invalid seed format version of seed image %s: %w", seedImage, err)
+	}
+	return version, nil
+}
+
+// CheckSeedFormatVersion checks that the seed format version in the labels of a seed image is one this version of
+// the lifecycle-agent supports. The format version is set by the lca-cli when the image is built, and is bumped by
+// developers when the image format changes in a way that is incompatible with previous versions
+func CheckSeedFormatVersion(seedImageRef string, labels map[string]string) error {
+	value, ok := labels[common.SeedFormatOCILabel]
+	if !ok {
+		return fmt.Errorf(
+			"seed image %s is missing the %s label, please build a new image using the latest version of the lca-cli",
+			seedImageRef, common.SeedFormatOCILabel)
+	}
+
+	version, err := strconv.Atoi(value)
+	if err != nil || version < common.MinSeedFormatVersion || version > common.SeedFormatVersion {
+		return fmt.Errorf("seed image format version mismatch: expected %d to %d, got %s",
+			common.MinSeedFormatVersion, common.SeedFormatVersion, value)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package prep
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
+)
+
+func TestCheckSeedFormatVersion(t *testing.T) {
+	testcases := []struct {
+		name    string
+		labels  map[string]string
+		wantErr string
+	}{
+		{
+			name:   "current format",
+			labels: map[string]string{common.SeedFormatOCILabel: "5"},
+		},
+		{
+			name:   "gzip format",
+			labels: map[string]string{common.SeedFormatOCILabel: "4"},
+		},
+		{
+			name:   "single layer format",
+			labels: map[string]string{common.SeedFormatOCILabel: "3"},
+		},
+		{
+			name:    "older format",
+			labels:  map[string]string{common.SeedFormatOCILabel: "2"},
+			wantErr: "expected 3 to 5, got 2",
+		},
+		{
+			name:    "newer format",
+			labels:  map[string]string{common.SeedFormatOCILabel: "6"},
+			wantErr: "expected 3 to 5, got 6",
+		},
+		{
+			name:    "invalid format",
+			labels:  map[string]string{common.SeedFormatOCILabel: "four"},
+			wantErr: "got four",
+		},
+		{
+			name:    "missing label",
+			labels:  map[string]string{},
+			wantErr: "missing the " + common.SeedFormatOCILabel + " label",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := CheckSeedFormatVersion("quay.io/seed/sno:4.16.0", tc.labels)
+			if tc.wantErr != "" {
+				assert.ErrorContains(t, err, tc.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestGetSeedFormatVersion(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	mockOps := ops.NewMockOps(ctrl)
+	seedImage := "quay.io/seed/sno:4.16.0"
+	inspectArgs := []any{"image", "inspect", "--format", `{{index .Labels "` + common.SeedFormatOCILabel + `"}}`, seedImage}
+
+	mockOps.EXPECT().RunInHostNamespace("podman", inspectArgs...).Return("5", nil)
+	version, err := GetSeedFormatVersion(mockOps, seedImage)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, version)
+	assert.Equal(t, SeedArchives{Ostree: "ostree.tar.zst", OstreeDelta: "ostree-delta.tar.zst", Var: "var.tar.zst",
+		Etc: "etc.tar.zst"}, GetSeedArchives(version))
+	assert.Equal(t, "var.tgz", GetSeedArchives(4).Var)
+
+	mockOps.EXPECT().RunInHostNamespace("podman", inspectArgs...).Return("<no value>", nil)
+	_, err = GetSeedFormatVersion(mockOps, seedImage)
+	assert.ErrorContains(t, err, "invalid seed format version")
+}
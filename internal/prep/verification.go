@@ -0,0 +1,47 @@
+package prep
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/openshift-kni/lifecycle-agent/internal/sigstore"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
+)
+
+// SeedImageVerificationError is returned when the seed image is rejected by the signature verification
+type SeedImageVerificationError struct {
+	Image string
+	Err   error
+}
+
+func (e *SeedImageVerificationError) Error() string {
+	return fmt.Sprintf("seed image %s failed signature verification: %s", e.Image, e.Err)
+}
+
+func (e *SeedImageVerificationError) Unwrap() error {
+	return e.Err
+}
+
+// IsSeedImageVerificationError returns true if the error, or any error it wraps, is a SeedImageVerificationError
+func IsSeedImageVerificationError(err error) bool {
+	var verificationErr *SeedImageVerificationError
+	return errors.As(err, &verificationErr)
+}
+
+// PullVerifiedImage pulls the image into the container storage, provided its signature is accepted by the policy.
+// The registries.d directory is optional and replaces the host configuration when set
+func PullVerifiedImage(executor ops.Execute, image, authFile, policyPath, registriesDir string) error {
+	args := []string{"copy", "--authfile", authFile, "--policy", policyPath}
+	if registriesDir != "" {
+		args = append(args, "--registries.d", registriesDir)
+	}
+	args = append(args, "docker://"+image, "containers-storage:"+image)
+
+	if _, err := executor.Execute("skopeo", args...); err != nil {
+		if sigstore.IsPolicyRejection(err) {
+			return &SeedImageVerificationError{Image: image, Err: err}
+		}
+		return fmt.Errorf("failed to pull image %s: %w", image, err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package prep
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
+)
+
+func TestPullVerifiedImage(t *testing.T) {
+	const image = "quay.io/openshift-kni/seed:4.16.0"
+
+	testcases := []struct {
+		name             string
+		registriesDir    string
+		expectedArgs     []string
+		executeErr       error
+		wantVerification bool
+		wantErr          bool
+	}{
+		{
+			name: "policy only",
+			expectedArgs: []string{"copy", "--authfile", "/auth.json", "--policy", "/policy.json",
+				"docker://" + image, "containers-storage:" + image},
+		},
+		{
+			name:          "cosign key",
+			registriesDir: "/registries.d",
+			expectedArgs: []string{"copy", "--authfile", "/auth.json", "--policy", "/policy.json", "--registries.d", "/registries.d",
+				"docker://" + image, "containers-storage:" + image},
+		},
+		{
+			name: "signature rejected",
+			expectedArgs: []string{"copy", "--authfile", "/auth.json", "--policy", "/policy.json",
+				"docker://" + image, "containers-storage:" + image},
+			executeErr:       fmt.Errorf("exit status 1: Source image rejected: A signature was required, but no signature exists"),
+			wantVerification: true,
+			wantErr:          true,
+		},
+		{
+			name: "pull failure",
+			expectedArgs: []string{"copy", "--authfile", "/auth.json", "--policy", "/policy.json",
+				"docker://" + image, "containers-storage:" + image},
+			executeErr: fmt.Errorf("exit status 1: manifest unknown"),
+			wantErr:    true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockExecutor := ops.NewMockExecute(ctrl)
+			args := make([]any, len(tc.expectedArgs))
+			for i, arg := range tc.expectedArgs {
+				args[i] = arg
+			}
+			mockExecutor.EXPECT().Execute("skopeo", args...).Return("", tc.executeErr)
+
+			err := PullVerifiedImage(mockExecutor, image, "/auth.json", "/policy.json", tc.registriesDir)
+			if !tc.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Error(t, err)
+			assert.Equal(t, tc.wantVerification, IsSeedImageVerificationError(err))
+		})
+	}
+}
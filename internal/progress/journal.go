@@ -0,0 +1,161 @@
+package progress
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	lcav1alpha1 "github.com/openshift-kni/lifecycle-agent/api/v1alpha1"
+	"github.com/openshift-kni/lifecycle-agent/controllers/utils"
+	lcautils "github.com/openshift-kni/lifecycle-agent/utils"
+)
+
+// JournalFile is where the post-pivot configuration records the progress of its steps. It is in the IBU workspace,
+// so that it is removed when the upgrade is finalized
+var JournalFile = filepath.Join(utils.IBUWorkspacePath, "post_pivot_progress.json")
+
+// StepResults defines the string values for the result of a step
+var StepResults = struct {
+	InProgress string
+	Completed  string
+	Failed     string
+}{
+	InProgress: "InProgress",
+	Completed:  "Completed",
+	Failed:     "Failed",
+}
+
+// messagePrefix starts the summary of the steps in the message of the Upgrade stage
+const messagePrefix = "Post-pivot configuration: "
+
+// Journal records the steps of the post-pivot configuration in a file, for the LCA Operator to report them once the
+// cluster is up. The journal is best effort: failing to write it is logged and doesn't fail the step
+type Journal struct {
+	filename string
+	log      *logrus.Logger
+	steps    []lcav1alpha1.PostPivotStep
+}
+
+// NewJournal returns the journal saved in the file. The steps already recorded are kept, as the post-pivot
+// configuration is run again if it is interrupted
+func NewJournal(filename string, log *logrus.Logger) *Journal {
+	steps, err := Read(filename)
+	if err != nil {
+		log.Warnf("Starting a new post-pivot progress journal: %v", err)
+	}
+	return &Journal{filename: filename, log: log, steps: steps}
+}
+
+// Run records the start of the step, runs it and records its result
+func (j *Journal) Run(name string, step func() error) error {
+	j.start(name)
+	err := step()
+	j.complete(name, err)
+	return err
+}
+
+func (j *Journal) start(name string) {
+	step := lcav1alpha1.PostPivotStep{Name: name, StartTime: metav1.Now(), Result: StepResults.InProgress}
+	if i := j.index(name); i >= 0 {
+		j.steps[i] = step
+	} else {
+		j.steps = append(j.steps, step)
+	}
+	j.save()
+}
+
+func (j *Journal) complete(name string, err error) {
+	i := j.index(name)
+	if i < 0 {
+		return
+	}
+	now := metav1.Now()
+	j.steps[i].CompletionTime = &now
+	j.steps[i].Result = StepResults.Completed
+	if err != nil {
+		j.steps[i].Result = StepResults.Failed
+		j.steps[i].Error = err.Error()
+	}
+	j.save()
+}
+
+func (j *Journal) index(name string) int {
+	for i := range j.steps {
+		if j.steps[i].Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (j *Journal) save() {
+	if err := os.MkdirAll(filepath.Dir(j.filename), 0o700); err != nil {
+		j.log.Warnf("Failed to create the post-pivot progress journal dir: %v", err)
+		return
+	}
+	if err := lcautils.MarshalToFile(j.steps, j.filename); err != nil {
+		j.log.Warnf("Failed to save the post-pivot progress journal: %v", err)
+	}
+}
+
+// Read returns the steps recorded in the journal file, none if it doesn't exist
+func Read(filename string) ([]lcav1alpha1.PostPivotStep, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read post-pivot progress journal: %w", err)
+	}
+	var steps []lcav1alpha1.PostPivotStep
+	if err := json.Unmarshal(data, &steps); err != nil {
+		return nil, fmt.Errorf("failed to decode post-pivot progress journal: %w", err)
+	}
+	return steps, nil
+}
+
+// Merge sets the steps in the IBU status. Unless the Upgrade stage completed, it also adds their summary to the
+// message of the Upgrade stage, replacing the one added by a previous merge
+func Merge(ibu *lcav1alpha1.ImageBasedUpgrade, steps []lcav1alpha1.PostPivotStep) {
+	if len(steps) == 0 {
+		return
+	}
+	ibu.Status.PostPivotSteps = steps
+
+	condition := utils.GetInProgressCondition(ibu, lcav1alpha1.Stages.Upgrade)
+	if condition == nil || utils.IsStageCompleted(ibu, lcav1alpha1.Stages.Upgrade) {
+		return
+	}
+	message, _, _ := strings.Cut(condition.Message, "; "+messagePrefix)
+	if message == "" || strings.HasPrefix(message, messagePrefix) {
+		condition.Message = Summarize(steps)
+	} else {
+		condition.Message = message + "; " + Summarize(steps)
+	}
+}
+
+// Summarize returns the failed or running step, or how long the steps took once they all completed
+func Summarize(steps []lcav1alpha1.PostPivotStep) string {
+	completed := 0
+	for _, step := range steps {
+		switch step.Result {
+		case StepResults.Failed:
+			return fmt.Sprintf("%sstep %s failed: %s", messagePrefix, step.Name, step.Error)
+		case StepResults.InProgress:
+			return fmt.Sprintf("%sstep %s in progress, %d steps completed", messagePrefix, step.Name, completed)
+		}
+		completed++
+	}
+
+	summary := fmt.Sprintf("%s%d steps completed", messagePrefix, completed)
+	if last := steps[len(steps)-1].CompletionTime; last != nil {
+		summary += fmt.Sprintf(" in %s", last.Sub(steps[0].StartTime.Time).Round(time.Second))
+	}
+	return summary
+}
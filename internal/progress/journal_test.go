@@ -0,0 +1,142 @@
+package progress
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	lcav1alpha1 "github.com/openshift-kni/lifecycle-agent/api/v1alpha1"
+	"github.com/openshift-kni/lifecycle-agent/controllers/utils"
+)
+
+func TestJournal(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "workspace", "progress.json")
+
+	steps, err := Read(filename)
+	assert.NoError(t, err)
+	assert.Nil(t, steps)
+
+	journal := NewJournal(filename, logrus.New())
+	assert.NoError(t, journal.Run("Recert", func() error { return nil }))
+	assert.Error(t, journal.Run("ApplyManifests", func() error {
+		steps, err := Read(filename)
+		assert.NoError(t, err)
+		assert.Equal(t, StepResults.InProgress, steps[1].Result)
+		return errors.New("boom")
+	}))
+
+	steps, err = Read(filename)
+	assert.NoError(t, err)
+	assert.Len(t, steps, 2)
+	assert.Equal(t, StepResults.Completed, steps[0].Result)
+	assert.NotNil(t, steps[0].CompletionTime)
+	assert.Equal(t, StepResults.Failed, steps[1].Result)
+	assert.Equal(t, "boom", steps[1].Error)
+
+	// A rerun keeps the recorded steps and replaces the ones run again
+	journal = NewJournal(filename, logrus.New())
+	assert.NoError(t, journal.Run("ApplyManifests", func() error { return nil }))
+	steps, err = Read(filename)
+	assert.NoError(t, err)
+	assert.Len(t, steps, 2)
+	assert.Equal(t, StepResults.Completed, steps[1].Result)
+	assert.Empty(t, steps[1].Error)
+}
+
+func TestSummarize(t *testing.T) {
+	start := metav1.NewTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	end := metav1.NewTime(start.Add(90 * time.Second))
+
+	testcases := []struct {
+		name  string
+		steps []lcav1alpha1.PostPivotStep
+		want  string
+	}{
+		{
+			name: "completed",
+			steps: []lcav1alpha1.PostPivotStep{
+				{Name: "Recert", StartTime: start, CompletionTime: &start, Result: StepResults.Completed},
+				{Name: "ApplyManifests", StartTime: start, CompletionTime: &end, Result: StepResults.Completed},
+			},
+			want: "Post-pivot configuration: 2 steps completed in 1m30s",
+		},
+		{
+			name: "in progress",
+			steps: []lcav1alpha1.PostPivotStep{
+				{Name: "Recert", StartTime: start, CompletionTime: &start, Result: StepResults.Completed},
+				{Name: "ApplyManifests", StartTime: start, Result: StepResults.InProgress},
+			},
+			want: "Post-pivot configuration: step ApplyManifests in progress, 1 steps completed",
+		},
+		{
+			name: "failed",
+			steps: []lcav1alpha1.PostPivotStep{
+				{Name: "Recert", StartTime: start, CompletionTime: &end, Result: StepResults.Failed, Error: "boom"},
+			},
+			want: "Post-pivot configuration: step Recert failed: boom",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, Summarize(tc.steps))
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	steps := []lcav1alpha1.PostPivotStep{{Name: "Recert", Result: StepResults.InProgress}}
+	summary := Summarize(steps)
+
+	testcases := []struct {
+		name        string
+		message     string
+		completed   bool
+		wantMessage string
+	}{
+		{
+			name:        "in progress",
+			message:     "Waiting for the system to stabilize",
+			wantMessage: "Waiting for the system to stabilize; " + summary,
+		},
+		{
+			name:        "previous summary replaced",
+			message:     "Waiting for the system to stabilize; Post-pivot configuration: 3 steps completed",
+			wantMessage: "Waiting for the system to stabilize; " + summary,
+		},
+		{
+			name:        "message with the summary only",
+			message:     "Post-pivot configuration: 3 steps completed",
+			wantMessage: summary,
+		},
+		{
+			name:      "upgrade completed",
+			message:   "Waiting for the system to stabilize",
+			completed: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			ibu := &lcav1alpha1.ImageBasedUpgrade{}
+			utils.SetUpgradeStatusInProgress(ibu, tc.message)
+			if tc.completed {
+				utils.SetUpgradeStatusCompleted(ibu)
+			}
+
+			Merge(ibu, steps)
+			assert.Equal(t, steps, ibu.Status.PostPivotSteps)
+			condition := utils.GetInProgressCondition(ibu, lcav1alpha1.Stages.Upgrade)
+			if tc.completed {
+				assert.NotContains(t, condition.Message, messagePrefix)
+			} else {
+				assert.Equal(t, tc.wantMessage, condition.Message)
+			}
+		})
+	}
+}
@@ -10,7 +10,9 @@ import (
 	"github.com/openshift-kni/lifecycle-agent/api/v1alpha1"
 	"github.com/openshift-kni/lifecycle-agent/controllers/utils"
 	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/internal/notify"
 	"github.com/openshift-kni/lifecycle-agent/internal/ostreeclient"
+	"github.com/openshift-kni/lifecycle-agent/internal/progress"
 	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
 	rpmostreeclient "github.com/openshift-kni/lifecycle-agent/lca-cli/ostreeclient"
 	lcautils "github.com/openshift-kni/lifecycle-agent/utils"
@@ -195,11 +197,29 @@ func (c *RebootClient) InitiateRollback(msg string) error {
 	}
 
 	utils.SetUpgradeStatusFailed(savedIbu, msg)
+	utils.SetHistoryRollbackReason(savedIbu, msg)
+
+	// Report the post-pivot configuration steps, as the journal stays in the new stateroot
+	if steps, err := progress.Read(common.PathOutsideChroot(progress.JournalFile)); err != nil {
+		c.log.Info(fmt.Sprintf("Unable to read the post-pivot progress: %s", err))
+	} else {
+		progress.Merge(savedIbu, steps)
+	}
 
 	if err := lcautils.MarshalToFile(savedIbu, filePath); err != nil {
 		return fmt.Errorf("unable to save updated ibu CR to %s: %w", filePath, err)
 	}
 
+	// Queue the notification of the rollback, and carry it over to the original stateroot along with the pending
+	// ones. The notifications are best effort and don't hold back the rollback
+	outboxDir := common.PathOutsideChroot(notify.Dir)
+	if err := notify.EnqueueAutoRollback(outboxDir, savedIbu, msg); err != nil {
+		c.log.Info(fmt.Sprintf("Unable to queue auto-rollback notification: %s", err))
+	}
+	if err := notify.NewOutbox(outboxDir).CopyTo(common.PathOutsideChroot(filepath.Join(common.GetStaterootPath(stateroot), notify.Dir))); err != nil {
+		c.log.Info(fmt.Sprintf("Unable to copy notifications for rollback: %s", err))
+	}
+
 	c.log.Info("Iniating rollback")
 
 	deploymentIndex, err := c.rpmOstreeClient.GetUnbootedDeploymentIndex()
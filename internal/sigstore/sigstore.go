@@ -0,0 +1,59 @@
+package sigstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// registriesConfig makes skopeo read and write sigstore signatures as OCI attachments in the registry, which is
+// where cosign stores them
+const registriesConfig = `default-docker:
+  use-sigstore-attachments: true
+`
+
+// policyRejectedMessage is the prefix of the error reported by containers/image when the policy rejects an image
+const policyRejectedMessage = "Source image rejected"
+
+// WriteRegistriesConfig writes a registries.d configuration enabling sigstore attachments to the directory, to be
+// passed to skopeo with --registries.d
+func WriteRegistriesConfig(dir string) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create registries.d directory %s: %w", dir, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "sigstore.yaml"), []byte(registriesConfig), 0o600); err != nil {
+		return fmt.Errorf("failed to write registries.d configuration in %s: %w", dir, err)
+	}
+	return nil
+}
+
+// VerificationPolicy returns a containers policy.json that only accepts images with a sigstore signature made by
+// the private key matching the public key file. The signature must be for the repository of the image, which is
+// what cosign signs by default, regardless of the tag
+func VerificationPolicy(publicKeyPath string) ([]byte, error) {
+	policy := map[string]any{
+		"default": []map[string]any{
+			{
+				"type":    "sigstoreSigned",
+				"keyPath": publicKeyPath,
+				"signedIdentity": map[string]string{
+					"type": "matchRepository",
+				},
+			},
+		},
+	}
+
+	data, err := json.MarshalIndent(policy, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal verification policy: %w", err)
+	}
+	return data, nil
+}
+
+// IsPolicyRejection returns true if the error of a skopeo or podman command shows that the image was rejected by
+// the signature policy, as opposed to failing to be pulled
+func IsPolicyRejection(err error) bool {
+	return err != nil && strings.Contains(err.Error(), policyRejectedMessage)
+}
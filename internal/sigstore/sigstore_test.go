@@ -0,0 +1,54 @@
+package sigstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerificationPolicy(t *testing.T) {
+	data, err := VerificationPolicy("/var/lib/lca/cosign.pub")
+	assert.NoError(t, err)
+
+	var policy struct {
+		Default []struct {
+			Type           string            `json:"type"`
+			KeyPath        string            `json:"keyPath"`
+			SignedIdentity map[string]string `json:"signedIdentity"`
+		} `json:"default"`
+	}
+	assert.NoError(t, json.Unmarshal(data, &policy))
+	assert.Len(t, policy.Default, 1)
+	assert.Equal(t, "sigstoreSigned", policy.Default[0].Type)
+	assert.Equal(t, "/var/lib/lca/cosign.pub", policy.Default[0].KeyPath)
+	assert.Equal(t, "matchRepository", policy.Default[0].SignedIdentity["type"])
+}
+
+func TestIsPolicyRejection(t *testing.T) {
+	testcases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "no error",
+		},
+		{
+			name: "missing signature",
+			err:  fmt.Errorf("exit status 1: FATA[0001] Source image rejected: A signature was required, but no signature exists"),
+			want: true,
+		},
+		{
+			name: "unreachable registry",
+			err:  fmt.Errorf("exit status 1: FATA[0030] initializing source docker://quay.io/seed:1: pinging container registry quay.io: i/o timeout"),
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, IsPolicyRejection(tc.err))
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package upgradepath
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Graph is an upgrade graph in the format served by the OpenShift Update Service (Cincinnati), e.g.
+// https://api.openshift.com/api/upgrades_info/v1/graph?channel=eus-4.16
+type Graph struct {
+	Nodes []Node `json:"nodes"`
+	// Edges are the supported upgrades, as pairs of indexes in Nodes. Conditional edges, which come with known risks,
+	// are not followed
+	Edges [][2]int `json:"edges"`
+}
+
+// Node is a release in an upgrade graph
+type Node struct {
+	Version string `json:"version"`
+	Payload string `json:"payload,omitempty"`
+}
+
+// GraphValidator allows the upgrades for which the graph has a path. IBU doesn't go through the intermediate versions
+// of the path, it jumps from the current version to the target version, so the jump must also be allowed by the
+// version skew rules
+type GraphValidator struct {
+	versions map[string]int
+	nodes    []Node
+	next     map[int][]int
+}
+
+// NewGraphValidator parses the upgrade graph
+func NewGraphValidator(data []byte) (*GraphValidator, error) {
+	graph := &Graph{}
+	if err := json.Unmarshal(data, graph); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal upgrade graph: %w", err)
+	}
+
+	v := &GraphValidator{
+		versions: make(map[string]int, len(graph.Nodes)),
+		nodes:    graph.Nodes,
+		next:     make(map[int][]int),
+	}
+	for i, node := range graph.Nodes {
+		v.versions[node.Version] = i
+	}
+	for _, edge := range graph.Edges {
+		from, to := edge[0], edge[1]
+		if from < 0 || from >= len(graph.Nodes) || to < 0 || to >= len(graph.Nodes) {
+			return nil, fmt.Errorf("invalid upgrade graph: edge %d -> %d refers to a missing node", from, to)
+		}
+		v.next[from] = append(v.next[from], to)
+	}
+	return v, nil
+}
+
+// Validate returns the shortest path from the current version to the target version in the graph, if the direct
+// upgrade between them is allowed by the version skew rules
+func (v *GraphValidator) Validate(current, target string) (*Path, error) {
+	from, ok := v.versions[current]
+	if !ok {
+		return nil, &RejectedError{From: current, To: target, Reason: fmt.Sprintf("version %s is not in the upgrade graph", current)}
+	}
+	to, ok := v.versions[target]
+	if !ok {
+		return nil, &RejectedError{From: current, To: target, Reason: fmt.Sprintf("version %s is not in the upgrade graph", target)}
+	}
+
+	// Breadth-first search, recording the node each node was reached from
+	previous := map[int]int{from: from}
+	queue := []int{from}
+	for len(queue) > 0 && !v.reached(previous, to) {
+		node := queue[0]
+		queue = queue[1:]
+		for _, n := range v.next[node] {
+			if _, seen := previous[n]; !seen {
+				previous[n] = node
+				queue = append(queue, n)
+			}
+		}
+	}
+	if !v.reached(previous, to) || from == to {
+		return nil, &RejectedError{From: current, To: target, Reason: "there is no upgrade path between these versions in the upgrade graph"}
+	}
+
+	if _, err := (&SkewValidator{}).Validate(current, target); err != nil {
+		return nil, err
+	}
+
+	var versions []string
+	for node := to; node != from; node = previous[node] {
+		versions = append([]string{v.nodes[node].Version}, versions...)
+	}
+	return &Path{Versions: append([]string{current}, versions...)}, nil
+}
+
+func (v *GraphValidator) reached(previous map[int]int, node int) bool {
+	_, ok := previous[node]
+	return ok
+}
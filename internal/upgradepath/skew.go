@@ -0,0 +1,43 @@
+package upgradepath
+
+import (
+	"fmt"
+
+	"github.com/coreos/go-semver/semver"
+)
+
+// SkewValidator allows upgrades to the next minor version, or across two minor versions between Extended Update
+// Support (EUS) releases, which are the even minor versions
+type SkewValidator struct{}
+
+func (v *SkewValidator) Validate(current, target string) (*Path, error) {
+	currentVer, err := semver.NewVersion(current)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse version %s: %w", current, err)
+	}
+	targetVer, err := semver.NewVersion(target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse version %s: %w", target, err)
+	}
+
+	reject := func(reason string) (*Path, error) {
+		return nil, &RejectedError{From: current, To: target, Reason: reason}
+	}
+	switch minors := targetVer.Minor - currentVer.Minor; {
+	case !currentVer.LessThan(*targetVer):
+		return reject("the target version must be higher than the current version")
+	case targetVer.Major != currentVer.Major:
+		return reject("upgrades across major versions are not supported")
+	case minors > 2:
+		return reject(fmt.Sprintf("the upgrade spans %d minor versions, at most 2 are supported", minors))
+	case minors == 2 && !(isEUS(currentVer) && isEUS(targetVer)):
+		return reject("upgrades across two minor versions are only supported between EUS releases")
+	}
+
+	return &Path{Versions: []string{current, target}}, nil
+}
+
+// isEUS returns true if the version is an Extended Update Support release
+func isEUS(version *semver.Version) bool {
+	return version.Minor%2 == 0
+}
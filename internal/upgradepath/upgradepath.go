@@ -0,0 +1,74 @@
+package upgradepath
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	lcav1alpha1 "github.com/openshift-kni/lifecycle-agent/api/v1alpha1"
+	"github.com/openshift-kni/lifecycle-agent/internal/common"
+)
+
+// GraphKey is the key of the config map holding an upgrade graph
+const GraphKey = "graph.json"
+
+// Validator validates the upgrade of a cluster from its current version to the version of a seed image
+type Validator interface {
+	// Validate returns the path the upgrade is allowed by, or a RejectedError
+	Validate(current, target string) (*Path, error)
+}
+
+// Path is the list of versions the upgrade from the first to the last one is allowed by
+type Path struct {
+	Versions []string
+}
+
+func (p *Path) String() string {
+	return strings.Join(p.Versions, " -> ")
+}
+
+// RejectedError is returned when the upgrade from a version to another is not supported
+type RejectedError struct {
+	From   string
+	To     string
+	Reason string
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("upgrade from %s to %s is not supported: %s", e.From, e.To, e.Reason)
+}
+
+// IsRejectedError returns true if the error, or any error it wraps, is a RejectedError
+func IsRejectedError(err error) bool {
+	var rejectedErr *RejectedError
+	return errors.As(err, &rejectedErr)
+}
+
+// NewValidator returns the validator for the given upgrade graph, in Cincinnati format. Without a graph, the version
+// skew rules are used
+func NewValidator(graphData []byte) (Validator, error) {
+	if len(graphData) > 0 {
+		return NewGraphValidator(graphData)
+	}
+	return &SkewValidator{}, nil
+}
+
+// NewValidatorForIBU returns the validator for the upgrade graph referenced by the IBU spec, if any
+func NewValidatorForIBU(ctx context.Context, c client.Client, ibu *lcav1alpha1.ImageBasedUpgrade) (Validator, error) {
+	var graph []byte
+	if graphRef := ibu.Spec.UpgradeGraphRef; graphRef != nil {
+		cm, err := common.GetConfigMap(ctx, c, *graphRef)
+		if err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+		data, ok := cm.Data[GraphKey]
+		if !ok {
+			return nil, fmt.Errorf("configMap %s/%s has no %s key", cm.Namespace, cm.Name, GraphKey)
+		}
+		graph = []byte(data)
+	}
+	return NewValidator(graph)
+}
@@ -0,0 +1,160 @@
+package upgradepath
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testGraph has the 4.14.8 -> 4.14.10 -> 4.15.2 -> 4.16.1 -> 4.17.0 chain, and an unreachable 4.16.0
+const testGraph = `{
+  "nodes": [
+    {"version": "4.14.8", "payload": "quay.io/openshift-release-dev/ocp-release@sha256:0"},
+    {"version": "4.14.10", "payload": "quay.io/openshift-release-dev/ocp-release@sha256:1"},
+    {"version": "4.15.2", "payload": "quay.io/openshift-release-dev/ocp-release@sha256:2"},
+    {"version": "4.16.1", "payload": "quay.io/openshift-release-dev/ocp-release@sha256:3"},
+    {"version": "4.16.0", "payload": "quay.io/openshift-release-dev/ocp-release@sha256:4"},
+    {"version": "4.17.0", "payload": "quay.io/openshift-release-dev/ocp-release@sha256:5"}
+  ],
+  "edges": [[0, 1], [1, 2], [2, 3], [0, 2], [3, 5]],
+  "conditionalEdges": [{"edges": [{"from": "4.15.2", "to": "4.16.0"}], "risks": [{"name": "SomeRisk"}]}]
+}`
+
+func TestGraphValidator(t *testing.T) {
+	validator, err := NewGraphValidator([]byte(testGraph))
+	assert.NoError(t, err)
+
+	testcases := []struct {
+		name     string
+		current  string
+		target   string
+		wantPath string
+	}{
+		{
+			name:     "direct edge",
+			current:  "4.14.8",
+			target:   "4.14.10",
+			wantPath: "4.14.8 -> 4.14.10",
+		},
+		{
+			name:     "shortest path",
+			current:  "4.14.8",
+			target:   "4.16.1",
+			wantPath: "4.14.8 -> 4.15.2 -> 4.16.1",
+		},
+		{
+			name:    "path across too many minor versions for a direct upgrade",
+			current: "4.14.8",
+			target:  "4.17.0",
+		},
+		{
+			name:    "conditional edges are not followed",
+			current: "4.14.8",
+			target:  "4.16.0",
+		},
+		{
+			name:    "downgrade",
+			current: "4.15.2",
+			target:  "4.14.8",
+		},
+		{
+			name:    "same version",
+			current: "4.14.8",
+			target:  "4.14.8",
+		},
+		{
+			name:    "unknown version",
+			current: "4.14.8",
+			target:  "4.18.0",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			path, err := validator.Validate(tc.current, tc.target)
+			if tc.wantPath == "" {
+				assert.True(t, IsRejectedError(err), "expected a rejection, got %v", err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantPath, path.String())
+		})
+	}
+}
+
+func TestNewGraphValidatorInvalidEdge(t *testing.T) {
+	_, err := NewGraphValidator([]byte(`{"nodes": [{"version": "4.14.8"}], "edges": [[0, 1]]}`))
+	assert.ErrorContains(t, err, "refers to a missing node")
+}
+
+func TestSkewValidator(t *testing.T) {
+	testcases := []struct {
+		name       string
+		current    string
+		target     string
+		wantReason string
+	}{
+		{
+			name:    "z-stream",
+			current: "4.14.8",
+			target:  "4.14.10",
+		},
+		{
+			name:    "next minor",
+			current: "4.15.3",
+			target:  "4.16.1",
+		},
+		{
+			name:    "EUS to EUS",
+			current: "4.14.8",
+			target:  "4.16.1",
+		},
+		{
+			name:       "EUS to non-EUS",
+			current:    "4.15.3",
+			target:     "4.17.1",
+			wantReason: "only supported between EUS releases",
+		},
+		{
+			name:       "several minors",
+			current:    "4.14.8",
+			target:     "4.17.1",
+			wantReason: "the upgrade spans 3 minor versions",
+		},
+		{
+			name:       "downgrade",
+			current:    "4.15.3",
+			target:     "4.14.8",
+			wantReason: "must be higher",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			path, err := (&SkewValidator{}).Validate(tc.current, tc.target)
+			if tc.wantReason != "" {
+				assert.True(t, IsRejectedError(err), "expected a rejection, got %v", err)
+				assert.ErrorContains(t, err, tc.wantReason)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, []string{tc.current, tc.target}, path.Versions)
+		})
+	}
+}
+
+func TestNewValidator(t *testing.T) {
+	// Without a graph, the version skew rules are used
+	validator, err := NewValidator(nil)
+	assert.NoError(t, err)
+	_, err = validator.Validate("4.14.8", "4.16.1")
+	assert.NoError(t, err)
+	_, err = validator.Validate("4.14.8", "4.17.1")
+	assert.True(t, IsRejectedError(err))
+
+	// A supplied graph is authoritative
+	validator, err = NewValidator([]byte(testGraph))
+	assert.NoError(t, err)
+	_, err = validator.Validate("4.14.8", "4.16.0")
+	assert.True(t, IsRejectedError(err))
+}
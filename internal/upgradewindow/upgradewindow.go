@@ -0,0 +1,102 @@
+package upgradewindow
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	lcav1alpha1 "github.com/openshift-kni/lifecycle-agent/api/v1alpha1"
+)
+
+// Window is an occurrence of the upgrade window
+type Window struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Schedule computes the occurrences of an upgrade window. The window opens at its start time, if set, and at every
+// occurrence of its cron schedule, if set, from its start time on
+type Schedule struct {
+	start            time.Time
+	cron             cron.Schedule
+	duration         time.Duration
+	expectedDuration time.Duration
+}
+
+// New validates the upgrade window and returns its schedule
+func New(window *lcav1alpha1.UpgradeWindow) (*Schedule, error) {
+	s := &Schedule{
+		duration:         window.Duration.Duration,
+		expectedDuration: window.ExpectedDuration.Duration,
+	}
+	if s.duration <= 0 {
+		return nil, fmt.Errorf("upgrade window duration must be positive")
+	}
+	if s.expectedDuration < 0 || s.expectedDuration > s.duration {
+		return nil, fmt.Errorf("expected upgrade duration %s must be between 0 and the window duration %s", s.expectedDuration, s.duration)
+	}
+	if window.Start != nil {
+		s.start = window.Start.Time
+	}
+	if window.Schedule != "" {
+		schedule, err := cron.ParseStandard(window.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid upgrade window schedule %q: %w", window.Schedule, err)
+		}
+		s.cron = schedule
+	}
+	if s.start.IsZero() && s.cron == nil {
+		return nil, fmt.Errorf("upgrade window must have a start or a schedule")
+	}
+	return s, nil
+}
+
+// Current returns the window open at the given time, if any
+func (s *Schedule) Current(now time.Time) (Window, bool) {
+	var current Window
+	var found bool
+
+	if !s.start.IsZero() && !now.Before(s.start) && now.Before(s.start.Add(s.duration)) {
+		current, found = Window{Start: s.start, End: s.start.Add(s.duration)}, true
+	}
+	if s.cron != nil {
+		// The latest occurrence that opened less than a duration ago. The schedule can't open before its start
+		from := now.Add(-s.duration)
+		if s.start.After(from) {
+			from = s.start
+		}
+		// A schedule that never fires, e.g. on February 30th, returns a zero time
+		for next := s.cron.Next(from.Add(-time.Second)); !next.IsZero() && !next.After(now); next = s.cron.Next(next) {
+			if !found || next.Add(s.duration).After(current.End) {
+				current, found = Window{Start: next, End: next.Add(s.duration)}, true
+			}
+		}
+	}
+	return current, found
+}
+
+// Next returns the first window opening after the given time, if any
+func (s *Schedule) Next(now time.Time) (Window, bool) {
+	if s.start.After(now) {
+		return Window{Start: s.start, End: s.start.Add(s.duration)}, true
+	}
+	if s.cron != nil {
+		if next := s.cron.Next(now); !next.IsZero() {
+			return Window{Start: next, End: next.Add(s.duration)}, true
+		}
+	}
+	return Window{}, false
+}
+
+// CanUpgrade returns true if a window is open at the given time and stays open for at least the expected upgrade
+// duration. Otherwise, the next window the upgrade can run in is returned, if any
+func (s *Schedule) CanUpgrade(now time.Time) (bool, *Window) {
+	if current, ok := s.Current(now); ok && current.End.Sub(now) >= s.expectedDuration {
+		return true, nil
+	}
+	if next, ok := s.Next(now); ok {
+		return false, &next
+	}
+	return false, nil
+}
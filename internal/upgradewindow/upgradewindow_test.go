@@ -0,0 +1,139 @@
+package upgradewindow
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	lcav1alpha1 "github.com/openshift-kni/lifecycle-agent/api/v1alpha1"
+)
+
+func mustParse(t *testing.T, value string) time.Time {
+	parsed, err := time.Parse(time.RFC3339, value)
+	assert.NoError(t, err)
+	return parsed
+}
+
+func TestNew(t *testing.T) {
+	start := metav1.NewTime(time.Date(2024, 3, 2, 2, 0, 0, 0, time.UTC))
+	testcases := []struct {
+		name    string
+		window  lcav1alpha1.UpgradeWindow
+		wantErr bool
+	}{
+		{
+			name:   "start only",
+			window: lcav1alpha1.UpgradeWindow{Start: &start, Duration: metav1.Duration{Duration: time.Hour}},
+		},
+		{
+			name:   "schedule only",
+			window: lcav1alpha1.UpgradeWindow{Schedule: "0 2 * * SAT", Duration: metav1.Duration{Duration: time.Hour}},
+		},
+		{
+			name:    "neither start nor schedule",
+			window:  lcav1alpha1.UpgradeWindow{Duration: metav1.Duration{Duration: time.Hour}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid schedule",
+			window:  lcav1alpha1.UpgradeWindow{Schedule: "every saturday", Duration: metav1.Duration{Duration: time.Hour}},
+			wantErr: true,
+		},
+		{
+			name:    "no duration",
+			window:  lcav1alpha1.UpgradeWindow{Start: &start},
+			wantErr: true,
+		},
+		{
+			name: "expected duration longer than the window",
+			window: lcav1alpha1.UpgradeWindow{Start: &start, Duration: metav1.Duration{Duration: time.Hour},
+				ExpectedDuration: metav1.Duration{Duration: 2 * time.Hour}},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := New(&tc.window)
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCanUpgrade(t *testing.T) {
+	start := metav1.NewTime(mustParse(t, "2024-03-02T02:00:00Z"))
+	testcases := []struct {
+		name     string
+		window   lcav1alpha1.UpgradeWindow
+		now      string
+		wantOK   bool
+		wantNext string
+	}{
+		{
+			name:     "before a one-off window",
+			window:   lcav1alpha1.UpgradeWindow{Start: &start, Duration: metav1.Duration{Duration: 4 * time.Hour}},
+			now:      "2024-03-01T12:00:00Z",
+			wantNext: "2024-03-02T02:00:00Z",
+		},
+		{
+			name:   "within a one-off window",
+			window: lcav1alpha1.UpgradeWindow{Start: &start, Duration: metav1.Duration{Duration: 4 * time.Hour}},
+			now:    "2024-03-02T03:00:00Z",
+			wantOK: true,
+		},
+		{
+			name: "not enough of a one-off window left",
+			window: lcav1alpha1.UpgradeWindow{Start: &start, Duration: metav1.Duration{Duration: 4 * time.Hour},
+				ExpectedDuration: metav1.Duration{Duration: 2 * time.Hour}},
+			now: "2024-03-02T05:00:00Z",
+		},
+		{
+			name:   "after a one-off window",
+			window: lcav1alpha1.UpgradeWindow{Start: &start, Duration: metav1.Duration{Duration: 4 * time.Hour}},
+			now:    "2024-03-02T06:00:00Z",
+		},
+		{
+			name:   "within a recurring window",
+			window: lcav1alpha1.UpgradeWindow{Schedule: "0 2 * * SAT", Duration: metav1.Duration{Duration: 4 * time.Hour}},
+			now:    "2024-03-09T05:00:00Z",
+			wantOK: true,
+		},
+		{
+			name: "not enough of a recurring window left",
+			window: lcav1alpha1.UpgradeWindow{Schedule: "0 2 * * SAT", Duration: metav1.Duration{Duration: 4 * time.Hour},
+				ExpectedDuration: metav1.Duration{Duration: 2 * time.Hour}},
+			now:      "2024-03-09T05:00:00Z",
+			wantNext: "2024-03-16T02:00:00Z",
+		},
+		{
+			name:     "recurring window not opened since its start",
+			window:   lcav1alpha1.UpgradeWindow{Start: &start, Schedule: "0 2 * * SAT", Duration: metav1.Duration{Duration: 4 * time.Hour}},
+			now:      "2024-02-24T03:00:00Z",
+			wantNext: "2024-03-02T02:00:00Z",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			schedule, err := New(&tc.window)
+			assert.NoError(t, err)
+
+			ok, next := schedule.CanUpgrade(mustParse(t, tc.now))
+			assert.Equal(t, tc.wantOK, ok)
+			switch {
+			case tc.wantNext != "":
+				if assert.NotNil(t, next) {
+					assert.True(t, mustParse(t, tc.wantNext).Equal(next.Start), "next window opens at %s", next.Start)
+				}
+			default:
+				assert.Nil(t, next)
+			}
+		})
+	}
+}
@@ -0,0 +1,305 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/coreos/go-semver/semver"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	lcav1alpha1 "github.com/openshift-kni/lifecycle-agent/api/v1alpha1"
+	"github.com/openshift-kni/lifecycle-agent/internal/backuprestore"
+	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/internal/healthcheck"
+	"github.com/openshift-kni/lifecycle-agent/internal/hooks"
+	"github.com/openshift-kni/lifecycle-agent/internal/upgradepath"
+	"github.com/openshift-kni/lifecycle-agent/internal/upgradewindow"
+)
+
+//+kubebuilder:webhook:path=/validate-lca-openshift-io-v1alpha1-imagebasedupgrade,mutating=false,failurePolicy=ignore,sideEffects=None,groups=lca.openshift.io,resources=imagebasedupgrades,verbs=create;update,versions=v1alpha1,name=vimagebasedupgrade.lca.openshift.io,admissionReviewVersions=v1,timeoutSeconds=10
+
+// IBUValidator rejects invalid ImageBasedUpgrade specs at admission time. The same checks are done
+// again by the reconciler, as the webhook is skipped while the lifecycle-agent is not running
+type IBUValidator struct {
+	Client        client.Client
+	BackupRestore backuprestore.BackuperRestorer
+	Log           logr.Logger
+}
+
+var _ admission.CustomValidator = &IBUValidator{}
+
+// SetupWebhookWithManager registers the validating webhook with the manager
+func (v *IBUValidator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	//nolint:wrapcheck
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&lcav1alpha1.ImageBasedUpgrade{}).
+		WithValidator(v).
+		Complete()
+}
+
+// ValidateCreate validates a new IBU, e.g. when it is restored by the lifecycle-agent after a pivot
+func (v *IBUValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	ibu, ok := obj.(*lcav1alpha1.ImageBasedUpgrade)
+	if !ok {
+		return nil, fmt.Errorf("expected an ImageBasedUpgrade but got a %T", obj)
+	}
+	return nil, v.validate(ctx, nil, ibu)
+}
+
+// ValidateUpdate validates the updated IBU spec
+func (v *IBUValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	oldIBU, ok := oldObj.(*lcav1alpha1.ImageBasedUpgrade)
+	if !ok {
+		return nil, fmt.Errorf("expected an ImageBasedUpgrade but got a %T", oldObj)
+	}
+	ibu, ok := newObj.(*lcav1alpha1.ImageBasedUpgrade)
+	if !ok {
+		return nil, fmt.Errorf("expected an ImageBasedUpgrade but got a %T", newObj)
+	}
+	return nil, v.validate(ctx, oldIBU, ibu)
+}
+
+// ValidateDelete allows any deletion
+func (v *IBUValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (v *IBUValidator) validate(ctx context.Context, oldIBU, ibu *lcav1alpha1.ImageBasedUpgrade) error {
+	allErrs := validateIBUSpec(ibu)
+
+	// The referenced resources are only checked when Prep is requested, as they may legitimately be
+	// created after the spec is set, or cleaned up once the upgrade is finalized
+	if ibu.Spec.Stage == lcav1alpha1.Stages.Prep && (oldIBU == nil || oldIBU.Spec.Stage != lcav1alpha1.Stages.Prep) {
+		refErrs, err := v.validateReferences(ctx, ibu)
+		if err != nil {
+			return err
+		}
+		allErrs = append(allErrs, refErrs...)
+	}
+
+	if len(allErrs) == 0 {
+		return nil
+	}
+	v.Log.Info("Rejecting ImageBasedUpgrade", "name", ibu.Name, "errors", allErrs.ToAggregate().Error())
+	return k8serrors.NewInvalid(lcav1alpha1.GroupVersion.WithKind("ImageBasedUpgrade").GroupKind(), ibu.Name, allErrs)
+}
+
+// validateIBUSpec checks the format of the spec fields
+func validateIBUSpec(ibu *lcav1alpha1.ImageBasedUpgrade) field.ErrorList {
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+	seedImageRefPath := specPath.Child("seedImageRef")
+
+	// The seed image and version are mandatory to start the upgrade, but may be left empty while idle
+	requireSeedImageRef := ibu.Spec.Stage != "" && ibu.Spec.Stage != lcav1alpha1.Stages.Idle
+
+	image := ibu.Spec.SeedImageRef.Image
+	switch {
+	case image != "":
+		if err := validateImageReference(image); err != nil {
+			allErrs = append(allErrs, field.Invalid(seedImageRefPath.Child("image"), image, err.Error()))
+		}
+	case requireSeedImageRef:
+		allErrs = append(allErrs, field.Required(seedImageRefPath.Child("image"), "seed image is required to start the upgrade"))
+	}
+
+	version := ibu.Spec.SeedImageRef.Version
+	switch {
+	case version != "":
+		if _, err := semver.NewVersion(version); err != nil {
+			allErrs = append(allErrs, field.Invalid(seedImageRefPath.Child("version"), version, fmt.Sprintf("must be a semantic version: %s", err)))
+		}
+	case requireSeedImageRef:
+		allErrs = append(allErrs, field.Required(seedImageRefPath.Child("version"), "seed version is required to start the upgrade"))
+	}
+
+	timeout := ibu.Spec.AutoRollbackOnFailure.InitMonitorTimeoutSeconds
+	if timeout > 0 && (timeout < common.IBUAutoRollbackInitMonitorTimeoutMinSeconds || timeout > common.IBUAutoRollbackInitMonitorTimeoutMaxSeconds) {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("autoRollbackOnFailure", "initMonitorTimeoutSeconds"), timeout,
+			fmt.Sprintf("must be between %d and %d seconds, or less than or equal to 0 to use the default of %d seconds",
+				common.IBUAutoRollbackInitMonitorTimeoutMinSeconds,
+				common.IBUAutoRollbackInitMonitorTimeoutMaxSeconds,
+				common.IBUAutoRollbackInitMonitorTimeoutDefaultSeconds)))
+	}
+
+	if window := ibu.Spec.UpgradeWindow; window != nil {
+		if _, err := upgradewindow.New(window); err != nil {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("upgradeWindow"), window, err.Error()))
+		}
+	}
+
+	allErrs = append(allErrs, validateHealthChecks(&ibu.Spec.HealthChecks, specPath.Child("healthChecks"))...)
+
+	if notifications := ibu.Spec.Notifications; notifications != nil {
+		for i, endpoint := range notifications.Endpoints {
+			if u, err := url.Parse(endpoint.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				allErrs = append(allErrs, field.Invalid(specPath.Child("notifications", "endpoints").Index(i).Child("url"), endpoint.URL,
+					"must be an absolute http or https URL"))
+			}
+		}
+	}
+
+	return allErrs
+}
+
+// validateHealthChecks checks the API versions, JSONPath templates and label selectors of the health checks
+func validateHealthChecks(checks *lcav1alpha1.HealthChecks, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	for i, check := range checks.Resources {
+		checkPath := fldPath.Child("resources").Index(i)
+		if _, err := schema.ParseGroupVersion(check.APIVersion); err != nil {
+			allErrs = append(allErrs, field.Invalid(checkPath.Child("apiVersion"), check.APIVersion, err.Error()))
+		}
+		if check.JSONPath != "" {
+			if _, err := healthcheck.ParseJSONPath(check.JSONPath); err != nil {
+				allErrs = append(allErrs, field.Invalid(checkPath.Child("jsonPath"), check.JSONPath, err.Error()))
+			}
+		}
+	}
+
+	for i, check := range checks.Workloads {
+		if _, err := metav1.LabelSelectorAsSelector(&check.Selector); err != nil {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("workloads").Index(i).Child("selector"), check.Selector, err.Error()))
+		}
+	}
+
+	return allErrs
+}
+
+// validateReferences checks that the Secrets and ConfigMaps referenced by the spec exist
+func (v *IBUValidator) validateReferences(ctx context.Context, ibu *lcav1alpha1.ImageBasedUpgrade) (field.ErrorList, error) {
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	if pullSecretRef := ibu.Spec.SeedImageRef.PullSecretRef; pullSecretRef != nil {
+		secret := &corev1.Secret{}
+		if err := v.Client.Get(ctx, types.NamespacedName{Name: pullSecretRef.Name, Namespace: common.LcaNamespace}, secret); err != nil {
+			if !k8serrors.IsNotFound(err) {
+				return nil, fmt.Errorf("failed to get pull secret %s: %w", pullSecretRef.Name, err)
+			}
+			allErrs = append(allErrs, field.NotFound(specPath.Child("seedImageRef", "pullSecretRef", "name"),
+				fmt.Sprintf("%s/%s", common.LcaNamespace, pullSecretRef.Name)))
+		}
+	}
+
+	if verification := ibu.Spec.SeedImageRef.Verification; verification != nil {
+		verificationPath := specPath.Child("seedImageRef", "verification")
+		if cosignKeyRef := verification.CosignKeyRef; cosignKeyRef != nil {
+			secret := &corev1.Secret{}
+			if err := v.Client.Get(ctx, types.NamespacedName{Name: cosignKeyRef.Name, Namespace: common.LcaNamespace}, secret); err != nil {
+				if !k8serrors.IsNotFound(err) {
+					return nil, fmt.Errorf("failed to get cosign key secret %s: %w", cosignKeyRef.Name, err)
+				}
+				allErrs = append(allErrs, field.NotFound(verificationPath.Child("cosignKeyRef", "name"),
+					fmt.Sprintf("%s/%s", common.LcaNamespace, cosignKeyRef.Name)))
+			}
+		}
+		if policyRef := verification.PolicyRef; policyRef != nil {
+			if _, err := common.GetConfigMap(ctx, v.Client, *policyRef); err != nil {
+				if !k8serrors.IsNotFound(err) {
+					return nil, fmt.Errorf("failed to get policy configMap %s/%s: %w", policyRef.Namespace, policyRef.Name, err)
+				}
+				allErrs = append(allErrs, field.NotFound(verificationPath.Child("policyRef"),
+					fmt.Sprintf("%s/%s", policyRef.Namespace, policyRef.Name)))
+			}
+		}
+	}
+
+	if notifications := ibu.Spec.Notifications; notifications != nil {
+		for i, endpoint := range notifications.Endpoints {
+			if endpoint.SigningKeyRef == nil {
+				continue
+			}
+			secret := &corev1.Secret{}
+			if err := v.Client.Get(ctx, types.NamespacedName{Name: endpoint.SigningKeyRef.Name, Namespace: common.LcaNamespace}, secret); err != nil {
+				if !k8serrors.IsNotFound(err) {
+					return nil, fmt.Errorf("failed to get notification signing key secret %s: %w", endpoint.SigningKeyRef.Name, err)
+				}
+				allErrs = append(allErrs, field.NotFound(specPath.Child("notifications", "endpoints").Index(i).Child("signingKeyRef", "name"),
+					fmt.Sprintf("%s/%s", common.LcaNamespace, endpoint.SigningKeyRef.Name)))
+			}
+		}
+	}
+
+	if len(ibu.Spec.OADPContent) != 0 {
+		if err := v.BackupRestore.ValidateOadpConfigmap(ctx, ibu.Spec.OADPContent); err != nil {
+			if !backuprestore.IsBRFailedValidationError(err) {
+				return nil, fmt.Errorf("failed to validate oadp configMap: %w", err)
+			}
+			allErrs = append(allErrs, field.Invalid(specPath.Child("oadpContent"), ibu.Spec.OADPContent, err.Error()))
+		}
+	}
+
+	if cmRef := ibu.Spec.AdditionalImages; cmRef.Name != "" {
+		if _, err := common.GetConfigMap(ctx, v.Client, cmRef); err != nil {
+			if !k8serrors.IsNotFound(err) {
+				return nil, fmt.Errorf("failed to get additional images configMap %s/%s: %w", cmRef.Namespace, cmRef.Name, err)
+			}
+			allErrs = append(allErrs, field.NotFound(specPath.Child("additionalImages"), fmt.Sprintf("%s/%s", cmRef.Namespace, cmRef.Name)))
+		}
+	}
+
+	for i, cmRef := range ibu.Spec.ExtraManifests {
+		if _, err := common.GetConfigMap(ctx, v.Client, cmRef); err != nil {
+			if !k8serrors.IsNotFound(err) {
+				return nil, fmt.Errorf("failed to get extra manifests configMap %s/%s: %w", cmRef.Namespace, cmRef.Name, err)
+			}
+			allErrs = append(allErrs, field.NotFound(specPath.Child("extraManifests").Index(i),
+				fmt.Sprintf("%s/%s", cmRef.Namespace, cmRef.Name)))
+		}
+	}
+
+	if graphRef := ibu.Spec.UpgradeGraphRef; graphRef != nil {
+		graphPath := specPath.Child("upgradeGraphRef")
+		cm, err := common.GetConfigMap(ctx, v.Client, *graphRef)
+		switch {
+		case k8serrors.IsNotFound(err):
+			allErrs = append(allErrs, field.NotFound(graphPath, fmt.Sprintf("%s/%s", graphRef.Namespace, graphRef.Name)))
+		case err != nil:
+			return nil, fmt.Errorf("failed to get upgrade graph configMap %s/%s: %w", graphRef.Namespace, graphRef.Name, err)
+		default:
+			if data, ok := cm.Data[upgradepath.GraphKey]; !ok {
+				allErrs = append(allErrs, field.Invalid(graphPath, *graphRef, fmt.Sprintf("configMap has no %s key", upgradepath.GraphKey)))
+			} else if _, err := upgradepath.NewGraphValidator([]byte(data)); err != nil {
+				allErrs = append(allErrs, field.Invalid(graphPath, *graphRef, err.Error()))
+			}
+		}
+	}
+
+	for _, point := range []hooks.Point{hooks.Points.PrePrep, hooks.Points.PostPrep, hooks.Points.PrePivot,
+		hooks.Points.PostPivot, hooks.Points.PostRollback} {
+		hook := hooks.HookFor(ibu.Spec.Hooks, point)
+		if hook == nil {
+			continue
+		}
+		templateRef := hook.JobTemplateRef
+		templatePath := specPath.Child("hooks", string(point), "jobTemplateRef")
+		cm, err := common.GetConfigMap(ctx, v.Client, templateRef)
+		switch {
+		case k8serrors.IsNotFound(err):
+			allErrs = append(allErrs, field.NotFound(templatePath, fmt.Sprintf("%s/%s", templateRef.Namespace, templateRef.Name)))
+		case err != nil:
+			return nil, fmt.Errorf("failed to get %s hook configMap %s/%s: %w", point, templateRef.Namespace, templateRef.Name, err)
+		default:
+			if data, ok := cm.Data[hooks.JobTemplateKey]; !ok {
+				allErrs = append(allErrs, field.Invalid(templatePath, templateRef, fmt.Sprintf("configMap has no %s key", hooks.JobTemplateKey)))
+			} else if _, err := hooks.ParseTemplate(data); err != nil {
+				allErrs = append(allErrs, field.Invalid(templatePath, templateRef, err.Error()))
+			}
+		}
+	}
+
+	return allErrs, nil
+}
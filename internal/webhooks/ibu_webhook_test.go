@@ -0,0 +1,303 @@
+package webhooks
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	lcav1alpha1 "github.com/openshift-kni/lifecycle-agent/api/v1alpha1"
+	"github.com/openshift-kni/lifecycle-agent/controllers/utils"
+	"github.com/openshift-kni/lifecycle-agent/internal/backuprestore"
+	mock_backuprestore "github.com/openshift-kni/lifecycle-agent/internal/backuprestore/mocks"
+	"github.com/openshift-kni/lifecycle-agent/internal/common"
+)
+
+func newIBU(stage lcav1alpha1.ImageBasedUpgradeStage, image, version string) *lcav1alpha1.ImageBasedUpgrade {
+	return &lcav1alpha1.ImageBasedUpgrade{
+		ObjectMeta: metav1.ObjectMeta{Name: utils.IBUName},
+		Spec: lcav1alpha1.ImageBasedUpgradeSpec{
+			Stage: stage,
+			SeedImageRef: lcav1alpha1.SeedImageRef{
+				Image:   image,
+				Version: version,
+			},
+		},
+	}
+}
+
+func TestValidateIBUSpec(t *testing.T) {
+	testcases := []struct {
+		name       string
+		ibu        *lcav1alpha1.ImageBasedUpgrade
+		modify     func(ibu *lcav1alpha1.ImageBasedUpgrade)
+		wantFields []string
+	}{
+		{
+			name: "valid prep",
+			ibu:  newIBU(lcav1alpha1.Stages.Prep, "quay.io/openshift-kni/seed:4.16.0", "4.16.0"),
+		},
+		{
+			name: "idle without seed image",
+			ibu:  newIBU(lcav1alpha1.Stages.Idle, "", ""),
+		},
+		{
+			name:       "prep without seed image",
+			ibu:        newIBU(lcav1alpha1.Stages.Prep, "", ""),
+			wantFields: []string{"spec.seedImageRef.image", "spec.seedImageRef.version"},
+		},
+		{
+			name:       "malformed image",
+			ibu:        newIBU(lcav1alpha1.Stages.Prep, "quay.io/Openshift-kni/seed:", "4.16.0"),
+			wantFields: []string{"spec.seedImageRef.image"},
+		},
+		{
+			name:       "version is not semver",
+			ibu:        newIBU(lcav1alpha1.Stages.Idle, "quay.io/openshift-kni/seed:4.16.0", "4.16"),
+			wantFields: []string{"spec.seedImageRef.version"},
+		},
+		{
+			name: "init monitor timeout within range",
+			ibu:  newIBU(lcav1alpha1.Stages.Prep, "quay.io/openshift-kni/seed:4.16.0", "4.16.0"),
+			modify: func(ibu *lcav1alpha1.ImageBasedUpgrade) {
+				ibu.Spec.AutoRollbackOnFailure.InitMonitorTimeoutSeconds = 600
+			},
+		},
+		{
+			name: "init monitor timeout too short",
+			ibu:  newIBU(lcav1alpha1.Stages.Prep, "quay.io/openshift-kni/seed:4.16.0", "4.16.0"),
+			modify: func(ibu *lcav1alpha1.ImageBasedUpgrade) {
+				ibu.Spec.AutoRollbackOnFailure.InitMonitorTimeoutSeconds = 10
+			},
+			wantFields: []string{"spec.autoRollbackOnFailure.initMonitorTimeoutSeconds"},
+		},
+		{
+			name: "recurring upgrade window",
+			ibu:  newIBU(lcav1alpha1.Stages.Upgrade, "quay.io/openshift-kni/seed:4.16.0", "4.16.0"),
+			modify: func(ibu *lcav1alpha1.ImageBasedUpgrade) {
+				ibu.Spec.UpgradeWindow = &lcav1alpha1.UpgradeWindow{Schedule: "0 2 * * SAT", Duration: metav1.Duration{Duration: 4 * time.Hour}}
+			},
+		},
+		{
+			name: "upgrade window with invalid schedule",
+			ibu:  newIBU(lcav1alpha1.Stages.Upgrade, "quay.io/openshift-kni/seed:4.16.0", "4.16.0"),
+			modify: func(ibu *lcav1alpha1.ImageBasedUpgrade) {
+				ibu.Spec.UpgradeWindow = &lcav1alpha1.UpgradeWindow{Schedule: "0 2 * *", Duration: metav1.Duration{Duration: 4 * time.Hour}}
+			},
+			wantFields: []string{"spec.upgradeWindow"},
+		},
+		{
+			name: "valid health checks",
+			ibu:  newIBU(lcav1alpha1.Stages.Upgrade, "quay.io/openshift-kni/seed:4.16.0", "4.16.0"),
+			modify: func(ibu *lcav1alpha1.ImageBasedUpgrade) {
+				ibu.Spec.HealthChecks = lcav1alpha1.HealthChecks{
+					Resources: []lcav1alpha1.ResourceHealthCheck{{APIVersion: "sriovnetwork.openshift.io/v1", Kind: "SriovNetworkNodeState",
+						Name: "sno", Namespace: "openshift-sriov-network-operator", JSONPath: "{.status.syncStatus}", Value: "Succeeded"}},
+					Workloads: []lcav1alpha1.WorkloadHealthCheck{{Selector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "du"}}}},
+				}
+			},
+		},
+		{
+			name: "invalid health checks",
+			ibu:  newIBU(lcav1alpha1.Stages.Upgrade, "quay.io/openshift-kni/seed:4.16.0", "4.16.0"),
+			modify: func(ibu *lcav1alpha1.ImageBasedUpgrade) {
+				ibu.Spec.HealthChecks = lcav1alpha1.HealthChecks{
+					Resources: []lcav1alpha1.ResourceHealthCheck{{APIVersion: "a/b/v1", Kind: "SriovNetworkNodeState",
+						Name: "sno", JSONPath: "{.status.syncStatus"}},
+					Workloads: []lcav1alpha1.WorkloadHealthCheck{{Selector: metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "app", Operator: "Matches"}}}}},
+				}
+			},
+			wantFields: []string{"spec.healthChecks.resources[0].apiVersion", "spec.healthChecks.resources[0].jsonPath",
+				"spec.healthChecks.workloads[0].selector"},
+		},
+		{
+			name: "invalid notification endpoint",
+			ibu:  newIBU(lcav1alpha1.Stages.Upgrade, "quay.io/openshift-kni/seed:4.16.0", "4.16.0"),
+			modify: func(ibu *lcav1alpha1.ImageBasedUpgrade) {
+				ibu.Spec.Notifications = &lcav1alpha1.Notifications{Endpoints: []lcav1alpha1.NotificationEndpoint{
+					{URL: "https://noc.example.com/ibu"}, {URL: "https://"}}}
+			},
+			wantFields: []string{"spec.notifications.endpoints[1].url"},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.modify != nil {
+				tc.modify(tc.ibu)
+			}
+			var fields []string
+			for _, err := range validateIBUSpec(tc.ibu) {
+				fields = append(fields, err.Field)
+			}
+			assert.Equal(t, tc.wantFields, fields)
+		})
+	}
+}
+
+func TestIBUValidatorValidateUpdate(t *testing.T) {
+	testscheme := runtime.NewScheme()
+	assert.NoError(t, clientgoscheme.AddToScheme(testscheme))
+	assert.NoError(t, lcav1alpha1.AddToScheme(testscheme))
+
+	pullSecret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "seed-pull-secret", Namespace: common.LcaNamespace}}
+	extraManifests := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "extra-manifests", Namespace: common.LcaNamespace}}
+	additionalImages := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "additional-images", Namespace: common.LcaNamespace}}
+	oadpContent := []lcav1alpha1.ConfigMapRef{{Name: "oadp-cm", Namespace: common.LcaNamespace}}
+	hookTemplate := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "prepivot-hook", Namespace: common.LcaNamespace},
+		Data: map[string]string{"job.yaml": "apiVersion: batch/v1\nkind: Job\nmetadata:\n  namespace: kube-system\n" +
+			"spec:\n  template:\n    spec:\n      containers:\n      - name: drain\n        image: quay.io/example/drain\n"},
+	}
+	upgradeGraph := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "upgrade-graph", Namespace: common.LcaNamespace},
+		Data:       map[string]string{"graph.json": `{"nodes": [], "edges": [[0, 1]]}`},
+	}
+
+	testcases := []struct {
+		name        string
+		oldStage    lcav1alpha1.ImageBasedUpgradeStage
+		modify      func(ibu *lcav1alpha1.ImageBasedUpgrade)
+		oadpErr     error
+		expectOadp  bool
+		wantInvalid bool
+	}{
+		{
+			name:     "prep with existing references",
+			oldStage: lcav1alpha1.Stages.Idle,
+			modify: func(ibu *lcav1alpha1.ImageBasedUpgrade) {
+				ibu.Spec.SeedImageRef.PullSecretRef = &lcav1alpha1.PullSecretRef{Name: pullSecret.Name}
+				ibu.Spec.ExtraManifests = []lcav1alpha1.ConfigMapRef{{Name: extraManifests.Name, Namespace: extraManifests.Namespace}}
+				ibu.Spec.AdditionalImages = lcav1alpha1.ConfigMapRef{Name: additionalImages.Name, Namespace: additionalImages.Namespace}
+				ibu.Spec.OADPContent = oadpContent
+			},
+			expectOadp: true,
+		},
+		{
+			name:     "prep with missing pull secret",
+			oldStage: lcav1alpha1.Stages.Idle,
+			modify: func(ibu *lcav1alpha1.ImageBasedUpgrade) {
+				ibu.Spec.SeedImageRef.PullSecretRef = &lcav1alpha1.PullSecretRef{Name: "missing"}
+			},
+			wantInvalid: true,
+		},
+		{
+			name:     "prep with missing extra manifests",
+			oldStage: lcav1alpha1.Stages.Idle,
+			modify: func(ibu *lcav1alpha1.ImageBasedUpgrade) {
+				ibu.Spec.ExtraManifests = []lcav1alpha1.ConfigMapRef{{Name: "missing", Namespace: common.LcaNamespace}}
+			},
+			wantInvalid: true,
+		},
+		{
+			name:     "prep with missing additional images",
+			oldStage: lcav1alpha1.Stages.Idle,
+			modify: func(ibu *lcav1alpha1.ImageBasedUpgrade) {
+				ibu.Spec.AdditionalImages = lcav1alpha1.ConfigMapRef{Name: "missing", Namespace: common.LcaNamespace}
+			},
+			wantInvalid: true,
+		},
+		{
+			name:     "prep with missing cosign key",
+			oldStage: lcav1alpha1.Stages.Idle,
+			modify: func(ibu *lcav1alpha1.ImageBasedUpgrade) {
+				ibu.Spec.SeedImageRef.Verification = &lcav1alpha1.SeedImageVerification{
+					CosignKeyRef: &lcav1alpha1.SecretRef{Name: "missing"},
+				}
+			},
+			wantInvalid: true,
+		},
+		{
+			name:     "prep with missing notification signing key",
+			oldStage: lcav1alpha1.Stages.Idle,
+			modify: func(ibu *lcav1alpha1.ImageBasedUpgrade) {
+				ibu.Spec.Notifications = &lcav1alpha1.Notifications{Endpoints: []lcav1alpha1.NotificationEndpoint{
+					{URL: "https://noc.example.com/ibu", SigningKeyRef: &lcav1alpha1.SecretRef{Name: "missing"}}}}
+			},
+			wantInvalid: true,
+		},
+		{
+			name:     "prep with invalid upgrade graph",
+			oldStage: lcav1alpha1.Stages.Idle,
+			modify: func(ibu *lcav1alpha1.ImageBasedUpgrade) {
+				ibu.Spec.UpgradeGraphRef = &lcav1alpha1.ConfigMapRef{Name: upgradeGraph.Name, Namespace: upgradeGraph.Namespace}
+			},
+			wantInvalid: true,
+		},
+		{
+			name:     "prep with missing hook job template",
+			oldStage: lcav1alpha1.Stages.Idle,
+			modify: func(ibu *lcav1alpha1.ImageBasedUpgrade) {
+				ibu.Spec.Hooks.PrePivot = &lcav1alpha1.Hook{
+					JobTemplateRef: lcav1alpha1.ConfigMapRef{Name: "missing", Namespace: common.LcaNamespace},
+				}
+			},
+			wantInvalid: true,
+		},
+		{
+			name:     "prep with hook job template in another namespace",
+			oldStage: lcav1alpha1.Stages.Idle,
+			modify: func(ibu *lcav1alpha1.ImageBasedUpgrade) {
+				ibu.Spec.Hooks.PrePivot = &lcav1alpha1.Hook{
+					JobTemplateRef: lcav1alpha1.ConfigMapRef{Name: hookTemplate.Name, Namespace: hookTemplate.Namespace},
+				}
+			},
+			wantInvalid: true,
+		},
+		{
+			name:     "prep with invalid oadp content",
+			oldStage: lcav1alpha1.Stages.Idle,
+			modify: func(ibu *lcav1alpha1.ImageBasedUpgrade) {
+				ibu.Spec.OADPContent = oadpContent
+			},
+			oadpErr:     backuprestore.NewBRFailedValidationError("OADP", "backup is missing"),
+			expectOadp:  true,
+			wantInvalid: true,
+		},
+		{
+			name:     "references are not checked once prep is requested",
+			oldStage: lcav1alpha1.Stages.Prep,
+			modify: func(ibu *lcav1alpha1.ImageBasedUpgrade) {
+				ibu.Spec.SeedImageRef.PullSecretRef = &lcav1alpha1.PullSecretRef{Name: "missing"}
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockController := gomock.NewController(t)
+			defer mockController.Finish()
+			mockBackuprestore := mock_backuprestore.NewMockBackuperRestorer(mockController)
+			if tc.expectOadp {
+				mockBackuprestore.EXPECT().ValidateOadpConfigmap(gomock.Any(), oadpContent).Return(tc.oadpErr).Times(1)
+			}
+
+			v := &IBUValidator{
+				Client:        fake.NewClientBuilder().WithScheme(testscheme).WithObjects(pullSecret, extraManifests, additionalImages, upgradeGraph, hookTemplate).Build(),
+				BackupRestore: mockBackuprestore,
+				Log:           logr.Discard(),
+			}
+
+			oldIBU := newIBU(tc.oldStage, "quay.io/openshift-kni/seed:4.16.0", "4.16.0")
+			ibu := newIBU(lcav1alpha1.Stages.Prep, "quay.io/openshift-kni/seed:4.16.0", "4.16.0")
+			tc.modify(ibu)
+
+			_, err := v.ValidateUpdate(context.Background(), oldIBU, ibu)
+			if tc.wantInvalid {
+				assert.True(t, k8serrors.IsInvalid(err), "expected an invalid error, got %v", err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package webhooks
+
+import (
+	"fmt"
+
+	"github.com/distribution/reference"
+)
+
+// validateImageReference checks that the image is a well-formed reference, e.g. quay.io/org/image:tag
+func validateImageReference(image string) error {
+	if _, err := reference.ParseNormalizedNamed(image); err != nil {
+		return fmt.Errorf("invalid image reference: %w", err)
+	}
+	return nil
+}
+
+// validatePinnedImageReference checks that the image is a well-formed reference with a tag or a digest
+func validatePinnedImageReference(image string) error {
+	named, err := reference.ParseNormalizedNamed(image)
+	if err != nil {
+		return fmt.Errorf("invalid image reference: %w", err)
+	}
+
+	_, tagged := named.(reference.Tagged)
+	_, digested := named.(reference.Digested)
+	if !tagged && !digested {
+		return fmt.Errorf("image reference must include a tag or a digest")
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package webhooks
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	seedgenv1alpha1 "github.com/openshift-kni/lifecycle-agent/api/seedgenerator/v1alpha1"
+)
+
+//+kubebuilder:webhook:path=/validate-lca-openshift-io-v1alpha1-seedgenerator,mutating=false,failurePolicy=ignore,sideEffects=None,groups=lca.openshift.io,resources=seedgenerators,verbs=create,versions=v1alpha1,name=vseedgenerator.lca.openshift.io,admissionReviewVersions=v1,timeoutSeconds=10
+
+// SeedGeneratorValidator rejects invalid SeedGenerator specs at admission time. The spec is immutable,
+// so only creation is validated
+type SeedGeneratorValidator struct {
+	Log logr.Logger
+}
+
+var _ admission.CustomValidator = &SeedGeneratorValidator{}
+
+// SetupWebhookWithManager registers the validating webhook with the manager
+func (v *SeedGeneratorValidator) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	//nolint:wrapcheck
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&seedgenv1alpha1.SeedGenerator{}).
+		WithValidator(v).
+		Complete()
+}
+
+// ValidateCreate validates a new SeedGenerator
+func (v *SeedGeneratorValidator) ValidateCreate(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	seedgen, ok := obj.(*seedgenv1alpha1.SeedGenerator)
+	if !ok {
+		return nil, fmt.Errorf("expected a SeedGenerator but got a %T", obj)
+	}
+
+	allErrs := validateSeedGeneratorSpec(seedgen)
+	if len(allErrs) == 0 {
+		return nil, nil
+	}
+	v.Log.Info("Rejecting SeedGenerator", "name", seedgen.Name, "errors", allErrs.ToAggregate().Error())
+	return nil, k8serrors.NewInvalid(seedgenv1alpha1.GroupVersion.WithKind("SeedGenerator").GroupKind(), seedgen.Name, allErrs)
+}
+
+// ValidateUpdate allows any update, as changes to the spec are already rejected by the CRD
+func (v *SeedGeneratorValidator) ValidateUpdate(_ context.Context, _, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// ValidateDelete allows any deletion
+func (v *SeedGeneratorValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func validateSeedGeneratorSpec(seedgen *seedgenv1alpha1.SeedGenerator) field.ErrorList {
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	if seedgen.Spec.SeedImage == "" {
+		allErrs = append(allErrs, field.Required(specPath.Child("seedImage"), "seed image is required"))
+	} else if err := validatePinnedImageReference(seedgen.Spec.SeedImage); err != nil {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("seedImage"), seedgen.Spec.SeedImage, err.Error()))
+	}
+
+	if seedgen.Spec.RecertImage != "" {
+		if err := validateImageReference(seedgen.Spec.RecertImage); err != nil {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("recertImage"), seedgen.Spec.RecertImage, err.Error()))
+		}
+	}
+
+	return allErrs
+}
@@ -0,0 +1,67 @@
+package webhooks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	seedgenv1alpha1 "github.com/openshift-kni/lifecycle-agent/api/seedgenerator/v1alpha1"
+	"github.com/openshift-kni/lifecycle-agent/controllers/utils"
+)
+
+func TestSeedGeneratorValidatorValidateCreate(t *testing.T) {
+	testcases := []struct {
+		name        string
+		seedImage   string
+		recertImage string
+		wantInvalid bool
+	}{
+		{
+			name:      "tagged seed image",
+			seedImage: "quay.io/openshift-kni/seed:4.16.0",
+		},
+		{
+			name:      "seed image with digest",
+			seedImage: "quay.io/openshift-kni/seed@sha256:" + "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+		},
+		{
+			name:        "empty seed image",
+			wantInvalid: true,
+		},
+		{
+			name:        "seed image without tag",
+			seedImage:   "quay.io/openshift-kni/seed",
+			wantInvalid: true,
+		},
+		{
+			name:        "malformed recert image",
+			seedImage:   "quay.io/openshift-kni/seed:4.16.0",
+			recertImage: "quay.io/edge-infrastructure/Recert:",
+			wantInvalid: true,
+		},
+	}
+
+	v := &SeedGeneratorValidator{Log: logr.Discard()}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			seedgen := &seedgenv1alpha1.SeedGenerator{
+				ObjectMeta: metav1.ObjectMeta{Name: utils.SeedGenName},
+				Spec: seedgenv1alpha1.SeedGeneratorSpec{
+					SeedImage:   tc.seedImage,
+					RecertImage: tc.recertImage,
+				},
+			}
+
+			_, err := v.ValidateCreate(context.Background(), seedgen)
+			if tc.wantInvalid {
+				assert.True(t, k8serrors.IsInvalid(err), "expected an invalid error, got %v", err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -50,6 +50,13 @@ var (
 	recertSkipValidation bool
 
 	skipCleanup bool
+
+	// signingKeyFile and signingPassFile are the cosign private key, and its password, used to sign the OCI image
+	signingKeyFile  string
+	signingPassFile string
+
+	// baseSeedImage is the seed image to create a delta seed image from
+	baseSeedImage string
 )
 
 func init() {
@@ -78,6 +85,9 @@ func init() {
 
 	// Add flags to create command
 	addCommonFlags(createCmd)
+	createCmd.Flags().StringVar(&signingKeyFile, "sign-by-sigstore-private-key", "", "The cosign private key used to sign the OCI image after it is pushed.")
+	createCmd.Flags().StringVar(&signingPassFile, "sign-passphrase-file", "", "The file containing the password of the signing key.")
+	createCmd.Flags().StringVar(&baseSeedImage, "base-image", "", "The seed image to create a delta seed image from, holding only the changes from it.")
 }
 
 func create() error {
@@ -110,7 +120,7 @@ func create() error {
 	}
 
 	seedCreator := seedcreator.NewSeedCreator(client, log, op, rpmOstreeClient, common.BackupDir, common.KubeconfigFile,
-		containerRegistry, authFile, recertContainerImage, recertSkipValidation)
+		containerRegistry, authFile, recertContainerImage, recertSkipValidation, signingKeyFile, signingPassFile, baseSeedImage)
 	if err = seedCreator.CreateSeedImage(); err != nil {
 		err = fmt.Errorf("failed to create seed image: %w", err)
 		log.Errorf(err.Error())
@@ -0,0 +1,106 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/clientcmd"
+	runtimeClient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/gather"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
+)
+
+var (
+	// gatherDestDir is where the gather tarball is written
+	gatherDestDir string
+
+	// gatherKubeconfig is the kubeconfig used to collect from the API, if it is up
+	gatherKubeconfig string
+)
+
+func init() {
+	utilruntime.Must(velerov1.AddToScheme(scheme))
+}
+
+// gatherCmd represents the gather command
+var gatherCmd = &cobra.Command{
+	Use:   "gather",
+	Short: "Collect the diagnostics of an image-based upgrade in a tarball",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := gatherDiagnostics(); err != nil {
+			log.Fatalf("Error executing gather command: %v", err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(gatherCmd)
+
+	gatherCmd.Flags().StringVarP(&gatherDestDir, "dest-dir", "d", "/var/tmp", "The directory the tarball is written to.")
+	gatherCmd.Flags().StringVarP(&gatherKubeconfig, "kubeconfig", "k", common.KubeconfigFile, "The path to the kubeconfig of the cluster.")
+	gatherCmd.Flags().BoolVarP(&inContainer, "in-container", "", false, "Use this flag if this command is being ran inside a container")
+}
+
+func gatherDiagnostics() error {
+	var hostCommandsExecutor ops.Execute
+	if inContainer {
+		hostCommandsExecutor = ops.NewNsenterExecutor(log, verbose)
+	} else {
+		hostCommandsExecutor = ops.NewRegularExecutor(log, verbose)
+	}
+
+	// The files on the host are still collected if the API is down
+	var client runtimeClient.Client
+	config, err := clientcmd.BuildConfigFromFlags("", gatherKubeconfig)
+	if err == nil {
+		config.Timeout = gather.APITimeout
+		client, err = runtimeClient.New(config, runtimeClient.Options{Scheme: scheme})
+	}
+	if err != nil {
+		log.Warnf("Not collecting from the API: %v", err)
+		client = nil
+	}
+
+	name := fmt.Sprintf("lca-gather-%s", time.Now().UTC().Format("20060102-150405"))
+	filename := filepath.Join(gatherDestDir, name+".tar.gz")
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", filename, err)
+	}
+
+	log.Info("Gathering the image-based upgrade diagnostics")
+	err = gather.NewGatherer(log, hostCommandsExecutor, client).Gather(context.Background(), f, name)
+	if closeErr := f.Close(); err == nil && closeErr != nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to gather in %s: %w", filename, err)
+	}
+
+	log.Infof("Diagnostics written to %s", filename)
+	return nil
+}
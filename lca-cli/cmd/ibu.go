@@ -0,0 +1,272 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/clientcmd"
+	runtimeClient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	lcav1alpha1 "github.com/openshift-kni/lifecycle-agent/api/v1alpha1"
+	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/ibu"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
+	ostree "github.com/openshift-kni/lifecycle-agent/lca-cli/ostreeclient"
+)
+
+var (
+	// ibuKubeconfig is the kubeconfig used to reach the cluster, the node's one by default
+	ibuKubeconfig string
+
+	// ibuOutput is the output format of the ibu commands, text or json
+	ibuOutput string
+
+	// ibuNoWait returns as soon as the transition is requested, and ibuTimeout and ibuInterval control the wait
+	ibuNoWait   bool
+	ibuTimeout  time.Duration
+	ibuInterval time.Duration
+)
+
+func init() {
+	utilruntime.Must(lcav1alpha1.AddToScheme(scheme))
+}
+
+// ibuCmd groups the commands driving the image-based upgrade
+var ibuCmd = &cobra.Command{
+	Use:   "ibu",
+	Short: "Drive and watch the image-based upgrade",
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		rootCmd.PersistentPreRun(cmd, args)
+		// Keep stdout for the JSON output
+		if ibuOutput == "json" {
+			log.Out = os.Stderr
+		}
+	},
+}
+
+var ibuStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show a summary of the ImageBasedUpgrade CR",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := ibuStatus(); err != nil {
+			log.Fatalf("Error executing ibu status command: %v", err)
+		}
+	},
+}
+
+var ibuWaitCmd = &cobra.Command{
+	Use:   "wait",
+	Short: "Wait for the desired stage to complete or fail",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := ibuWait(); err != nil {
+			log.Fatalf("Error executing ibu wait command: %v", err)
+		}
+	},
+}
+
+func newIBUTransitionCmd(action ibu.Action, short string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   string(action),
+		Short: short,
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := ibuTransition(action); err != nil {
+				log.Fatalf("Error executing ibu %s command: %v", action, err)
+			}
+		},
+	}
+	cmd.Flags().BoolVarP(&ibuNoWait, "no-wait", "", false, "Return once the transition is requested, without waiting for the stage to complete.")
+	cmd.Flags().DurationVarP(&ibuTimeout, "timeout", "t", 0, "How long to wait for the stage to complete, e.g. 2h. Waits indefinitely by default.")
+	cmd.Flags().DurationVarP(&ibuInterval, "interval", "", 10*time.Second, "How often the ImageBasedUpgrade CR is polled.")
+	return cmd
+}
+
+func init() {
+	rootCmd.AddCommand(ibuCmd)
+	ibuCmd.PersistentFlags().StringVarP(&ibuKubeconfig, "kubeconfig", "k", common.KubeconfigFile, "The path to the kubeconfig of the cluster.")
+	ibuCmd.PersistentFlags().StringVarP(&ibuOutput, "output", "o", "text", "The output format, text or json. In json, each status is printed as a JSON object on its own line.")
+
+	ibuCmd.AddCommand(ibuStatusCmd)
+
+	ibuWaitCmd.Flags().DurationVarP(&ibuTimeout, "timeout", "t", 0, "How long to wait for the stage to complete, e.g. 2h. Waits indefinitely by default.")
+	ibuWaitCmd.Flags().DurationVarP(&ibuInterval, "interval", "", 10*time.Second, "How often the ImageBasedUpgrade CR is polled.")
+	ibuCmd.AddCommand(ibuWaitCmd)
+
+	prepCmd := newIBUTransitionCmd(ibu.Actions.Prep, "Start the Prep stage")
+	prepCmd.Flags().StringVarP(&seedImage, "seed-image", "s", "", "Set the seed image before starting the Prep stage.")
+	prepCmd.Flags().StringVarP(&seedVersion, "seed-version", "", "", "Set the seed version before starting the Prep stage.")
+	ibuCmd.AddCommand(prepCmd)
+	ibuCmd.AddCommand(newIBUTransitionCmd(ibu.Actions.Upgrade, "Start the Upgrade stage"))
+	ibuCmd.AddCommand(newIBUTransitionCmd(ibu.Actions.Rollback, "Roll back the upgrade"))
+	ibuCmd.AddCommand(newIBUTransitionCmd(ibu.Actions.Finalize, "Finalize the completed upgrade or rollback"))
+	ibuCmd.AddCommand(newIBUTransitionCmd(ibu.Actions.Abort, "Abort the upgrade before the pivot"))
+}
+
+func newIBUClient() (runtimeClient.Client, error) {
+	if ibuOutput != "text" && ibuOutput != "json" {
+		return nil, fmt.Errorf("invalid output format %q, must be text or json", ibuOutput)
+	}
+	config, err := clientcmd.BuildConfigFromFlags("", ibuKubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create k8s config: %w", err)
+	}
+	client, err := runtimeClient.New(config, runtimeClient.Options{Scheme: scheme})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create runtime client: %w", err)
+	}
+	return client, nil
+}
+
+func ibuStatus() error {
+	client, err := newIBUClient()
+	if err != nil {
+		return err
+	}
+	current, err := ibu.Get(context.Background(), client)
+	if err != nil {
+		return err
+	}
+
+	summary := ibu.Summarize(current)
+	if ibuOutput == "json" {
+		return printIBUJSON(summary)
+	}
+
+	fmt.Printf("Image:   %s\n", summary.SeedImage)
+	fmt.Printf("Version: %s\n", summary.SeedVersion)
+	fmt.Printf("Stage:   %s\n", summary.DesiredStage)
+	fmt.Printf("Status:  %s %s since %s\n", summary.Stage, summary.Phase, summary.Since.Format(time.RFC3339))
+	if summary.Reason != "" {
+		fmt.Printf("  Reason:  %s\n", summary.Reason)
+	}
+	if summary.Message != "" {
+		fmt.Printf("  Message: %s\n", summary.Message)
+	}
+	fmt.Printf("Valid next stages: %s\n", joinStages(summary.ValidNextStages))
+	return nil
+}
+
+func ibuWait() error {
+	client, err := newIBUClient()
+	if err != nil {
+		return err
+	}
+	current, err := ibu.Get(context.Background(), client)
+	if err != nil {
+		return err
+	}
+	return waitForStage(client, current.Spec.Stage)
+}
+
+func ibuTransition(action ibu.Action) error {
+	client, err := newIBUClient()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	current, err := ibu.Get(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	if err := ibu.ValidateTransition(current, action, isAfterPivot(current)); err != nil {
+		return err
+	}
+
+	var seedImageRef *lcav1alpha1.SeedImageRef
+	if action == ibu.Actions.Prep && (seedImage != "" || seedVersion != "") {
+		seedImageRef = &lcav1alpha1.SeedImageRef{Image: seedImage, Version: seedVersion}
+	}
+	if err := ibu.Transition(ctx, client, current, action, seedImageRef); err != nil {
+		return err
+	}
+	log.Infof("Requested the %s stage", action.Stage())
+
+	if ibuNoWait {
+		return nil
+	}
+	return waitForStage(client, action.Stage())
+}
+
+// isAfterPivot checks whether the node rebooted to the new stateroot. It is nil if rpm-ostree can't tell, e.g. when
+// not running on the node
+func isAfterPivot(current *lcav1alpha1.ImageBasedUpgrade) *bool {
+	var executor ops.Execute
+	if _, err := os.Stat(common.Host); err == nil {
+		executor = ops.NewNsenterExecutor(log, verbose)
+	} else {
+		executor = ops.NewRegularExecutor(log, verbose)
+	}
+	booted, err := ostree.NewClient("lca-cli", executor).IsStaterootBooted(common.GetDesiredStaterootName(current))
+	if err != nil {
+		log.Warnf("Unable to check the booted stateroot, validating against the valid next stages of the status: %v", err)
+		return nil
+	}
+	return &booted
+}
+
+func waitForStage(client runtimeClient.Client, stage lcav1alpha1.ImageBasedUpgradeStage) error {
+	ctx := context.Background()
+	if ibuTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ibuTimeout)
+		defer cancel()
+	}
+
+	return ibu.Wait(ctx, client, stage, ibuInterval, func(summary ibu.Summary, err error) {
+		if err != nil {
+			log.Warnf("Retrying: %v", err)
+			return
+		}
+		if ibuOutput == "json" {
+			if err := printIBUJSON(summary); err != nil {
+				log.Warn(err)
+			}
+			return
+		}
+		line := fmt.Sprintf("%s %s %s", summary.Time.Format(time.RFC3339), summary.Stage, summary.Phase)
+		if summary.Message != "" {
+			line += ": " + summary.Message
+		}
+		fmt.Println(line)
+	})
+}
+
+func printIBUJSON(summary ibu.Summary) error {
+	if err := json.NewEncoder(os.Stdout).Encode(summary); err != nil {
+		return fmt.Errorf("failed to encode status: %w", err)
+	}
+	return nil
+}
+
+func joinStages(stages []lcav1alpha1.ImageBasedUpgradeStage) string {
+	if len(stages) == 0 {
+		return "none"
+	}
+	names := make([]string, 0, len(stages))
+	for _, stage := range stages {
+		names = append(names, string(stage))
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,158 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd"
+	runtimeClient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/seedinspect"
+)
+
+var (
+	// seedOutput is the format of the seed reports, text or json
+	seedOutput string
+
+	// seedKubeconfig is the kubeconfig of the cluster the seed image is checked against
+	seedKubeconfig string
+
+	// seedFiles makes the seed commands pull the seed images to read their metadata files
+	seedFiles bool
+)
+
+// seedCmd groups the commands auditing seed images
+var seedCmd = &cobra.Command{
+	Use:   "seed",
+	Short: "Audit seed images before using them",
+}
+
+// seedInspectCmd represents the seed inspect command
+var seedInspectCmd = &cobra.Command{
+	Use:   "inspect <image>",
+	Short: "Report the content of a seed image, and its incompatibilities with a cluster",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := inspectSeed(args[0]); err != nil {
+			log.Fatalf("Error executing seed inspect command: %v", err)
+		}
+	},
+}
+
+// seedDiffCmd represents the seed diff command
+var seedDiffCmd = &cobra.Command{
+	Use:   "diff <image> <image>",
+	Short: "Compare the content of two seed images",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := diffSeeds(args[0], args[1]); err != nil {
+			log.Fatalf("Error executing seed diff command: %v", err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(seedCmd)
+	seedCmd.AddCommand(seedInspectCmd, seedDiffCmd)
+
+	seedCmd.PersistentFlags().StringVarP(&authFile, "authfile", "a", common.ImageRegistryAuthFile, "The path to the authentication file of the container registry.")
+	seedCmd.PersistentFlags().StringVarP(&seedOutput, "output", "o", "text", "The output format, text or json.")
+	seedCmd.PersistentFlags().BoolVarP(&seedFiles, "files", "", false, "Pull and mount the seed images to report their metadata files, such as the cluster info and the image list.")
+	seedCmd.PersistentFlags().BoolVarP(&inContainer, "in-container", "", false, "Use this flag if this command is being ran inside a container")
+	seedInspectCmd.Flags().StringVarP(&seedKubeconfig, "kubeconfig", "k", "", "The path to the kubeconfig of a cluster to check the seed image against.")
+}
+
+func newSeedInspector() *seedinspect.Inspector {
+	var hostCommandsExecutor ops.Execute
+	if inContainer {
+		hostCommandsExecutor = ops.NewNsenterExecutor(log, verbose)
+	} else {
+		hostCommandsExecutor = ops.NewRegularExecutor(log, verbose)
+	}
+	// The reports are written to stdout, the logs go to stderr
+	log.SetOutput(os.Stderr)
+	return seedinspect.NewInspector(log, ops.NewOps(log, hostCommandsExecutor), authFile, seedFiles)
+}
+
+func inspectSeed(image string) error {
+	if seedOutput != "text" && seedOutput != "json" {
+		return fmt.Errorf("unsupported output format %s", seedOutput)
+	}
+
+	report, err := newSeedInspector().Inspect(image)
+	if err != nil {
+		return fmt.Errorf("failed to inspect seed image: %w", err)
+	}
+
+	if seedKubeconfig != "" {
+		config, err := clientcmd.BuildConfigFromFlags("", seedKubeconfig)
+		if err != nil {
+			return fmt.Errorf("failed to create k8s config: %w", err)
+		}
+		client, err := runtimeClient.New(config, runtimeClient.Options{Scheme: scheme})
+		if err != nil {
+			return fmt.Errorf("failed to create runtime client: %w", err)
+		}
+		if err := seedinspect.Check(context.Background(), client, report); err != nil {
+			return fmt.Errorf("failed to check seed image compatibility: %w", err)
+		}
+	}
+
+	if seedOutput == "json" {
+		return printJSON(report)
+	}
+	return report.Print(os.Stdout) //nolint:wrapcheck
+}
+
+func diffSeeds(from, to string) error {
+	if seedOutput != "text" && seedOutput != "json" {
+		return fmt.Errorf("unsupported output format %s", seedOutput)
+	}
+
+	inspector := newSeedInspector()
+	fromReport, err := inspector.Inspect(from)
+	if err != nil {
+		return fmt.Errorf("failed to inspect seed image: %w", err)
+	}
+	toReport, err := inspector.Inspect(to)
+	if err != nil {
+		return fmt.Errorf("failed to inspect seed image: %w", err)
+	}
+
+	diff := seedinspect.Compare(fromReport, toReport)
+	if seedOutput == "json" {
+		return printJSON(diff)
+	}
+	diff.Print(os.Stdout)
+	return nil
+}
+
+func printJSON(v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal report: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
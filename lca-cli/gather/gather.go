@@ -0,0 +1,296 @@
+package gather
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/sirupsen/logrus"
+	velerov1 "github.com/vmware-tanzu/velero/pkg/apis/velero/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	lcav1alpha1 "github.com/openshift-kni/lifecycle-agent/api/v1alpha1"
+	"github.com/openshift-kni/lifecycle-agent/controllers/utils"
+	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/internal/recert"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
+	rpmostreeclient "github.com/openshift-kni/lifecycle-agent/lca-cli/ostreeclient"
+)
+
+const (
+	// IndexFile is the manifest at the root of the bundle, listing everything that was collected
+	IndexFile = "index.json"
+
+	// RecertSeedSummaryFile is the summary of the recert run when the seed image was created, kept in its /etc
+	RecertSeedSummaryFile = "/etc/kubernetes/recert-seed-summary.yaml"
+
+	// MaxFileSize is the size above which files are left out of the bundle
+	MaxFileSize = 20 * 1024 * 1024
+
+	// APITimeout bounds the time spent collecting from the API, which is down when the upgrade failed badly
+	APITimeout = 30 * time.Second
+)
+
+// JournalUnits are the service-units whose journal is collected from each stateroot
+var JournalUnits = []string{"installation-configuration.service", common.IBUInitMonitorService}
+
+// Entry describes an item of the bundle
+type Entry struct {
+	// Path is the path of the item in the bundle, relative to its root directory
+	Path string `json:"path"`
+	// Source is the file, command or API resource the item was collected from
+	Source   string `json:"source"`
+	Size     int    `json:"size"`
+	Redacted bool   `json:"redacted,omitempty"`
+	// Error is set if the item could not be collected, or only partially
+	Error string `json:"error,omitempty"`
+}
+
+// Index is the manifest of the bundle
+type Index struct {
+	Created         time.Time `json:"created"`
+	Hostname        string    `json:"hostname,omitempty"`
+	BootedStateroot string    `json:"bootedStateroot,omitempty"`
+	Stateroots      []string  `json:"stateroots,omitempty"`
+	Entries         []Entry   `json:"entries"`
+}
+
+// Gatherer collects the IBU diagnostics from the host, through the executor, and from the API if a client is set
+type Gatherer struct {
+	log      *logrus.Logger
+	executor ops.Execute
+	client   client.Client
+
+	index Index
+	root  string
+	tw    *tar.Writer
+	err   error
+}
+
+// NewGatherer returns a Gatherer. The client may be nil, e.g. when the kubeconfig can't be loaded
+func NewGatherer(log *logrus.Logger, executor ops.Execute, c client.Client) *Gatherer {
+	return &Gatherer{log: log, executor: executor, client: c}
+}
+
+// Gather writes a gzipped tarball of the diagnostics to w, under the root directory of the given name. Failures to
+// collect an item are recorded in the index rather than returned, so that as much as possible is collected
+func (g *Gatherer) Gather(ctx context.Context, w io.Writer, root string) error {
+	gz := gzip.NewWriter(w)
+	g.tw = tar.NewWriter(gz)
+	g.root = root
+	g.index = Index{Created: time.Now().UTC(), Entries: []Entry{}}
+	g.err = nil
+
+	if hostname, err := g.executor.Execute("hostname"); err == nil {
+		g.index.Hostname = hostname
+	}
+
+	g.gatherHost()
+	g.gatherStateroots()
+	g.gatherCluster(ctx)
+
+	data, err := json.MarshalIndent(g.index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal the gather index: %w", err)
+	}
+	g.write(IndexFile, data)
+	if g.err != nil {
+		return g.err
+	}
+
+	if err := g.tw.Close(); err != nil {
+		return fmt.Errorf("failed to close the gather tarball: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to close the gather tarball: %w", err)
+	}
+	return nil
+}
+
+func (g *Gatherer) gatherHost() {
+	output, err := g.executor.Execute("rpm-ostree", "status", "--json")
+	g.add("host/rpm-ostree-status.json", "rpm-ostree status --json", []byte(output), err)
+	if err == nil {
+		status := &rpmostreeclient.Status{}
+		if err := json.Unmarshal([]byte(output), status); err != nil {
+			g.log.Warnf("Failed to parse the rpm-ostree status: %v", err)
+		}
+		for _, deployment := range status.Deployments {
+			if deployment.Booted {
+				g.index.BootedStateroot = deployment.OSName
+			}
+			g.index.Stateroots = append(g.index.Stateroots, deployment.OSName)
+		}
+	}
+	g.command("host/rpm-ostree-status.txt", "rpm-ostree", "status", "-v")
+	g.command("host/journal/boots.txt", "journalctl", "--list-boots", "--no-pager")
+	g.file("host"+RecertSeedSummaryFile, RecertSeedSummaryFile)
+
+	if len(g.index.Stateroots) == 0 {
+		// Fall back on the stateroot directories, e.g. when rpm-ostree is not usable
+		output, err := g.executor.Execute("ls", "-1", common.GetStaterootPath(""))
+		if err != nil {
+			g.log.Warnf("Failed to list the stateroots: %v", err)
+			return
+		}
+		g.index.Stateroots = strings.Fields(output)
+	}
+	g.index.Stateroots = lo.Uniq(g.index.Stateroots)
+	sort.Strings(g.index.Stateroots)
+}
+
+// gatherStateroots collects the LCA files, the recert summary and the journal of the service-units of each stateroot,
+// as the failure may have happened in the other one
+func (g *Gatherer) gatherStateroots() {
+	for _, stateroot := range g.index.Stateroots {
+		staterootPath := common.GetStaterootPath(stateroot)
+		dir := path.Join("stateroots", stateroot)
+
+		g.dir(path.Join(dir, common.LCAConfigDir), filepath.Join(staterootPath, common.LCAConfigDir))
+		g.file(path.Join(dir, recert.SummaryFile), filepath.Join(staterootPath, recert.SummaryFile))
+
+		for _, unit := range JournalUnits {
+			args := []string{"--no-pager", "-o", "short-iso", "-u", unit}
+			if stateroot != g.index.BootedStateroot {
+				args = append(args, "-D", filepath.Join(staterootPath, "var/log/journal"))
+			}
+			g.command(path.Join(dir, "journal", unit+".log"), "journalctl", args...)
+		}
+	}
+}
+
+// gatherCluster collects the IBU, the OADP Backups and Restores and the IBU events, if the API is reachable
+func (g *Gatherer) gatherCluster(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, APITimeout)
+	defer cancel()
+
+	ibu := &lcav1alpha1.ImageBasedUpgrade{}
+	g.object(ctx, "cluster/imagebasedupgrade.json", "imagebasedupgrades.lca.openshift.io/"+utils.IBUName, func() (any, error) {
+		return ibu, g.client.Get(ctx, types.NamespacedName{Name: utils.IBUName}, ibu) //nolint:wrapcheck
+	})
+	g.object(ctx, "cluster/oadp-backups.json", "backups.velero.io", func() (any, error) {
+		backups := &velerov1.BackupList{}
+		return backups, g.client.List(ctx, backups) //nolint:wrapcheck
+	})
+	g.object(ctx, "cluster/oadp-restores.json", "restores.velero.io", func() (any, error) {
+		restores := &velerov1.RestoreList{}
+		return restores, g.client.List(ctx, restores) //nolint:wrapcheck
+	})
+	g.object(ctx, "cluster/imagebasedupgrade-events.json", "events involving the ImageBasedUpgrade", func() (any, error) {
+		// The events of cluster-scoped resources are in the default namespace
+		events := &corev1.EventList{}
+		if err := g.client.List(ctx, events, client.InNamespace(metav1.NamespaceDefault)); err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+		events.Items = lo.Filter(events.Items, func(event corev1.Event, _ int) bool {
+			return event.InvolvedObject.Kind == "ImageBasedUpgrade"
+		})
+		return events, nil
+	})
+}
+
+func (g *Gatherer) object(ctx context.Context, name, source string, get func() (any, error)) {
+	if g.client == nil {
+		g.add(name, source, nil, errors.New("no client for the API"))
+		return
+	}
+	if ctx.Err() != nil {
+		g.add(name, source, nil, fmt.Errorf("API unreachable: %w", ctx.Err()))
+		return
+	}
+
+	obj, err := get()
+	if err != nil {
+		g.add(name, source, nil, err)
+		return
+	}
+	data, err := json.MarshalIndent(obj, "", "  ")
+	g.add(name, source, data, err)
+}
+
+func (g *Gatherer) command(name, command string, args ...string) {
+	output, err := g.executor.Execute(command, args...)
+	g.add(name, strings.Join(append([]string{command}, args...), " "), []byte(output), err)
+}
+
+func (g *Gatherer) file(name, filename string) {
+	output, err := g.executor.Execute("cat", filename)
+	if err != nil {
+		// The output is the error message
+		output = ""
+	}
+	g.add(name, filename, []byte(output), err)
+}
+
+// dir collects the files under dir, except for the ones larger than MaxFileSize
+func (g *Gatherer) dir(name, dir string) {
+	output, err := g.executor.Execute("find", dir, "-type", "f", "-printf", `%s %p\n`)
+	if err != nil {
+		g.add(name, dir, nil, err)
+		return
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		size, filename, found := strings.Cut(line, " ")
+		if !found {
+			continue
+		}
+		rel, err := filepath.Rel(dir, filename)
+		if err != nil {
+			continue
+		}
+		if n, err := strconv.Atoi(size); err == nil && n > MaxFileSize {
+			g.add(path.Join(name, rel), filename, nil, fmt.Errorf("skipped, %d bytes is more than the %d bytes limit", n, MaxFileSize))
+			continue
+		}
+		g.file(path.Join(name, rel), filename)
+	}
+}
+
+// add records the item in the index, and writes it redacted to the bundle unless it is empty
+func (g *Gatherer) add(name, source string, data []byte, err error) {
+	entry := Entry{Path: name, Source: source}
+	if err != nil {
+		g.log.Warnf("Failed to collect %s: %v", source, err)
+		entry.Error = err.Error()
+	}
+	if len(data) > 0 {
+		data, entry.Redacted = Redact(data)
+		entry.Size = len(data)
+		g.write(name, data)
+	}
+	g.index.Entries = append(g.index.Entries, entry)
+}
+
+func (g *Gatherer) write(name string, data []byte) {
+	if g.err != nil {
+		return
+	}
+	header := &tar.Header{
+		Name:    path.Join(g.root, name),
+		Mode:    0o600,
+		Size:    int64(len(data)),
+		ModTime: g.index.Created,
+	}
+	if err := g.tw.WriteHeader(header); err != nil {
+		g.err = fmt.Errorf("failed to write %s to the gather tarball: %w", name, err)
+		return
+	}
+	if _, err := g.tw.Write(data); err != nil {
+		g.err = fmt.Errorf("failed to write %s to the gather tarball: %w", name, err)
+	}
+}
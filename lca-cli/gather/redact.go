@@ -0,0 +1,36 @@
+package gather
+
+import (
+	"regexp"
+)
+
+// Redacted replaces the sensitive values in the bundle
+const Redacted = "REDACTED"
+
+// sensitiveKeys are the keys holding the pull secret, the registry credentials, the kubeadmin password hash and the
+// private keys, in the seed reconfiguration, the recert config and summary, and the auth files
+const sensitiveKeys = `auth|password|identitytoken|registrytoken|pull_secret|pullSecret|kubeadmin_password_hash|kubeadmin|seedAuth|\.dockerconfigjson|[A-Za-z_]*private_key`
+
+var redactions = []struct {
+	re          *regexp.Regexp
+	replacement string
+}{
+	// JSON string values
+	{regexp.MustCompile(`("(?:` + sensitiveKeys + `)"\s*:\s*)"(?:[^"\\]|\\.)*"`), `${1}"` + Redacted + `"`},
+	// YAML values
+	{regexp.MustCompile(`(?m)^(\s*(?:-\s+)?(?:` + sensitiveKeys + `):[ \t]+)\S.*$`), `${1}` + Redacted},
+	// bcrypt hashes wherever they are, e.g. the kubeadmin password hash in the recert logs
+	{regexp.MustCompile(`\$2[aby]?\$\d{2}\$[./A-Za-z0-9]{53}`), Redacted},
+	// PEM private keys, JSON escaped or not
+	{regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`), Redacted},
+}
+
+// Redact returns the data with the pull secrets, registry credentials, password hashes and private keys replaced,
+// and whether anything was
+func Redact(data []byte) ([]byte, bool) {
+	redacted := data
+	for _, r := range redactions {
+		redacted = r.re.ReplaceAll(redacted, []byte(r.replacement))
+	}
+	return redacted, string(redacted) != string(data)
+}
@@ -1,6 +1,7 @@
 package ibi_preparation
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -61,8 +62,10 @@ func (i *IBIPrepare) Run() error {
 	log := logr.Logger{}
 	common.OstreeDeployPathPrefix = "/mnt/"
 	// Setup state root
-	if err := prep.SetupStateroot(log, i.ops, i.ostreeClient, i.rpmostreeClient,
-		i.seedImage, i.seedExpectedVersion, imageListFile, true); err != nil {
+	if err := prep.SetupStateroot(context.Background(), log, i.ops, i.ostreeClient, i.rpmostreeClient,
+		i.seedImage, i.seedExpectedVersion, imageListFile, true, func(progress string) {
+			i.log.Infof("Setting up stateroot: %s", progress)
+		}); err != nil {
 		return fmt.Errorf("failed to setup stateroot: %w", err)
 	}
 
@@ -90,7 +93,7 @@ func (i *IBIPrepare) precacheFlow(imageListFile string) error {
 		return fmt.Errorf("failed to create status file dir, err %w", err)
 	}
 	i.log.Infof("chroot %s successful", common.Host)
-	if err := workload.Precache(imageList, i.pullSecretFile, i.precacheBestEffort); err != nil {
+	if err := workload.Precache(imageList, nil, i.pullSecretFile, i.precacheBestEffort); err != nil {
 		return fmt.Errorf("failed to start precache: %w", err)
 	}
 	return nil
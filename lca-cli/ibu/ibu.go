@@ -0,0 +1,260 @@
+package ibu
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	lcav1alpha1 "github.com/openshift-kni/lifecycle-agent/api/v1alpha1"
+	"github.com/openshift-kni/lifecycle-agent/controllers/utils"
+)
+
+// Phase is the phase of the current stage of the IBU
+type Phase string
+
+// Phases defines the string values for the phases of a stage
+var Phases = struct {
+	InProgress Phase
+	Completed  Phase
+	Failed     Phase
+}{
+	InProgress: "InProgress",
+	Completed:  "Completed",
+	Failed:     "Failed",
+}
+
+// Summary is a brief status of the IBU
+type Summary struct {
+	Time time.Time `json:"time"`
+	// DesiredStage is spec.stage, and Stage the stage the conditions were last set for
+	DesiredStage    lcav1alpha1.ImageBasedUpgradeStage   `json:"desiredStage"`
+	Stage           lcav1alpha1.ImageBasedUpgradeStage   `json:"stage"`
+	Phase           Phase                                `json:"phase"`
+	Reason          string                               `json:"reason,omitempty"`
+	Message         string                               `json:"message,omitempty"`
+	Since           metav1.Time                          `json:"since"`
+	SeedImage       string                               `json:"seedImage,omitempty"`
+	SeedVersion     string                               `json:"seedVersion,omitempty"`
+	ValidNextStages []lcav1alpha1.ImageBasedUpgradeStage `json:"validNextStages"`
+	Conditions      []metav1.Condition                   `json:"conditions,omitempty"`
+}
+
+// Changed returns true if the stage, phase or message differs from the other summary
+func (s *Summary) Changed(other *Summary) bool {
+	return other == nil || s.DesiredStage != other.DesiredStage || s.Stage != other.Stage || s.Phase != other.Phase ||
+		s.Reason != other.Reason || s.Message != other.Message
+}
+
+// Summarize returns the summary of the IBU. The stages are looked at from the last one, Rollback, to Prep and Idle
+func Summarize(ibu *lcav1alpha1.ImageBasedUpgrade) Summary {
+	summary := Summary{
+		Time:            time.Now(),
+		DesiredStage:    ibu.Spec.Stage,
+		SeedImage:       ibu.Spec.SeedImageRef.Image,
+		SeedVersion:     ibu.Spec.SeedImageRef.Version,
+		ValidNextStages: ibu.Status.ValidNextStages,
+		Conditions:      ibu.Status.Conditions,
+	}
+	set := func(stage lcav1alpha1.ImageBasedUpgradeStage, phase Phase, condition *metav1.Condition) Summary {
+		summary.Stage = stage
+		summary.Phase = phase
+		summary.Reason = condition.Reason
+		summary.Message = condition.Message
+		summary.Since = condition.LastTransitionTime
+		return summary
+	}
+
+	for _, stage := range []lcav1alpha1.ImageBasedUpgradeStage{lcav1alpha1.Stages.Rollback, lcav1alpha1.Stages.Upgrade, lcav1alpha1.Stages.Prep} {
+		completed := utils.GetCompletedCondition(ibu, stage)
+		inProgress := utils.GetInProgressCondition(ibu, stage)
+		switch {
+		case completed != nil && completed.Status == metav1.ConditionTrue:
+			return set(stage, Phases.Completed, completed)
+		case completed != nil && inProgress != nil && inProgress.Status == metav1.ConditionFalse:
+			// The in-progress condition holds the reason of the failure
+			return set(stage, Phases.Failed, inProgress)
+		case inProgress != nil && inProgress.Status == metav1.ConditionTrue:
+			return set(stage, Phases.InProgress, inProgress)
+		}
+	}
+
+	idle := meta.FindStatusCondition(ibu.Status.Conditions, string(utils.ConditionTypes.Idle))
+	switch {
+	case idle == nil:
+		summary.Stage = lcav1alpha1.Stages.Idle
+		summary.Phase = Phases.InProgress
+		return summary
+	case idle.Status == metav1.ConditionTrue:
+		return set(lcav1alpha1.Stages.Idle, Phases.Completed, idle)
+	case isFailedReason(idle.Reason):
+		return set(lcav1alpha1.Stages.Idle, Phases.Failed, idle)
+	default:
+		return set(lcav1alpha1.Stages.Idle, Phases.InProgress, idle)
+	}
+}
+
+func isFailedReason(reason string) bool {
+	return lo.Contains([]string{string(utils.ConditionReasons.AbortFailed), string(utils.ConditionReasons.FinalizeFailed),
+		string(utils.ConditionReasons.InvalidTransition)}, reason)
+}
+
+// Done checks whether the stage reached a terminal condition since the operator handled the latest spec. It returns
+// an error if the stage failed or the transition was rejected
+func Done(ibu *lcav1alpha1.ImageBasedUpgrade, stage lcav1alpha1.ImageBasedUpgradeStage) (bool, error) {
+	if ibu.Status.ObservedGeneration < ibu.Generation {
+		return false, nil
+	}
+
+	if stage == lcav1alpha1.Stages.Idle {
+		idle := meta.FindStatusCondition(ibu.Status.Conditions, string(utils.ConditionTypes.Idle))
+		switch {
+		case idle == nil:
+			return false, nil
+		case idle.Status == metav1.ConditionTrue:
+			return true, nil
+		case isFailedReason(idle.Reason):
+			return true, fmt.Errorf("%s: %s", idle.Reason, idle.Message)
+		}
+		return false, nil
+	}
+
+	inProgress := utils.GetInProgressCondition(ibu, stage)
+	switch {
+	case inProgress != nil && inProgress.Status == metav1.ConditionFalse && inProgress.Reason == string(utils.ConditionReasons.InvalidTransition):
+		return true, fmt.Errorf("%s: %s", inProgress.Reason, inProgress.Message)
+	case utils.IsStageCompleted(ibu, stage):
+		return true, nil
+	case utils.IsStageFailed(ibu, stage) && !utils.IsStageInProgress(ibu, stage):
+		if inProgress != nil {
+			return true, fmt.Errorf("%s failed: %s: %s", stage, inProgress.Reason, inProgress.Message)
+		}
+		return true, fmt.Errorf("%s failed", stage)
+	}
+	return false, nil
+}
+
+// Action is a transition requested by an ibu command
+type Action string
+
+// Actions defines the transitions requested by the ibu commands, finalize and abort both requesting the Idle stage
+var Actions = struct {
+	Prep     Action
+	Upgrade  Action
+	Rollback Action
+	Finalize Action
+	Abort    Action
+}{
+	Prep:     "prep",
+	Upgrade:  "upgrade",
+	Rollback: "rollback",
+	Finalize: "finalize",
+	Abort:    "abort",
+}
+
+// Stage returns the stage requested by the action
+func (a Action) Stage() lcav1alpha1.ImageBasedUpgradeStage {
+	switch a {
+	case Actions.Prep:
+		return lcav1alpha1.Stages.Prep
+	case Actions.Upgrade:
+		return lcav1alpha1.Stages.Upgrade
+	case Actions.Rollback:
+		return lcav1alpha1.Stages.Rollback
+	default:
+		return lcav1alpha1.Stages.Idle
+	}
+}
+
+// ValidateTransition checks that the action is allowed, with the same rules as the operator. The valid next stages
+// are computed from the conditions when it is known whether the node rebooted to the new stateroot, and taken from
+// the status otherwise
+func ValidateTransition(ibu *lcav1alpha1.ImageBasedUpgrade, action Action, isAfterPivot *bool) error {
+	validNextStages := ibu.Status.ValidNextStages
+	if isAfterPivot != nil {
+		validNextStages = utils.GetValidNextStages(ibu, *isAfterPivot)
+	}
+
+	stage := action.Stage()
+	if !lo.Contains(validNextStages, stage) {
+		return fmt.Errorf("cannot %s: transition to the %s stage is not allowed, valid next stages are %v", action, stage, validNextStages)
+	}
+
+	if stage == lcav1alpha1.Stages.Idle {
+		finalize := lo.SomeBy(utils.FinalConditionTypes, func(conditionType utils.ConditionType) bool {
+			return meta.IsStatusConditionTrue(ibu.Status.Conditions, string(conditionType))
+		})
+		if action == Actions.Finalize && !finalize {
+			return fmt.Errorf("cannot finalize: neither the Upgrade nor the Rollback stage completed, abort instead")
+		}
+		if action == Actions.Abort && finalize {
+			return fmt.Errorf("cannot abort: the %s stage completed, finalize instead", Summarize(ibu).Stage)
+		}
+	}
+	return nil
+}
+
+// Transition patches the stage of the IBU, and the seed image reference if set
+func Transition(ctx context.Context, c client.Client, ibu *lcav1alpha1.ImageBasedUpgrade, action Action, seedImageRef *lcav1alpha1.SeedImageRef) error {
+	patch := client.MergeFrom(ibu.DeepCopy())
+	ibu.Spec.Stage = action.Stage()
+	if seedImageRef != nil {
+		if seedImageRef.Image != "" {
+			ibu.Spec.SeedImageRef.Image = seedImageRef.Image
+		}
+		if seedImageRef.Version != "" {
+			ibu.Spec.SeedImageRef.Version = seedImageRef.Version
+		}
+	}
+	if err := c.Patch(ctx, ibu, patch); err != nil {
+		return fmt.Errorf("failed to patch %s: %w", ibu.Name, err)
+	}
+	return nil
+}
+
+// Get returns the IBU
+func Get(ctx context.Context, c client.Reader) (*lcav1alpha1.ImageBasedUpgrade, error) {
+	ibu := &lcav1alpha1.ImageBasedUpgrade{}
+	if err := c.Get(ctx, types.NamespacedName{Name: utils.IBUName}, ibu); err != nil {
+		return nil, fmt.Errorf("failed to get %s: %w", utils.IBUName, err)
+	}
+	return ibu, nil
+}
+
+// Wait polls the IBU until the stage reaches a terminal condition, and reports the summary every time it changes.
+// Failures to get the IBU are reported and retried, as the API is unreachable while the node reboots
+func Wait(ctx context.Context, c client.Reader, stage lcav1alpha1.ImageBasedUpgradeStage, interval time.Duration,
+	report func(summary Summary, err error)) error {
+	var last *Summary
+	var lastErr error
+	for {
+		ibu, err := Get(ctx, c)
+		if err != nil {
+			if lastErr == nil || lastErr.Error() != err.Error() {
+				report(Summary{}, err)
+			}
+			lastErr = err
+		} else {
+			lastErr = nil
+			summary := Summarize(ibu)
+			if summary.Changed(last) {
+				report(summary, nil)
+				last = &summary
+			}
+			if done, err := Done(ibu, stage); done {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for the %s stage: %w", stage, ctx.Err())
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package ibu
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	lcav1alpha1 "github.com/openshift-kni/lifecycle-agent/api/v1alpha1"
+	"github.com/openshift-kni/lifecycle-agent/controllers/utils"
+)
+
+func newIBU(generation, observedGeneration int64, stage lcav1alpha1.ImageBasedUpgradeStage, setConditions ...func(ibu *lcav1alpha1.ImageBasedUpgrade)) *lcav1alpha1.ImageBasedUpgrade {
+	ibu := &lcav1alpha1.ImageBasedUpgrade{
+		ObjectMeta: metav1.ObjectMeta{Name: utils.IBUName, Generation: generation},
+		Spec:       lcav1alpha1.ImageBasedUpgradeSpec{Stage: stage},
+		Status:     lcav1alpha1.ImageBasedUpgradeStatus{ObservedGeneration: observedGeneration},
+	}
+	for _, set := range setConditions {
+		set(ibu)
+	}
+	return ibu
+}
+
+func idle(ibu *lcav1alpha1.ImageBasedUpgrade) {
+	utils.ResetStatusConditions(&ibu.Status.Conditions, ibu.Generation)
+}
+
+func idleFalse(reason utils.ConditionReason, msg string) func(ibu *lcav1alpha1.ImageBasedUpgrade) {
+	return func(ibu *lcav1alpha1.ImageBasedUpgrade) {
+		utils.SetStatusCondition(&ibu.Status.Conditions, utils.ConditionTypes.Idle, reason, metav1.ConditionFalse, msg, ibu.Generation)
+	}
+}
+
+func prepInProgress(ibu *lcav1alpha1.ImageBasedUpgrade) {
+	utils.SetPrepStatusInProgress(ibu, "Pulling the seed image")
+}
+
+func prepCompleted(ibu *lcav1alpha1.ImageBasedUpgrade) {
+	utils.SetPrepStatusCompleted(ibu, "Prep stage completed successfully")
+}
+
+func upgradeFailed(ibu *lcav1alpha1.ImageBasedUpgrade) {
+	utils.SetUpgradeStatusFailed(ibu, "health check failed")
+}
+
+func upgradeCompleted(ibu *lcav1alpha1.ImageBasedUpgrade) {
+	utils.SetUpgradeStatusCompleted(ibu)
+}
+
+func TestSummarize(t *testing.T) {
+	testcases := []struct {
+		name        string
+		ibu         *lcav1alpha1.ImageBasedUpgrade
+		wantStage   lcav1alpha1.ImageBasedUpgradeStage
+		wantPhase   Phase
+		wantMessage string
+	}{
+		{
+			name:        "idle",
+			ibu:         newIBU(1, 1, lcav1alpha1.Stages.Idle, idle),
+			wantStage:   lcav1alpha1.Stages.Idle,
+			wantPhase:   Phases.Completed,
+			wantMessage: "Idle",
+		},
+		{
+			name:        "prep in progress",
+			ibu:         newIBU(2, 2, lcav1alpha1.Stages.Prep, idleFalse(utils.ConditionReasons.InProgress, "In progress"), prepInProgress),
+			wantStage:   lcav1alpha1.Stages.Prep,
+			wantPhase:   Phases.InProgress,
+			wantMessage: "Pulling the seed image",
+		},
+		{
+			name: "upgrade failed",
+			ibu: newIBU(3, 3, lcav1alpha1.Stages.Upgrade, idleFalse(utils.ConditionReasons.InProgress, "In progress"),
+				prepCompleted, upgradeFailed),
+			wantStage:   lcav1alpha1.Stages.Upgrade,
+			wantPhase:   Phases.Failed,
+			wantMessage: "health check failed",
+		},
+		{
+			name:        "finalize failed",
+			ibu:         newIBU(4, 4, lcav1alpha1.Stages.Idle, idleFalse(utils.ConditionReasons.FinalizeFailed, "failed to clean up")),
+			wantStage:   lcav1alpha1.Stages.Idle,
+			wantPhase:   Phases.Failed,
+			wantMessage: "failed to clean up",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			summary := Summarize(tc.ibu)
+			assert.Equal(t, tc.wantStage, summary.Stage)
+			assert.Equal(t, tc.wantPhase, summary.Phase)
+			assert.Equal(t, tc.wantMessage, summary.Message)
+		})
+	}
+}
+
+func TestDone(t *testing.T) {
+	testcases := []struct {
+		name     string
+		ibu      *lcav1alpha1.ImageBasedUpgrade
+		stage    lcav1alpha1.ImageBasedUpgradeStage
+		wantDone bool
+		wantErr  bool
+	}{
+		{
+			name:  "spec not handled yet",
+			ibu:   newIBU(3, 2, lcav1alpha1.Stages.Upgrade, idleFalse(utils.ConditionReasons.InProgress, "In progress"), prepCompleted),
+			stage: lcav1alpha1.Stages.Upgrade,
+		},
+		{
+			name:  "prep in progress",
+			ibu:   newIBU(2, 2, lcav1alpha1.Stages.Prep, idleFalse(utils.ConditionReasons.InProgress, "In progress"), prepInProgress),
+			stage: lcav1alpha1.Stages.Prep,
+		},
+		{
+			name:     "prep completed",
+			ibu:      newIBU(2, 2, lcav1alpha1.Stages.Prep, idleFalse(utils.ConditionReasons.InProgress, "In progress"), prepCompleted),
+			stage:    lcav1alpha1.Stages.Prep,
+			wantDone: true,
+		},
+		{
+			name: "upgrade failed",
+			ibu: newIBU(3, 3, lcav1alpha1.Stages.Upgrade, idleFalse(utils.ConditionReasons.InProgress, "In progress"),
+				prepCompleted, upgradeFailed),
+			stage:    lcav1alpha1.Stages.Upgrade,
+			wantDone: true,
+			wantErr:  true,
+		},
+		{
+			name: "invalid transition",
+			ibu: newIBU(2, 2, lcav1alpha1.Stages.Upgrade, idle, func(ibu *lcav1alpha1.ImageBasedUpgrade) {
+				utils.SetStatusCondition(&ibu.Status.Conditions, utils.ConditionTypes.UpgradeInProgress,
+					utils.ConditionReasons.InvalidTransition, metav1.ConditionFalse, "Previous stage not succeeded", ibu.Generation)
+			}),
+			stage:    lcav1alpha1.Stages.Upgrade,
+			wantDone: true,
+			wantErr:  true,
+		},
+		{
+			name:  "finalizing",
+			ibu:   newIBU(4, 4, lcav1alpha1.Stages.Idle, idleFalse(utils.ConditionReasons.Finalizing, "Finalizing")),
+			stage: lcav1alpha1.Stages.Idle,
+		},
+		{
+			name:     "finalized",
+			ibu:      newIBU(4, 4, lcav1alpha1.Stages.Idle, idle),
+			stage:    lcav1alpha1.Stages.Idle,
+			wantDone: true,
+		},
+		{
+			name:     "abort failed",
+			ibu:      newIBU(4, 4, lcav1alpha1.Stages.Idle, idleFalse(utils.ConditionReasons.AbortFailed, "failed to abort")),
+			stage:    lcav1alpha1.Stages.Idle,
+			wantDone: true,
+			wantErr:  true,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			done, err := Done(tc.ibu, tc.stage)
+			assert.Equal(t, tc.wantDone, done)
+			assert.Equal(t, tc.wantErr, err != nil)
+		})
+	}
+}
+
+func TestValidateTransition(t *testing.T) {
+	afterPivot := true
+	beforePivot := false
+	testcases := []struct {
+		name         string
+		ibu          *lcav1alpha1.ImageBasedUpgrade
+		action       Action
+		isAfterPivot *bool
+		wantErr      bool
+	}{
+		{
+			name:         "prep from idle",
+			ibu:          newIBU(1, 1, lcav1alpha1.Stages.Idle, idle),
+			action:       Actions.Prep,
+			isAfterPivot: &beforePivot,
+		},
+		{
+			name:         "upgrade from idle",
+			ibu:          newIBU(1, 1, lcav1alpha1.Stages.Idle, idle),
+			action:       Actions.Upgrade,
+			isAfterPivot: &beforePivot,
+			wantErr:      true,
+		},
+		{
+			name:         "abort after prep",
+			ibu:          newIBU(2, 2, lcav1alpha1.Stages.Prep, idleFalse(utils.ConditionReasons.InProgress, "In progress"), prepCompleted),
+			action:       Actions.Abort,
+			isAfterPivot: &beforePivot,
+		},
+		{
+			name:         "finalize after prep",
+			ibu:          newIBU(2, 2, lcav1alpha1.Stages.Prep, idleFalse(utils.ConditionReasons.InProgress, "In progress"), prepCompleted),
+			action:       Actions.Finalize,
+			isAfterPivot: &beforePivot,
+			wantErr:      true,
+		},
+		{
+			name: "rollback after a failed upgrade",
+			ibu: newIBU(3, 3, lcav1alpha1.Stages.Upgrade, idleFalse(utils.ConditionReasons.InProgress, "In progress"),
+				prepCompleted, upgradeFailed),
+			action:       Actions.Rollback,
+			isAfterPivot: &afterPivot,
+		},
+		{
+			name: "abort after a failed upgrade before the pivot",
+			ibu: newIBU(3, 3, lcav1alpha1.Stages.Upgrade, idleFalse(utils.ConditionReasons.InProgress, "In progress"),
+				prepCompleted, upgradeFailed),
+			action:       Actions.Abort,
+			isAfterPivot: &beforePivot,
+		},
+		{
+			name: "abort after the upgrade completed",
+			ibu: newIBU(3, 3, lcav1alpha1.Stages.Upgrade, idleFalse(utils.ConditionReasons.InProgress, "In progress"),
+				prepCompleted, upgradeCompleted),
+			action:       Actions.Abort,
+			isAfterPivot: &afterPivot,
+			wantErr:      true,
+		},
+		{
+			name: "finalize from the valid next stages of the status",
+			ibu: newIBU(3, 3, lcav1alpha1.Stages.Upgrade, idleFalse(utils.ConditionReasons.InProgress, "In progress"),
+				prepCompleted, upgradeCompleted, func(ibu *lcav1alpha1.ImageBasedUpgrade) {
+					ibu.Status.ValidNextStages = []lcav1alpha1.ImageBasedUpgradeStage{lcav1alpha1.Stages.Idle, lcav1alpha1.Stages.Rollback}
+				}),
+			action: Actions.Finalize,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateTransition(tc.ibu, tc.action, tc.isAfterPivot)
+			assert.Equal(t, tc.wantErr, err != nil, "error: %v", err)
+		})
+	}
+}
+
+func TestTransitionAndWait(t *testing.T) {
+	testscheme := runtime.NewScheme()
+	assert.NoError(t, lcav1alpha1.AddToScheme(testscheme))
+	c := fake.NewClientBuilder().WithScheme(testscheme).WithObjects(newIBU(1, 1, lcav1alpha1.Stages.Idle, idle)).
+		WithStatusSubresource(&lcav1alpha1.ImageBasedUpgrade{}).Build()
+	ctx := context.Background()
+
+	current, err := Get(ctx, c)
+	assert.NoError(t, err)
+	assert.NoError(t, Transition(ctx, c, current, Actions.Prep, &lcav1alpha1.SeedImageRef{Image: "quay.io/openshift-kni/seed:4.16.0", Version: "4.16.0"}))
+
+	current, err = Get(ctx, c)
+	assert.NoError(t, err)
+	assert.Equal(t, lcav1alpha1.Stages.Prep, current.Spec.Stage)
+	assert.Equal(t, "4.16.0", current.Spec.SeedImageRef.Version)
+
+	// Play the operator: the stage progresses and completes while waiting
+	steps := []func(ibu *lcav1alpha1.ImageBasedUpgrade){
+		func(ibu *lcav1alpha1.ImageBasedUpgrade) {
+			ibu.Status.ObservedGeneration = ibu.Generation
+			idleFalse(utils.ConditionReasons.InProgress, "In progress")(ibu)
+			prepInProgress(ibu)
+		},
+		prepCompleted,
+	}
+	var reported []Phase
+	err = Wait(ctx, c, lcav1alpha1.Stages.Prep, time.Millisecond, func(summary Summary, err error) {
+		assert.NoError(t, err)
+		reported = append(reported, summary.Phase)
+		if len(steps) > 0 {
+			ibu, err := Get(ctx, c)
+			assert.NoError(t, err)
+			steps[0](ibu)
+			steps = steps[1:]
+			assert.NoError(t, c.Status().Update(ctx, ibu))
+		}
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []Phase{Phases.Completed, Phases.InProgress, Phases.Completed}, reported)
+}
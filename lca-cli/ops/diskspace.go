@@ -0,0 +1,74 @@
+package ops
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// InsufficientDiskSpaceError is returned when a filesystem doesn't have room for the data that is about to be written
+type InsufficientDiskSpaceError struct {
+	MountPoint string
+	Paths      []string
+	Required   uint64
+	Available  uint64
+}
+
+func (e *InsufficientDiskSpaceError) Error() string {
+	return fmt.Sprintf("insufficient disk space on %s (used by %s): %s required, %s available",
+		e.MountPoint, strings.Join(e.Paths, ", "), FormatBytes(e.Required), FormatBytes(e.Available))
+}
+
+// IsInsufficientDiskSpaceError returns true if the error, or any error it wraps, is an InsufficientDiskSpaceError
+func IsInsufficientDiskSpaceError(err error) bool {
+	var diskErr *InsufficientDiskSpaceError
+	return errors.As(err, &diskErr)
+}
+
+// CheckDiskSpace compares the space required under each path, in bytes, with the space available on the host.
+// Paths on the same filesystem are summed up, e.g. /var/tmp and /var/lib/containers both belong to /sysroot on RHCOS
+func CheckDiskSpace(ops Ops, required map[string]uint64) error {
+	paths := make([]string, 0, len(required))
+	for path := range required {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var mountPoints []string
+	errs := make(map[string]*InsufficientDiskSpaceError)
+	for _, path := range paths {
+		space, err := ops.GetDiskSpace(path)
+		if err != nil {
+			return err
+		}
+		if _, ok := errs[space.MountPoint]; !ok {
+			mountPoints = append(mountPoints, space.MountPoint)
+			errs[space.MountPoint] = &InsufficientDiskSpaceError{MountPoint: space.MountPoint, Available: space.Available}
+		}
+		errs[space.MountPoint].Paths = append(errs[space.MountPoint].Paths, path)
+		errs[space.MountPoint].Required += required[path]
+	}
+
+	var allErrs []error
+	for _, mountPoint := range mountPoints {
+		if err := errs[mountPoint]; err.Required > err.Available {
+			allErrs = append(allErrs, err)
+		}
+	}
+	return errors.Join(allErrs...) //nolint:wrapcheck
+}
+
+// FormatBytes returns a human-readable size, e.g. 1.5GiB
+func FormatBytes(size uint64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%dB", size)
+	}
+	div, exp := uint64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,58 @@
+package ops
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCheckDiskSpace(t *testing.T) {
+	const gib = 1024 * 1024 * 1024
+
+	testcases := []struct {
+		name        string
+		required    map[string]uint64
+		wantErr     bool
+		errContains string
+	}{
+		{
+			name:     "enough space",
+			required: map[string]uint64{"/var/lib/containers": 10 * gib, "/boot": 100},
+		},
+		{
+			name:        "paths on the same filesystem are summed up",
+			required:    map[string]uint64{"/var/lib/containers": 15 * gib, "/sysroot": 10 * gib},
+			wantErr:     true,
+			errContains: "insufficient disk space on /sysroot (used by /sysroot, /var/lib/containers): 25.0GiB required, 20.0GiB available",
+		},
+		{
+			name:        "small filesystem",
+			required:    map[string]uint64{"/boot": 2 * gib},
+			wantErr:     true,
+			errContains: "insufficient disk space on /boot (used by /boot): 2.0GiB required, 512.0MiB available",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockOps := NewMockOps(ctrl)
+			mockOps.EXPECT().GetDiskSpace(gomock.Any()).DoAndReturn(func(path string) (*DiskSpace, error) {
+				if path == "/boot" {
+					return &DiskSpace{MountPoint: "/boot", Available: gib / 2}, nil
+				}
+				return &DiskSpace{MountPoint: "/sysroot", Available: 20 * gib}, nil
+			}).AnyTimes()
+
+			err := CheckDiskSpace(mockOps, tc.required)
+			if !tc.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+			assert.True(t, IsInsufficientDiskSpaceError(err))
+			assert.ErrorContains(t, err, tc.errContains)
+		})
+	}
+}
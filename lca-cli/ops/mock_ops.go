@@ -65,6 +65,21 @@ func (mr *MockOpsMockRecorder) ForceExpireSeedCrypto(recertContainerImage, authF
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForceExpireSeedCrypto", reflect.TypeOf((*MockOps)(nil).ForceExpireSeedCrypto), recertContainerImage, authFile)
 }
 
+// GetDiskSpace mocks base method.
+func (m *MockOps) GetDiskSpace(path string) (*DiskSpace, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDiskSpace", path)
+	ret0, _ := ret[0].(*DiskSpace)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDiskSpace indicates an expected call of GetDiskSpace.
+func (mr *MockOpsMockRecorder) GetDiskSpace(path any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDiskSpace", reflect.TypeOf((*MockOps)(nil).GetDiskSpace), path)
+}
+
 // ImageExists mocks base method.
 func (m *MockOps) ImageExists(img string) (bool, error) {
 	m.ctrl.T.Helper()
@@ -8,6 +8,7 @@ import (
 	"os"
 	"os/exec"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 
@@ -43,6 +44,7 @@ type Ops interface {
 	ListBlockDevices() ([]BlockDevice, error)
 	Mount(deviceName, mountFolder string) error
 	Umount(deviceName string) error
+	GetDiskSpace(path string) (*DiskSpace, error)
 }
 
 type BlockDevice struct {
@@ -50,6 +52,12 @@ type BlockDevice struct {
 	Label string
 }
 
+// DiskSpace is the space available on the filesystem a path belongs to
+type DiskSpace struct {
+	MountPoint string
+	Available  uint64
+}
+
 type ops struct {
 	log                  *logrus.Logger
 	hostCommandsExecutor Execute
@@ -216,9 +224,18 @@ func (o *ops) RunRecert(recertContainerImage, authFile, recertConfigFile string,
 	return nil
 }
 
+// TarCompressionFlag returns the tar option to decompress the archive, a zstd archive in the seed images of the
+// zstd seed format and a gzip one before
+func TarCompressionFlag(archive string) string {
+	if strings.HasSuffix(archive, ".zst") {
+		return "--zstd"
+	}
+	return "--gzip"
+}
+
 func (o *ops) ExtractTarWithSELinux(srcPath, destPath string) error {
 	if _, err := o.hostCommandsExecutor.Execute(
-		"tar", "xzf", srcPath, "-C", destPath, "--selinux",
+		"tar", "xf", srcPath, TarCompressionFlag(srcPath), "-C", destPath, "--selinux", "--xattrs", "--xattrs-include=*",
 	); err != nil {
 		return fmt.Errorf("failed to extract tar with SELinux with sourcePath %s and destPath %s: %w", srcPath, destPath, err)
 	}
@@ -376,3 +393,27 @@ func (o *ops) Umount(deviceName string) error {
 	}
 	return nil
 }
+
+// GetDiskSpace returns the mount point and the space available, in bytes, of the filesystem the path belongs to
+func (o *ops) GetDiskSpace(path string) (*DiskSpace, error) {
+	output, err := o.RunInHostNamespace("df", "--output=target,avail", "-B1", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get disk space of %s, err: %w", path, err)
+	}
+
+	// The first line is the header, e.g. "Mounted on Avail"
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if len(lines) != 2 {
+		return nil, fmt.Errorf("unexpected df output for %s: %s", path, output)
+	}
+	fields := strings.Fields(lines[1])
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("unexpected df output for %s: %s", path, output)
+	}
+	available, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse available disk space of %s, err: %w", path, err)
+	}
+
+	return &DiskSpace{MountPoint: fields[0], Available: available}, nil
+}
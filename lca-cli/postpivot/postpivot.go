@@ -13,6 +13,7 @@ import (
 
 	clusterconfig_api "github.com/openshift-kni/lifecycle-agent/api/seedreconfig"
 	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/internal/progress"
 	"github.com/openshift-kni/lifecycle-agent/internal/recert"
 	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
 	"github.com/openshift-kni/lifecycle-agent/lca-cli/seedclusterinfo"
@@ -79,37 +80,85 @@ const (
 	dnsmasqService = "dnsmasq.service"
 )
 
+// postPivotSteps defines the names of the post-pivot configuration steps, as recorded in the progress journal
+var postPivotSteps = struct {
+	WaitForConfiguration          string
+	ReadConfiguration             string
+	NetworkConfiguration          string
+	SetSSHKey                     string
+	PullSecret                    string
+	Recert                        string
+	StartCluster                  string
+	DeleteOldMirrorResources      string
+	ApplyManifests                string
+	ChangeRegistryInCSVDeployment string
+	SetClusterID                  string
+	RecoverLvmDevices             string
+	Cleanup                       string
+}{
+	WaitForConfiguration:          "WaitForConfiguration",
+	ReadConfiguration:             "ReadConfiguration",
+	NetworkConfiguration:          "NetworkConfiguration",
+	SetSSHKey:                     "SetSSHKey",
+	PullSecret:                    "PullSecret",
+	Recert:                        "Recert",
+	StartCluster:                  "StartCluster",
+	DeleteOldMirrorResources:      "DeleteOldMirrorResources",
+	ApplyManifests:                "ApplyManifests",
+	ChangeRegistryInCSVDeployment: "ChangeRegistryInCSVDeployment",
+	SetClusterID:                  "SetClusterID",
+	RecoverLvmDevices:             "RecoverLvmDevices",
+	Cleanup:                       "Cleanup",
+}
+
 func (p *PostPivot) PostPivotConfiguration(ctx context.Context) error {
+	// The progress of the steps is reported by the LCA Operator once the cluster is up
+	journal := progress.NewJournal(progress.JournalFile, p.log)
 
-	if err := p.waitForConfiguration(ctx, filepath.Join(common.OptOpenshift, common.ClusterConfigDir), blockDeviceMountFolder); err != nil {
+	if err := journal.Run(postPivotSteps.WaitForConfiguration, func() error {
+		return p.waitForConfiguration(ctx, filepath.Join(common.OptOpenshift, common.ClusterConfigDir), blockDeviceMountFolder)
+	}); err != nil {
 		return err
 	}
 
-	p.log.Info("Reading seed image info")
-	seedClusterInfo, err := seedclusterinfo.ReadSeedClusterInfoFromFile(path.Join(common.SeedDataDir, common.SeedClusterInfoFileName))
-	if err != nil {
-		return fmt.Errorf("failed to get seed info from %s, err: %w", "", err)
-	}
+	var seedClusterInfo *seedclusterinfo.SeedClusterInfo
+	var seedReconfiguration *clusterconfig_api.SeedReconfiguration
+	if err := journal.Run(postPivotSteps.ReadConfiguration, func() error {
+		var err error
+		p.log.Info("Reading seed image info")
+		seedClusterInfo, err = seedclusterinfo.ReadSeedClusterInfoFromFile(path.Join(common.SeedDataDir, common.SeedClusterInfoFileName))
+		if err != nil {
+			return fmt.Errorf("failed to get seed info from %s, err: %w", "", err)
+		}
 
-	p.log.Info("Reading seed reconfiguration info")
-	seedReconfiguration, err := utils.ReadSeedReconfigurationFromFile(
-		path.Join(p.workingDir, common.ClusterConfigDir, common.SeedClusterInfoFileName))
-	if err != nil {
-		return fmt.Errorf("failed to get cluster info from %s, err: %w", "", err)
+		p.log.Info("Reading seed reconfiguration info")
+		seedReconfiguration, err = utils.ReadSeedReconfigurationFromFile(
+			path.Join(p.workingDir, common.ClusterConfigDir, common.SeedClusterInfoFileName))
+		if err != nil {
+			return fmt.Errorf("failed to get cluster info from %s, err: %w", "", err)
+		}
+		return nil
+	}); err != nil {
+		return err
 	}
 
-	if err := p.networkConfiguration(ctx, seedReconfiguration); err != nil {
+	if err := journal.Run(postPivotSteps.NetworkConfiguration, func() error {
+		return p.networkConfiguration(ctx, seedReconfiguration)
+	}); err != nil {
 		return fmt.Errorf("failed to configure networking, err: %w", err)
 	}
 
-	if err := utils.RunOnce("setSSHKey", p.workingDir, p.log, p.setSSHKey,
-		seedReconfiguration, sshKeyEarlyAccessFile); err != nil {
+	if err := journal.Run(postPivotSteps.SetSSHKey, func() error {
+		return utils.RunOnce("setSSHKey", p.workingDir, p.log, p.setSSHKey, seedReconfiguration, sshKeyEarlyAccessFile)
+	}); err != nil {
 		return fmt.Errorf("failed to run once setSSHKey for post pivot: %w", err)
 	}
 
-	if err := utils.RunOnce("pull-secret", p.workingDir, p.log, p.createPullSecretFileAndManifest,
-		seedReconfiguration.PullSecret, common.ImageRegistryAuthFile, path.Join(p.workingDir, common.ClusterConfigDir,
-			common.ManifestsDir, pullSecretFileName)); err != nil {
+	if err := journal.Run(postPivotSteps.PullSecret, func() error {
+		return utils.RunOnce("pull-secret", p.workingDir, p.log, p.createPullSecretFileAndManifest,
+			seedReconfiguration.PullSecret, common.ImageRegistryAuthFile, path.Join(p.workingDir, common.ClusterConfigDir,
+				common.ManifestsDir, pullSecretFileName))
+	}); err != nil {
 		return fmt.Errorf("failed to run once pull-secret for post pivot: %w", err)
 	}
 
@@ -117,56 +166,79 @@ func (p *PostPivot) PostPivotConfiguration(ctx context.Context) error {
 		return fmt.Errorf("unsupported seed reconfiguration version %d", seedReconfiguration.APIVersion)
 	}
 
-	if err := utils.RunOnce("recert", p.workingDir, p.log, p.recert, ctx, seedReconfiguration, seedClusterInfo); err != nil {
+	if err := journal.Run(postPivotSteps.Recert, func() error {
+		return utils.RunOnce("recert", p.workingDir, p.log, p.recert, ctx, seedReconfiguration, seedClusterInfo)
+	}); err != nil {
 		return fmt.Errorf("failed to run once recert for post pivot: %w", err)
 	}
 
-	if err := p.copyClusterConfigFiles(); err != nil {
-		return fmt.Errorf("failed copy cluster config files: %w", err)
-	}
+	var client runtimeclient.Client
+	if err := journal.Run(postPivotSteps.StartCluster, func() error {
+		if err := p.copyClusterConfigFiles(); err != nil {
+			return fmt.Errorf("failed copy cluster config files: %w", err)
+		}
 
-	client, err := utils.CreateKubeClient(p.scheme, p.kubeconfig)
-	if err != nil {
-		return fmt.Errorf("failed to create k8s client, err: %w", err)
-	}
+		var err error
+		client, err = utils.CreateKubeClient(p.scheme, p.kubeconfig)
+		if err != nil {
+			return fmt.Errorf("failed to create k8s client, err: %w", err)
+		}
 
-	if _, err := p.ops.SystemctlAction("enable", "kubelet", "--now"); err != nil {
-		return fmt.Errorf("failed to enable kubelet: %w", err)
+		if _, err := p.ops.SystemctlAction("enable", "kubelet", "--now"); err != nil {
+			return fmt.Errorf("failed to enable kubelet: %w", err)
+		}
+		p.waitForApi(ctx, client)
+		return nil
+	}); err != nil {
+		return err
 	}
-	p.waitForApi(ctx, client)
 
-	if err := p.deleteAllOldMirrorResources(ctx, client); err != nil {
+	if err := journal.Run(postPivotSteps.DeleteOldMirrorResources, func() error {
+		return p.deleteAllOldMirrorResources(ctx, client)
+	}); err != nil {
 		return fmt.Errorf("failed to all old mirror resources: %w", err)
 	}
 
-	// We move back seed pull secret that we saved aside (if it exists), right before applying new PS secret
-	// in order for MCO not to be degraded and apply new rendered master machine config
-	if err := utils.MoveFileIfExists(common.ImageRegistryAuthFile+seedPullSecretSuffix,
-		common.ImageRegistryAuthFile); err != nil {
-		return fmt.Errorf("failed move back seed pull secret: %w", err)
-	}
-	if err := p.applyManifests(); err != nil {
-		return fmt.Errorf("failed apply manifests: %w", err)
+	if err := journal.Run(postPivotSteps.ApplyManifests, func() error {
+		// We move back seed pull secret that we saved aside (if it exists), right before applying new PS secret
+		// in order for MCO not to be degraded and apply new rendered master machine config
+		if err := utils.MoveFileIfExists(common.ImageRegistryAuthFile+seedPullSecretSuffix,
+			common.ImageRegistryAuthFile); err != nil {
+			return fmt.Errorf("failed move back seed pull secret: %w", err)
+		}
+		if err := p.applyManifests(); err != nil {
+			return fmt.Errorf("failed apply manifests: %w", err)
+		}
+		return nil
+	}); err != nil {
+		return err
 	}
 
-	if err := p.changeRegistryInCSVDeployment(ctx, client, seedReconfiguration, seedClusterInfo); err != nil {
+	if err := journal.Run(postPivotSteps.ChangeRegistryInCSVDeployment, func() error {
+		return p.changeRegistryInCSVDeployment(ctx, client, seedReconfiguration, seedClusterInfo)
+	}); err != nil {
 		return fmt.Errorf("failed change registry in CSV deployment: %w", err)
 	}
 
-	if err := utils.RunOnce("set_cluster_id", p.workingDir, p.log, p.setNewClusterID, ctx, client, seedReconfiguration); err != nil {
+	if err := journal.Run(postPivotSteps.SetClusterID, func() error {
+		return utils.RunOnce("set_cluster_id", p.workingDir, p.log, p.setNewClusterID, ctx, client, seedReconfiguration)
+	}); err != nil {
 		return fmt.Errorf("failed to run once set_cluster_id for post pivot: %w", err)
 	}
 
 	// Restore lvm devices
-	if err := utils.RunOnce("recover_lvm_devices", p.workingDir, p.log, p.recoverLvmDevices); err != nil {
+	if err := journal.Run(postPivotSteps.RecoverLvmDevices, func() error {
+		return utils.RunOnce("recover_lvm_devices", p.workingDir, p.log, p.recoverLvmDevices)
+	}); err != nil {
 		return fmt.Errorf("failed to run once recover_lvm_devices for post pivot: %w", err)
 	}
 
-	if _, err = p.ops.SystemctlAction("disable", "installation-configuration.service"); err != nil {
-		return fmt.Errorf("failed to disable installation-configuration.service, err: %w", err)
-	}
-
-	return p.cleanup()
+	return journal.Run(postPivotSteps.Cleanup, func() error {
+		if _, err := p.ops.SystemctlAction("disable", "installation-configuration.service"); err != nil {
+			return fmt.Errorf("failed to disable installation-configuration.service, err: %w", err)
+		}
+		return p.cleanup()
+	})
 }
 
 func (p *PostPivot) recert(ctx context.Context, seedReconfiguration *clusterconfig_api.SeedReconfiguration, seedClusterInfo *seedclusterinfo.SeedClusterInfo) error {
@@ -0,0 +1,319 @@
+package seedcontent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/distribution/reference"
+	"github.com/opencontainers/go-digest"
+	operatorsv1alpha1 "github.com/operator-framework/api/pkg/operators/v1alpha1"
+	"github.com/samber/lo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift-kni/lifecycle-agent/utils"
+)
+
+// SeedContent describes what a seed image holds. It is saved in the seed image, and set in its OCI annotations so
+// that it can be read from the registry without pulling the image
+type SeedContent struct {
+	// Version is the OCP version of the seed cluster
+	Version string `json:"version"`
+	// ReleaseImage is the release image of the seed cluster, by digest
+	ReleaseImage string `json:"releaseImage"`
+	// OstreeCommit is the RHCOS ostree commit booted in the seed cluster
+	OstreeCommit string `json:"ostreeCommit"`
+	// KernelArgs are the kernel arguments of the MCO current config
+	KernelArgs []string `json:"kernelArgs,omitempty"`
+	// Operators are the OLM operators installed in the seed cluster
+	Operators   []Operator `json:"operators,omitempty"`
+	NetworkType string     `json:"networkType"`
+	FIPS        bool       `json:"fips"`
+	// SBOM lists the container images included in the seed image
+	SBOM BOM `json:"sbom"`
+	// SBOMDigest, ImageCount and Images summarize the SBOM. They are only set when the content is read from the
+	// annotations, which don't hold the SBOM itself. Images is empty when the list was too large for an annotation
+	SBOMDigest string   `json:"sbomDigest,omitempty"`
+	ImageCount int      `json:"imageCount,omitempty"`
+	Images     []string `json:"images,omitempty"`
+}
+
+// Operator is an OLM operator, installed by a ClusterServiceVersion
+type Operator struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Version   string `json:"version"`
+	// Package and Channel are those of the Subscription that installed the operator, if any
+	Package string `json:"package,omitempty"`
+	Channel string `json:"channel,omitempty"`
+}
+
+// PackageName identifies the operator across versions: it is the package of its subscription, or the name of its CSV
+// without the version
+func (o Operator) PackageName() string {
+	if o.Package != "" {
+		return o.Package
+	}
+	name, _, _ := strings.Cut(o.Name, ".v")
+	return name
+}
+
+// ListOperators returns the operators installed by OLM, with the package and channel of their subscription. The
+// copies of the CSVs made by OLM in the namespaces watched by an operator are left out
+func ListOperators(ctx context.Context, c client.Client) ([]Operator, error) {
+	allNamespaces := client.ListOptions{Namespace: metav1.NamespaceAll}
+	csvs := &operatorsv1alpha1.ClusterServiceVersionList{}
+	if err := c.List(ctx, csvs, &allNamespaces); err != nil {
+		return nil, fmt.Errorf("failed to list all clusterServiceVersions: %w", err)
+	}
+	subscriptions := &operatorsv1alpha1.SubscriptionList{}
+	if err := c.List(ctx, subscriptions, &allNamespaces); err != nil {
+		return nil, fmt.Errorf("failed to list all subscriptions: %w", err)
+	}
+
+	operators := []Operator{}
+	for _, csv := range csvs.Items {
+		if csv.IsCopied() {
+			continue
+		}
+		operator := Operator{Name: csv.Name, Namespace: csv.Namespace, Version: csv.Spec.Version.String()}
+		subscription, found := lo.Find(subscriptions.Items, func(sub operatorsv1alpha1.Subscription) bool {
+			return sub.Namespace == csv.Namespace && sub.Status.InstalledCSV == csv.Name
+		})
+		if found && subscription.Spec != nil {
+			operator.Package = subscription.Spec.Package
+			operator.Channel = subscription.Spec.Channel
+		}
+		operators = append(operators, operator)
+	}
+	sort.Slice(operators, func(i, j int) bool {
+		return operators[i].Namespace+"/"+operators[i].Name < operators[j].Namespace+"/"+operators[j].Name
+	})
+	return operators, nil
+}
+
+// BOM is a CycloneDX software bill of materials, with the fields used to list container images
+type BOM struct {
+	BOMFormat   string      `json:"bomFormat"`
+	SpecVersion string      `json:"specVersion"`
+	Version     int         `json:"version"`
+	Components  []Component `json:"components"`
+}
+
+// Component is a CycloneDX component
+type Component struct {
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	PURL    string `json:"purl,omitempty"`
+	Hashes  []Hash `json:"hashes,omitempty"`
+}
+
+// Hash is the hash of a CycloneDX component
+type Hash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+// Image is a container image of the seed cluster, as listed by crictl images
+type Image struct {
+	RepoTags    []string `json:"repoTags"`
+	RepoDigests []string `json:"repoDigests"`
+}
+
+// Annotations are the OCI annotations of the seed image holding its content
+var Annotations = struct {
+	Version      string
+	ReleaseImage string
+	OstreeCommit string
+	KernelArgs   string
+	Operators    string
+	NetworkType  string
+	FIPS         string
+	SBOMDigest   string
+	ImageCount   string
+	Images       string
+}{
+	Version:      "com.openshift.lifecycle-agent.seed.version",
+	ReleaseImage: "com.openshift.lifecycle-agent.seed.release_image",
+	OstreeCommit: "com.openshift.lifecycle-agent.seed.ostree_commit",
+	KernelArgs:   "com.openshift.lifecycle-agent.seed.kernel_args",
+	Operators:    "com.openshift.lifecycle-agent.seed.operators",
+	NetworkType:  "com.openshift.lifecycle-agent.seed.network_type",
+	FIPS:         "com.openshift.lifecycle-agent.seed.fips",
+	SBOMDigest:   "com.openshift.lifecycle-agent.seed.sbom_digest",
+	ImageCount:   "com.openshift.lifecycle-agent.seed.image_count",
+	Images:       "com.openshift.lifecycle-agent.seed.images",
+}
+
+// maxOperatorsAnnotationSize and maxImagesAnnotationSize bound the operators and images annotations, as the
+// annotations are passed as podman build arguments and are fetched with the image manifest
+const (
+	maxOperatorsAnnotationSize = 64 * 1024
+	maxImagesAnnotationSize    = 64 * 1024
+)
+
+// NewBOM returns the bill of materials of the images of the list, with the digests crictl reports for them. The
+// images that are not pulled are listed without a digest
+func NewBOM(imageList []string, images []Image) (BOM, error) {
+	bom := BOM{BOMFormat: "CycloneDX", SpecVersion: "1.5", Version: 1, Components: []Component{}}
+	for _, image := range imageList {
+		if image == "" {
+			continue
+		}
+		named, err := reference.ParseNormalizedNamed(image)
+		if err != nil {
+			return BOM{}, fmt.Errorf("failed to parse image %s: %w", image, err)
+		}
+		component := Component{Type: "container", Name: named.Name()}
+		if tagged, ok := named.(reference.Tagged); ok {
+			component.Version = tagged.Tag()
+		}
+
+		imageDigest := ""
+		if digested, ok := named.(reference.Digested); ok {
+			imageDigest = digested.Digest().String()
+		} else {
+			imageDigest = findDigest(named.Name(), image, images)
+		}
+		if alg, hash, found := strings.Cut(imageDigest, ":"); found {
+			if component.Version == "" {
+				component.Version = imageDigest
+			}
+			component.Hashes = []Hash{{Alg: strings.ToUpper(strings.ReplaceAll(alg, "sha", "SHA-")), Content: hash}}
+			component.PURL = purl(named, component.Version, imageDigest)
+		}
+		bom.Components = append(bom.Components, component)
+	}
+	return bom, nil
+}
+
+// Images returns the references of the images of the bill of materials, by tag, or by digest for those without a tag
+func (b BOM) Images() []string {
+	images := make([]string, 0, len(b.Components))
+	for _, component := range b.Components {
+		switch {
+		case strings.Contains(component.Version, ":"):
+			images = append(images, component.Name+"@"+component.Version)
+		case component.Version != "":
+			images = append(images, component.Name+":"+component.Version)
+		default:
+			images = append(images, component.Name)
+		}
+	}
+	return images
+}
+
+// findDigest returns the digest of the tagged image, from the repo digests of the same repository
+func findDigest(name, image string, images []Image) string {
+	for _, candidate := range images {
+		if !lo.Contains(candidate.RepoTags, image) {
+			continue
+		}
+		for _, repoDigest := range candidate.RepoDigests {
+			if repo, repoImageDigest, found := strings.Cut(repoDigest, "@"); found && repo == name {
+				return repoImageDigest
+			}
+		}
+	}
+	return ""
+}
+
+// purl returns the package URL of the image, e.g.
+// pkg:oci/ose-cli@sha256%3Aabc?repository_url=quay.io/openshift/ose-cli&tag=v4.16
+func purl(named reference.Named, version, imageDigest string) string {
+	path := reference.Path(named)
+	query := "repository_url=" + reference.Domain(named) + "/" + path
+	if version != imageDigest {
+		query += "&tag=" + url.QueryEscape(version)
+	}
+	return fmt.Sprintf("pkg:oci/%s@%s?%s", path[strings.LastIndex(path, "/")+1:], url.QueryEscape(imageDigest), query)
+}
+
+// Annotations returns the OCI annotations holding the content. The SBOM is too large for an annotation, only its
+// digest, the number of images it lists and, when they fit, the references of the images are set
+func (c *SeedContent) Annotations() (map[string]string, error) {
+	sbom, err := json.Marshal(c.SBOM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal sbom: %w", err)
+	}
+	annotations := map[string]string{
+		Annotations.Version:      c.Version,
+		Annotations.ReleaseImage: c.ReleaseImage,
+		Annotations.OstreeCommit: c.OstreeCommit,
+		Annotations.NetworkType:  c.NetworkType,
+		Annotations.FIPS:         strconv.FormatBool(c.FIPS),
+		Annotations.SBOMDigest:   digest.FromBytes(sbom).String(),
+		Annotations.ImageCount:   strconv.Itoa(len(c.SBOM.Components)),
+	}
+	for annotation, value := range map[string]any{
+		Annotations.KernelArgs: c.KernelArgs,
+		Annotations.Operators:  c.Operators,
+	} {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal %s annotation: %w", annotation, err)
+		}
+		annotations[annotation] = string(data)
+	}
+	if size := len(annotations[Annotations.Operators]); size > maxOperatorsAnnotationSize {
+		return nil, fmt.Errorf("operators annotation is %d bytes, more than the %d bytes allowed", size, maxOperatorsAnnotationSize)
+	}
+	// The images are only used to estimate the precaching disk space before pulling the seed image, they are left out
+	// rather than failing the seed image creation when there are too many
+	images, err := json.Marshal(c.SBOM.Images())
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s annotation: %w", Annotations.Images, err)
+	}
+	if len(images) <= maxImagesAnnotationSize {
+		annotations[Annotations.Images] = string(images)
+	}
+	return annotations, nil
+}
+
+// FromAnnotations returns the content held in the OCI annotations of a seed image, nil if it has none
+func FromAnnotations(annotations map[string]string) (*SeedContent, error) {
+	if _, ok := annotations[Annotations.ReleaseImage]; !ok {
+		return nil, nil
+	}
+	c := &SeedContent{
+		Version:      annotations[Annotations.Version],
+		ReleaseImage: annotations[Annotations.ReleaseImage],
+		OstreeCommit: annotations[Annotations.OstreeCommit],
+		NetworkType:  annotations[Annotations.NetworkType],
+		FIPS:         annotations[Annotations.FIPS] == "true",
+		SBOMDigest:   annotations[Annotations.SBOMDigest],
+	}
+	if count, ok := annotations[Annotations.ImageCount]; ok {
+		var err error
+		if c.ImageCount, err = strconv.Atoi(count); err != nil {
+			return nil, fmt.Errorf("failed to parse %s annotation: %w", Annotations.ImageCount, err)
+		}
+	}
+	for annotation, value := range map[string]any{
+		Annotations.KernelArgs: &c.KernelArgs,
+		Annotations.Operators:  &c.Operators,
+		Annotations.Images:     &c.Images,
+	} {
+		if data, ok := annotations[annotation]; ok {
+			if err := json.Unmarshal([]byte(data), value); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal %s annotation: %w", annotation, err)
+			}
+		}
+	}
+	return c, nil
+}
+
+// ReadSeedContentFromFile reads the seed content file of a seed image
+func ReadSeedContentFromFile(path string) (*SeedContent, error) {
+	c := &SeedContent{}
+	if err := utils.ReadYamlOrJSONFile(path, c); err != nil {
+		return nil, fmt.Errorf("failed to read seed content from file: %w", err)
+	}
+	return c, nil
+}
@@ -0,0 +1,136 @@
+package seedcontent
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBOM(t *testing.T) {
+	images := []Image{
+		{
+			RepoTags:    []string{"quay.io/openshift-kni/lifecycle-agent-operator:4.16.0"},
+			RepoDigests: []string{"quay.io/openshift-kni/lifecycle-agent-operator@sha256:abc"},
+		},
+	}
+
+	testcases := []struct {
+		name  string
+		image string
+		want  Component
+	}{
+		{
+			name:  "tagged image",
+			image: "quay.io/openshift-kni/lifecycle-agent-operator:4.16.0",
+			want: Component{
+				Type:    "container",
+				Name:    "quay.io/openshift-kni/lifecycle-agent-operator",
+				Version: "4.16.0",
+				PURL:    "pkg:oci/lifecycle-agent-operator@sha256%3Aabc?repository_url=quay.io/openshift-kni/lifecycle-agent-operator&tag=4.16.0",
+				Hashes:  []Hash{{Alg: "SHA-256", Content: "abc"}},
+			},
+		},
+		{
+			name:  "image by digest",
+			image: "quay.io/openshift-release-dev/ocp-v4.0-art-dev@sha256:0f4f2b9d2a8c0e3f6b7a1c5d9e8f7a6b5c4d3e2f1a0b9c8d7e6f5a4b3c2d1e0f",
+			want: Component{
+				Type:    "container",
+				Name:    "quay.io/openshift-release-dev/ocp-v4.0-art-dev",
+				Version: "sha256:0f4f2b9d2a8c0e3f6b7a1c5d9e8f7a6b5c4d3e2f1a0b9c8d7e6f5a4b3c2d1e0f",
+				PURL:    "pkg:oci/ocp-v4.0-art-dev@sha256%3A0f4f2b9d2a8c0e3f6b7a1c5d9e8f7a6b5c4d3e2f1a0b9c8d7e6f5a4b3c2d1e0f?repository_url=quay.io/openshift-release-dev/ocp-v4.0-art-dev",
+				Hashes:  []Hash{{Alg: "SHA-256", Content: "0f4f2b9d2a8c0e3f6b7a1c5d9e8f7a6b5c4d3e2f1a0b9c8d7e6f5a4b3c2d1e0f"}},
+			},
+		},
+		{
+			name:  "image without digest",
+			image: "localhost/recert:latest",
+			want:  Component{Type: "container", Name: "localhost/recert", Version: "latest"},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			bom, err := NewBOM([]string{tc.image, ""}, images)
+			assert.NoError(t, err)
+			assert.Equal(t, "CycloneDX", bom.BOMFormat)
+			assert.Equal(t, []Component{tc.want}, bom.Components)
+		})
+	}
+
+	_, err := NewBOM([]string{"Invalid Image"}, images)
+	assert.Error(t, err)
+}
+
+func TestAnnotations(t *testing.T) {
+	bom, err := NewBOM([]string{"quay.io/openshift-kni/lifecycle-agent-operator:4.16.0"}, nil)
+	assert.NoError(t, err)
+	content := &SeedContent{
+		Version:      "4.16.1",
+		ReleaseImage: "quay.io/openshift-release-dev/ocp-release@sha256:abc",
+		OstreeCommit: "5f9e8a",
+		KernelArgs:   []string{"rcupdate.rcu_normal_after_boot=0", "systemd.cpu_affinity=0,1"},
+		Operators: []Operator{
+			{Name: "lifecycle-agent.v4.16.0", Namespace: "openshift-lifecycle-agent", Version: "4.16.0",
+				Package: "lifecycle-agent", Channel: "stable"},
+		},
+		NetworkType: "OVNKubernetes",
+		FIPS:        true,
+		SBOM:        bom,
+	}
+
+	annotations, err := content.Annotations()
+	assert.NoError(t, err)
+	assert.Equal(t, "true", annotations[Annotations.FIPS])
+	assert.Equal(t, `["rcupdate.rcu_normal_after_boot=0","systemd.cpu_affinity=0,1"]`, annotations[Annotations.KernelArgs])
+
+	// The annotations only hold a summary of the SBOM
+	assert.Equal(t, "1", annotations[Annotations.ImageCount])
+	assert.Regexp(t, "^sha256:[0-9a-f]{64}$", annotations[Annotations.SBOMDigest])
+	fromAnnotations, err := FromAnnotations(annotations)
+	assert.NoError(t, err)
+	want := *content
+	want.SBOM = BOM{}
+	want.SBOMDigest = annotations[Annotations.SBOMDigest]
+	want.ImageCount = 1
+	want.Images = []string{"quay.io/openshift-kni/lifecycle-agent-operator:4.16.0"}
+	assert.Equal(t, &want, fromAnnotations)
+
+	// The images are left out when there are too many of them
+	content.SBOM.Components = make([]Component, 1000)
+	for i := range content.SBOM.Components {
+		content.SBOM.Components[i] = Component{Type: "container", Name: fmt.Sprintf("quay.io/openshift-release-dev/image-%d", i),
+			Version: "sha256:4b1c8d0e5f6a7b8c9d0e1f2a3b4c5d6e7f8a9b0c1d2e3f4a5b6c7d8e9f0a1b2c"}
+	}
+	annotations, err = content.Annotations()
+	assert.NoError(t, err)
+	assert.Equal(t, "1000", annotations[Annotations.ImageCount])
+	assert.NotContains(t, annotations, Annotations.Images)
+
+	// The operators annotation is bounded
+	content.Operators = make([]Operator, 1000)
+	for i := range content.Operators {
+		content.Operators[i] = Operator{Name: fmt.Sprintf("operator-%d.v4.16.0", i), Namespace: fmt.Sprintf("operator-%d", i),
+			Version: "4.16.0", Package: fmt.Sprintf("operator-%d", i), Channel: "stable"}
+	}
+	_, err = content.Annotations()
+	assert.ErrorContains(t, err, "operators annotation is")
+
+	// Seed images created by older versions have no content annotations
+	fromAnnotations, err = FromAnnotations(map[string]string{"org.opencontainers.image.created": "2024-01-01"})
+	assert.NoError(t, err)
+	assert.Nil(t, fromAnnotations)
+}
+
+func TestBOMImages(t *testing.T) {
+	bom := BOM{Components: []Component{
+		{Name: "quay.io/openshift-kni/lifecycle-agent-operator", Version: "4.16.0"},
+		{Name: "quay.io/openshift-release-dev/ocp-v4.0-art-dev", Version: "sha256:4b1c8d0e5f6a7b8c9d0e1f2a3b4c5d6e7f8a9b0c1d2e3f4a5b6c7d8e9f0a1b2c"},
+		{Name: "quay.io/edge-infrastructure/recert"},
+	}}
+	assert.Equal(t, []string{
+		"quay.io/openshift-kni/lifecycle-agent-operator:4.16.0",
+		"quay.io/openshift-release-dev/ocp-v4.0-art-dev@sha256:4b1c8d0e5f6a7b8c9d0e1f2a3b4c5d6e7f8a9b0c1d2e3f4a5b6c7d8e9f0a1b2c",
+		"quay.io/edge-infrastructure/recert",
+	}, bom.Images())
+}
@@ -0,0 +1,275 @@
+package seedcreator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/openshift-kni/lifecycle-agent/internal/archive"
+	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/internal/prep"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
+	ostree "github.com/openshift-kni/lifecycle-agent/lca-cli/ostreeclient"
+	"github.com/openshift-kni/lifecycle-agent/utils"
+)
+
+// baseSeedWorkDir is where the base seed is extracted to compute the delta. It is left out of the /var backup
+const baseSeedWorkDir = "/var/tmp/lca-base-seed"
+
+// resolveBaseSeedImage pulls the base seed image by digest, and records it in the delta seed
+func (s *SeedCreator) resolveBaseSeedImage() error {
+	s.log.Infof("Pulling base seed image %s", s.baseSeedImage)
+	digest, err := prep.ResolveImageDigest(s.ops, s.baseSeedImage, s.authFile)
+	if err != nil {
+		return fmt.Errorf("failed to resolve base seed image digest: %w", err)
+	}
+	baseSeedImage, err := prep.PinImage(s.baseSeedImage, digest)
+	if err != nil {
+		return fmt.Errorf("failed to pin base seed image: %w", err)
+	}
+	if _, err := s.ops.RunInHostNamespace("podman", "pull", "--authfile", s.authFile, baseSeedImage); err != nil {
+		return fmt.Errorf("failed to pull base seed image: %w", err)
+	}
+
+	// The delta only holds the changes from a full seed image of a supported format
+	inspectRaw, err := s.ops.RunInHostNamespace("podman", "image", "inspect", "--format", "json", baseSeedImage)
+	if err != nil {
+		return fmt.Errorf("failed to inspect base seed image: %w", err)
+	}
+	var inspect []struct {
+		Labels map[string]string `json:"Labels"`
+	}
+	if err := json.Unmarshal([]byte(inspectRaw), &inspect); err != nil || len(inspect) != 1 {
+		return fmt.Errorf("failed to unmarshal base seed image inspect output: %w", err)
+	}
+	if err := prep.CheckSeedFormatVersion(baseSeedImage, inspect[0].Labels); err != nil {
+		return fmt.Errorf("invalid base seed image: %w", err)
+	}
+	if base := inspect[0].Labels[common.SeedBaseDigestOCILabel]; base != "" {
+		return fmt.Errorf("base seed image %s is itself a delta seed image, based on %s", baseSeedImage, base)
+	}
+	baseFormatVersion, _ := strconv.Atoi(inspect[0].Labels[common.SeedFormatOCILabel])
+
+	mountpoint, err := s.ops.RunInHostNamespace("podman", "image", "mount", baseSeedImage)
+	if err != nil {
+		return fmt.Errorf("failed to mount base seed image: %w", err)
+	}
+	rpmOstreeJSON, err := s.ops.RunInHostNamespace("cat", filepath.Join(mountpoint, "rpm-ostree.json"))
+	if err != nil {
+		return fmt.Errorf("failed to read base seed rpm-ostree.json: %w", err)
+	}
+	status := &ostree.Status{}
+	if err := json.Unmarshal([]byte(rpmOstreeJSON), status); err != nil {
+		return fmt.Errorf("failed to unmarshal base seed rpm-ostree.json: %w", err)
+	}
+	var baseCommit string
+	for _, deployment := range status.Deployments {
+		if deployment.Booted {
+			baseCommit = deployment.Checksum
+		}
+	}
+	if baseCommit == "" {
+		return fmt.Errorf("failed to find the booted deployment of the base seed")
+	}
+
+	delta := &prep.SeedDelta{
+		BaseSeedImage:         baseSeedImage,
+		BaseSeedDigest:        digest,
+		BaseOstreeCommit:      baseCommit,
+		BaseSeedFormatVersion: baseFormatVersion,
+	}
+	if err := utils.MarshalToFile(delta, filepath.Join(s.backupDir, prep.SeedDeltaFile)); err != nil {
+		return fmt.Errorf("failed to write %s: %w", prep.SeedDeltaFile, err)
+	}
+	s.log.Infof("Creating a delta seed image from base seed image %s", baseSeedImage)
+	return nil
+}
+
+func (s *SeedCreator) readSeedDelta() (*prep.SeedDelta, error) {
+	delta := &prep.SeedDelta{}
+	if err := utils.ReadYamlOrJSONFile(filepath.Join(s.backupDir, prep.SeedDeltaFile), delta); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", prep.SeedDeltaFile, err)
+	}
+	return delta, nil
+}
+
+// mountBaseSeedImage mounts the base seed image, pulling it again if it was removed since it was resolved, and
+// returns the names of its archives
+func (s *SeedCreator) mountBaseSeedImage() (prep.SeedArchives, error) {
+	delta, err := s.readSeedDelta()
+	if err != nil {
+		return prep.SeedArchives{}, err
+	}
+
+	if s.baseSeedMountpoint == "" {
+		exists, err := s.ops.ImageExists(delta.BaseSeedImage)
+		if err != nil {
+			return prep.SeedArchives{}, fmt.Errorf("failed to check for the base seed image: %w", err)
+		}
+		if !exists {
+			if _, err := s.ops.RunInHostNamespace("podman", "pull", "--authfile", s.authFile, delta.BaseSeedImage); err != nil {
+				return prep.SeedArchives{}, fmt.Errorf("failed to pull base seed image: %w", err)
+			}
+		}
+		if s.baseSeedMountpoint, err = s.ops.RunInHostNamespace("podman", "image", "mount", delta.BaseSeedImage); err != nil {
+			return prep.SeedArchives{}, fmt.Errorf("failed to mount base seed image: %w", err)
+		}
+	}
+	return prep.GetSeedArchives(delta.BaseSeedFormatVersion), nil
+}
+
+// extractBaseSeedArchive extracts the archive of the mounted base seed image in the work directory
+func (s *SeedCreator) extractBaseSeedArchive(archive string) (string, error) {
+	baseDir := filepath.Join(baseSeedWorkDir, "base")
+	if err := os.MkdirAll(baseDir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", baseDir, err)
+	}
+	if err := s.ops.ExtractTarWithSELinux(filepath.Join(s.baseSeedMountpoint, archive), baseDir); err != nil {
+		return "", fmt.Errorf("failed to extract base seed %s: %w", archive, err)
+	}
+	return baseDir, nil
+}
+
+// writeBatch writes the rsync batch of the changes from the tree of the base seed to the source
+func (s *SeedCreator) writeBatch(batch, source, baseDir string, excludePatterns []string) error {
+	args := append([]string{}, prep.RsyncBatchArgs...)
+	for _, pattern := range excludePatterns {
+		// We're handling the excluded patterns in bash, we need to single quote them to prevent expansion
+		args = append(args, "--exclude", fmt.Sprintf("'%s'", pattern))
+	}
+	batchFile := path.Join(s.backupDir, batch)
+	args = append(args, "--only-write-batch="+batchFile, source, baseDir+"/")
+	if _, err := s.ops.RunBashInHostNamespace("rsync", args...); err != nil {
+		return fmt.Errorf("failed to write %s: %w", batch, err)
+	}
+
+	// rsync also writes a script to read the batch, which isn't used
+	if err := os.Remove(batchFile + ".sh"); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove %s.sh: %w", batch, err)
+	}
+	return nil
+}
+
+// backupVarDelta writes the changes of /var from the var of the base seed
+func (s *SeedCreator) backupVarDelta() error {
+	baseArchives, err := s.mountBaseSeedImage()
+	if err != nil {
+		return err
+	}
+	baseDir, err := s.extractBaseSeedArchive(baseArchives.Var)
+	if err != nil {
+		return err
+	}
+
+	// Without a trailing slash on the source, rsync anchors the patterns to / as tar does
+	if err := s.writeBatch(prep.VarDeltaBatch, common.VarFolder, baseDir, varExcludePatterns); err != nil {
+		return err
+	}
+
+	s.log.Infof("Delta of %s created successfully.", common.VarFolder)
+	return nil
+}
+
+// backupEtcDelta writes the changes of the /etc files that differ from the ostree deployment, from the ones of the
+// base seed
+func (s *SeedCreator) backupEtcDelta() error {
+	s.log.Info("Backing up /etc delta")
+	baseArchives, err := s.mountBaseSeedImage()
+	if err != nil {
+		return err
+	}
+	baseDir, err := s.extractBaseSeedArchive(baseArchives.Etc)
+	if err != nil {
+		return err
+	}
+
+	// Copy the changed files to compare them with the base ones
+	changes, err := s.etcChanges()
+	if err != nil {
+		return err
+	}
+	changesList := filepath.Join(baseSeedWorkDir, "etc-changes.list")
+	if err := os.WriteFile(changesList, []byte(strings.Join(changes, "\n")+"\n"), 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", changesList, err)
+	}
+	newDir := filepath.Join(baseSeedWorkDir, "new")
+	if err := os.MkdirAll(newDir, 0o700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", newDir, err)
+	}
+	args := []string{"cf", "-"}
+	for _, pattern := range etcExcludePatterns {
+		// We're handling the excluded patterns in bash, we need to single quote them to prevent expansion
+		args = append(args, "--exclude", fmt.Sprintf("'%s'", pattern))
+	}
+	args = append(args, "--selinux", "--xattrs", "-T", changesList, "|",
+		"tar", "xf", "-", "--selinux", "--xattrs", "--xattrs-include='*'", "-C", newDir)
+	if _, err := s.ops.RunBashInHostNamespace("tar", args...); err != nil {
+		return fmt.Errorf("failed to copy the /etc changes: %w", err)
+	}
+
+	if err := s.writeBatch(prep.EtcDeltaBatch, filepath.Join(newDir, "etc"), baseDir, nil); err != nil {
+		return err
+	}
+
+	s.log.Info("Delta of /etc created successfully.")
+	return nil
+}
+
+// backupOstreeDelta archives the objects of /ostree/repo that are not in the repo of the base seed
+func (s *SeedCreator) backupOstreeDelta() error {
+	s.log.Info("Backing up ostree delta")
+	baseArchives, err := s.mountBaseSeedImage()
+	if err != nil {
+		return err
+	}
+
+	baseObjects := filepath.Join(baseSeedWorkDir, "base-objects.list")
+	seedObjects := filepath.Join(baseSeedWorkDir, "seed-objects.list")
+	newObjects := filepath.Join(baseSeedWorkDir, "new-objects.list")
+
+	// The base seed ostree archive is archived from the repo directory, its entries are listed as ./objects/xx/yyy
+	baseOstree := filepath.Join(s.baseSeedMountpoint, baseArchives.Ostree)
+	commands := [][]string{
+		{"tar", "tf", baseOstree, ops.TarCompressionFlag(baseOstree), "|", "grep", `'^\./objects/.*[^/]$'`,
+			"|", "LC_ALL=C", "sort", ">", baseObjects},
+		{"find", "/ostree/repo/objects", "-type", "f", "-printf", `'./objects/%P\n'`, "|", "LC_ALL=C", "sort", ">", seedObjects},
+		{"LC_ALL=C", "comm", "-23", seedObjects, baseObjects, ">", newObjects},
+	}
+	for _, command := range commands {
+		if _, err := s.ops.RunBashInHostNamespace(command[0], command[1:]...); err != nil {
+			return fmt.Errorf("failed backing up ostree delta with command %s: %w", strings.Join(command, " "), err)
+		}
+	}
+
+	objects, err := os.ReadFile(newObjects)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", newObjects, err)
+	}
+	if err := archive.NewArchiver(s.log, ostreeRepo, nil).Create(
+		path.Join(s.backupDir, seedArchives.OstreeDelta), strings.Fields(string(objects))...); err != nil {
+		return fmt.Errorf("failed to archive ostree delta: %w", err)
+	}
+
+	s.log.Info("Backup of ostree delta created successfully.")
+	return nil
+}
+
+// cleanupBaseSeedImage removes the base seed image and its extracted content once the delta is computed
+func (s *SeedCreator) cleanupBaseSeedImage() error {
+	delta, err := s.readSeedDelta()
+	if err != nil {
+		return err
+	}
+	if err := s.ops.UnmountAndRemoveImage(delta.BaseSeedImage); err != nil {
+		return fmt.Errorf("failed to remove base seed image: %w", err)
+	}
+	if err := os.RemoveAll(baseSeedWorkDir); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", baseSeedWorkDir, err)
+	}
+	s.baseSeedMountpoint = ""
+	return nil
+}
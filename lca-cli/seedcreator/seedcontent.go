@@ -0,0 +1,131 @@
+package seedcreator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	configv1 "github.com/openshift/api/config/v1"
+	mcfgv1 "github.com/openshift/api/machineconfiguration/v1"
+	"github.com/samber/lo"
+	runtime "sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/seedcontent"
+	"github.com/openshift-kni/lifecycle-agent/utils"
+)
+
+const mcoCurrentConfig = "/etc/machine-config-daemon/currentconfig"
+
+// gatherSeedContent saves the description of the content of the seed image, set in its annotations when it is built.
+// It runs after the container list is created, as its images are listed in the SBOM
+func (s *SeedCreator) gatherSeedContent(ctx context.Context) error {
+	s.log.Info("Saving seed content")
+	content := &seedcontent.SeedContent{}
+
+	clusterVersion := &configv1.ClusterVersion{}
+	if err := s.client.Get(ctx, runtime.ObjectKey{Name: "version"}, clusterVersion); err != nil {
+		return fmt.Errorf("failed to get clusterversion: %w", err)
+	}
+	content.Version = clusterVersion.Status.Desired.Version
+	content.ReleaseImage = clusterVersion.Status.Desired.Image
+
+	status, err := s.ostreeClient.QueryStatus()
+	if err != nil {
+		return fmt.Errorf("failed to query ostree status: %w", err)
+	}
+	for _, deployment := range status.Deployments {
+		if deployment.Booted {
+			content.OstreeCommit = deployment.Checksum
+		}
+	}
+
+	if content.KernelArgs, err = s.kernelArgs(); err != nil {
+		return err
+	}
+	if content.Operators, err = seedcontent.ListOperators(ctx, s.client); err != nil {
+		return err
+	}
+
+	network := &configv1.Network{}
+	if err := s.client.Get(ctx, runtime.ObjectKey{Name: "cluster"}, network); err != nil {
+		return fmt.Errorf("failed to get cluster network: %w", err)
+	}
+	content.NetworkType = network.Status.NetworkType
+
+	fips, err := s.ops.RunInHostNamespace("cat", "/proc/sys/crypto/fips_enabled")
+	if err != nil {
+		return fmt.Errorf("failed to get fips mode: %w", err)
+	}
+	content.FIPS = strings.TrimSpace(fips) == "1"
+
+	if content.SBOM, err = s.imagesBOM(); err != nil {
+		return err
+	}
+
+	p := path.Join(s.backupDir, common.SeedContentFileName)
+	if err := utils.MarshalToFile(content, p); err != nil {
+		return fmt.Errorf("error creating seed content file in %s: %w", p, err)
+	}
+	return nil
+}
+
+// kernelArgs returns the kernel arguments of the current MCO config
+func (s *SeedCreator) kernelArgs() ([]string, error) {
+	output, err := s.ops.RunInHostNamespace("cat", mcoCurrentConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", mcoCurrentConfig, err)
+	}
+	mc := &mcfgv1.MachineConfig{}
+	if err := json.Unmarshal([]byte(output), mc); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s: %w", mcoCurrentConfig, err)
+	}
+	return mc.Spec.KernelArguments, nil
+}
+
+// imagesBOM returns the SBOM of the images of the container list, with the digests reported by crictl
+func (s *SeedCreator) imagesBOM() (seedcontent.BOM, error) {
+	output, err := s.ops.RunInHostNamespace("crictl", "images", "-o", "json")
+	if err != nil {
+		return seedcontent.BOM{}, fmt.Errorf("failed to list images: %w", err)
+	}
+	var images struct {
+		Images []seedcontent.Image `json:"images"`
+	}
+	if err := json.Unmarshal([]byte(output), &images); err != nil {
+		return seedcontent.BOM{}, fmt.Errorf("failed to unmarshal crictl images: %w", err)
+	}
+
+	imageList, err := os.ReadFile(path.Join(s.backupDir, containersListFileName))
+	if err != nil {
+		return seedcontent.BOM{}, fmt.Errorf("failed to read container list: %w", err)
+	}
+	bom, err := seedcontent.NewBOM(strings.Split(string(imageList), "\n"), images.Images)
+	if err != nil {
+		return seedcontent.BOM{}, fmt.Errorf("failed to create images SBOM: %w", err)
+	}
+	return bom, nil
+}
+
+// seedContentAnnotations returns the podman build arguments setting the seed content in the image annotations
+func (s *SeedCreator) seedContentAnnotations() ([]string, error) {
+	content, err := seedcontent.ReadSeedContentFromFile(path.Join(s.backupDir, common.SeedContentFileName))
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	annotations, err := content.Annotations()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create seed content annotations: %w", err)
+	}
+	keys := lo.Keys(annotations)
+	sort.Strings(keys)
+	var args []string
+	for _, key := range keys {
+		args = append(args, "--annotation", fmt.Sprintf("%s=%s", key, annotations[key]))
+	}
+	return args, nil
+}
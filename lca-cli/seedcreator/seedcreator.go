@@ -9,6 +9,7 @@ import (
 	"path"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -20,18 +21,53 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 	runtime "sigs.k8s.io/controller-runtime/pkg/client"
 
+	"github.com/openshift-kni/lifecycle-agent/internal/archive"
 	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/internal/prep"
+	"github.com/openshift-kni/lifecycle-agent/internal/sigstore"
 	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
 	ostree "github.com/openshift-kni/lifecycle-agent/lca-cli/ostreeclient"
 	"github.com/openshift-kni/lifecycle-agent/lca-cli/seedclusterinfo"
 	"github.com/openshift-kni/lifecycle-agent/utils"
 )
 
-// containerFileContent is the Dockerfile content for the IBU seed image
-const containerFileContent = `
-FROM scratch
-COPY . /
-`
+// seedArchives are the archives of the seed images created by this version of the lca-cli
+var seedArchives = prep.GetSeedArchives(common.SeedFormatVersion)
+
+// seedImageLayers are the files of the seed image split in layers, from the bottom one. The remaining files of the
+// backup directory are copied in the metadata layer, on top. The ostree repo changes less often than var and etc, so
+// it is at the bottom for its layer to be reused. Every file is copied to the root of the image, so that it is
+// mounted as a single layer seed image is
+var seedImageLayers = [][]string{
+	{seedArchives.Ostree, seedArchives.Ostree + archive.ManifestSuffix,
+		seedArchives.OstreeDelta, seedArchives.OstreeDelta + archive.ManifestSuffix},
+	{seedArchives.Var, seedArchives.Var + archive.ManifestSuffix, prep.VarDeltaBatch},
+	{seedArchives.Etc, seedArchives.Etc + archive.ManifestSuffix, "etc.deletions", prep.EtcDeltaBatch},
+}
+
+// containersListFileName is the list of the images of the seed, precached before the upgrade
+const containersListFileName = "containers.list"
+
+// ostreeRepo is the ostree repo of the seed, mounted read-only in the lca-cli container
+const ostreeRepo = "/ostree/repo"
+
+// varExcludePatterns are the paths left out of the /var backup
+var varExcludePatterns = []string{
+	"*/.bash_history",
+	"/var/tmp/*",
+	"/var/log/*",
+	"/var/lib/lca",
+	"/var/lib/log/*",
+	"/var/lib/cni/bin/*",
+	"/var/lib/containers/*",
+	"/var/lib/kubelet/pods/*",
+	common.OvnNodeCerts + "/*",
+}
+
+// etcExcludePatterns are the paths left out of the /etc backup
+var etcExcludePatterns = []string{
+	"/etc/NetworkManager/system-connections",
+}
 
 // SeedCreator TODO: move params to Options
 type SeedCreator struct {
@@ -45,11 +81,16 @@ type SeedCreator struct {
 	authFile             string
 	recertContainerImage string
 	recertSkipValidation bool
+	signingKeyFile       string
+	signingPassFile      string
+	baseSeedImage        string
+	baseSeedMountpoint   string
 }
 
 // NewSeedCreator is a constructor function for SeedCreator
 func NewSeedCreator(client runtime.Client, log *logrus.Logger, ops ops.Ops, ostreeClient *ostree.Client, backupDir,
-	kubeconfig, containerRegistry, authFile, recertContainerImage string, recertSkipValidation bool) *SeedCreator {
+	kubeconfig, containerRegistry, authFile, recertContainerImage string, recertSkipValidation bool,
+	signingKeyFile, signingPassFile, baseSeedImage string) *SeedCreator {
 
 	return &SeedCreator{
 		client:               client,
@@ -62,6 +103,9 @@ func NewSeedCreator(client runtime.Client, log *logrus.Logger, ops ops.Ops, ostr
 		authFile:             authFile,
 		recertContainerImage: recertContainerImage,
 		recertSkipValidation: recertSkipValidation,
+		signingKeyFile:       signingKeyFile,
+		signingPassFile:      signingPassFile,
+		baseSeedImage:        baseSeedImage,
 	}
 }
 
@@ -97,6 +141,10 @@ func (s *SeedCreator) CreateSeedImage() error {
 		return fmt.Errorf("failed to run once gather_cluster_info: %w", err)
 	}
 
+	if err := utils.RunOnce("gather_seed_content", common.BackupChecksDir, s.log, s.gatherSeedContent, ctx); err != nil {
+		return fmt.Errorf("failed to run once gather_seed_content: %w", err)
+	}
+
 	if s.recertSkipValidation {
 		s.log.Info("Skipping seed certificates backing up.")
 	} else {
@@ -107,6 +155,17 @@ func (s *SeedCreator) CreateSeedImage() error {
 		s.log.Info("Seed cluster certificates backed up successfully for recert tool")
 	}
 
+	if err := utils.RunOnce("check_disk_space", common.BackupChecksDir, s.log, s.checkDiskSpace); err != nil {
+		return fmt.Errorf("failed to run once check_disk_space: %w", err)
+	}
+
+	// Pull the base seed image of a delta while the cluster is still running
+	if s.baseSeedImage != "" {
+		if err := utils.RunOnce("resolve_base_seed", common.BackupChecksDir, s.log, s.resolveBaseSeedImage); err != nil {
+			return fmt.Errorf("failed to run once resolve_base_seed: %w", err)
+		}
+	}
+
 	if err := s.stopServices(); err != nil {
 		return fmt.Errorf("failed to stop services to create seed image: %w", err)
 	}
@@ -122,18 +181,29 @@ func (s *SeedCreator) CreateSeedImage() error {
 		return fmt.Errorf("failed remove all OVN certs folders: %w", err)
 	}
 
-	if err := utils.RunOnce("backup_var", common.BackupChecksDir, s.log, s.backupVar); err != nil {
+	backupVar, backupEtc, backupOstree := s.backupVar, s.backupEtc, s.backupOstree
+	if s.baseSeedImage != "" {
+		backupVar, backupEtc, backupOstree = s.backupVarDelta, s.backupEtcDelta, s.backupOstreeDelta
+	}
+
+	if err := utils.RunOnce("backup_var", common.BackupChecksDir, s.log, backupVar); err != nil {
 		return fmt.Errorf("failed to run once backup_var: %w", err)
 	}
 
-	if err := utils.RunOnce("backup_etc", common.BackupChecksDir, s.log, s.backupEtc); err != nil {
+	if err := utils.RunOnce("backup_etc", common.BackupChecksDir, s.log, backupEtc); err != nil {
 		return fmt.Errorf("failed to run once backup_etc: %w", err)
 	}
 
-	if err := utils.RunOnce("backup_ostree", common.BackupChecksDir, s.log, s.backupOstree); err != nil {
+	if err := utils.RunOnce("backup_ostree", common.BackupChecksDir, s.log, backupOstree); err != nil {
 		return fmt.Errorf("failed to run once backup_ostree: %w", err)
 	}
 
+	if s.baseSeedImage != "" {
+		if err := s.cleanupBaseSeedImage(); err != nil {
+			return fmt.Errorf("failed to cleanup base seed image: %w", err)
+		}
+	}
+
 	if err := utils.RunOnce("backup_rpmostree", common.BackupChecksDir, s.log, s.backupRPMOstree); err != nil {
 		return fmt.Errorf("failed to run once backup_rpmostree: %w", err)
 	}
@@ -203,7 +273,7 @@ func (s *SeedCreator) gatherClusterInfo(ctx context.Context) error {
 
 func (s *SeedCreator) createContainerList(ctx context.Context) error {
 	s.log.Info("Saving list of running containers and catalogsources.")
-	containersListFileName := s.backupDir + "/containers.list"
+	containersListFile := path.Join(s.backupDir, containersListFileName)
 
 	// purge all unknown image if exists
 	s.log.Info("Cleaning image list")
@@ -230,9 +300,9 @@ func (s *SeedCreator) createContainerList(ctx context.Context) error {
 	s.log.Infof("Adding recert %s image to image list", s.recertContainerImage)
 	images = utils.AppendToListIfNotExists(images, s.recertContainerImage)
 
-	s.log.Infof("Creating %s file", containersListFileName)
-	if err := os.WriteFile(containersListFileName, []byte(strings.Join(images, "\n")), 0o600); err != nil {
-		return fmt.Errorf("failed to write container list file %s, err %w", containersListFileName, err)
+	s.log.Infof("Creating %s file", containersListFile)
+	if err := os.WriteFile(containersListFile, []byte(strings.Join(images, "\n")), 0o600); err != nil {
+		return fmt.Errorf("failed to write container list file %s, err %w", containersListFile, err)
 	}
 
 	s.log.Info("List of containers  saved successfully.")
@@ -286,34 +356,44 @@ func (s *SeedCreator) stopServices() error {
 	return nil
 }
 
-func (s *SeedCreator) backupVar() error {
-	varTarFile := path.Join(s.backupDir, "var.tgz")
-
-	// Define the 'exclude' patterns
-	excludePatterns := []string{
-		"*/.bash_history",
-		"/var/tmp/*",
-		"/var/log/*",
-		"/var/lib/lca",
-		"/var/lib/log/*",
-		"/var/lib/cni/bin/*",
-		"/var/lib/containers/*",
-		"/var/lib/kubelet/pods/*",
-		common.OvnNodeCerts + "/*",
-	}
-
-	// Build the tar command
-	tarArgs := []string{"czf", varTarFile}
-	for _, pattern := range excludePatterns {
+// checkDiskSpace fails before the services are stopped if the host doesn't have room for the backup archives
+// and for the seed image built from them. The size of the content to archive is used as an upper bound
+func (s *SeedCreator) checkDiskSpace() error {
+	duArgs := []string{"-sbc"}
+	for _, pattern := range varExcludePatterns {
 		// We're handling the excluded patterns in bash, we need to single quote them to prevent expansion
-		tarArgs = append(tarArgs, "--exclude", fmt.Sprintf("'%s'", pattern))
+		duArgs = append(duArgs, "--exclude", fmt.Sprintf("'%s'", pattern))
 	}
-	tarArgs = append(tarArgs, "--selinux", common.VarFolder)
+	duArgs = append(duArgs, common.VarFolder, "/etc", "/ostree/repo", "|", "tail", "-1")
 
-	// Run the tar command
-	_, err := s.ops.RunBashInHostNamespace("tar", tarArgs...)
+	output, err := s.ops.RunBashInHostNamespace("du", duArgs...)
 	if err != nil {
-		return fmt.Errorf("failed to run tar for backupVar: %w", err)
+		return fmt.Errorf("failed to estimate the backup size: %w", err)
+	}
+	// The last line is the grand total, e.g. "21474836480	total"
+	fields := strings.Fields(output)
+	if len(fields) == 0 {
+		return fmt.Errorf("unexpected du output: %s", output)
+	}
+	backupSize, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("failed to parse the backup size: %w", err)
+	}
+	s.log.Infof("Checking disk space for a backup of up to %s", ops.FormatBytes(backupSize))
+
+	if err := ops.CheckDiskSpace(s.ops, map[string]uint64{
+		s.backupDir:                backupSize,
+		common.ContainerStorageDir: backupSize,
+	}); err != nil {
+		return fmt.Errorf("not enough disk space to create the seed image: %w", err)
+	}
+	return nil
+}
+
+func (s *SeedCreator) backupVar() error {
+	archiver := archive.NewArchiver(s.log, "/", varExcludePatterns)
+	if err := archiver.Create(path.Join(s.backupDir, seedArchives.Var), strings.TrimPrefix(common.VarFolder, "/")); err != nil {
+		return fmt.Errorf("failed to archive %s: %w", common.VarFolder, err)
 	}
 
 	s.log.Infof("Backup of %s created successfully.", common.VarFolder)
@@ -323,45 +403,44 @@ func (s *SeedCreator) backupVar() error {
 func (s *SeedCreator) backupEtc() error {
 	s.log.Info("Backing up /etc")
 
-	// Execute 'ostree admin config-diff' command and backup etc.deletions
-	excludePatterns := []string{
-		"/etc/NetworkManager/system-connections",
+	changes, err := s.etcChanges()
+	if err != nil {
+		return err
 	}
-	tarArgs := []string{"tar", "czf", path.Join(s.backupDir + "/etc.tgz")}
-	for _, pattern := range excludePatterns {
-		// We're handling the excluded patterns in bash, we need to single quote them to prevent expansion
-		tarArgs = append(tarArgs, "--exclude", fmt.Sprintf("'%s'", pattern))
+	names := lo.Map(changes, func(change string, _ int) string { return strings.TrimPrefix(change, "/") })
+	archiver := archive.NewArchiver(s.log, "/", etcExcludePatterns)
+	if err := archiver.Create(path.Join(s.backupDir, seedArchives.Etc), names...); err != nil {
+		return fmt.Errorf("failed to archive /etc: %w", err)
 	}
-	tarArgs = append(tarArgs, "--selinux", "-T", "-")
+	s.log.Info("Backup of /etc created successfully.")
 
+	return nil
+}
+
+// etcChanges backs up etc.deletions and returns the /etc files that differ from the ostree deployment
+func (s *SeedCreator) etcChanges() ([]string, error) {
+	// Execute 'ostree admin config-diff' command and backup etc.deletions
 	args := []string{"admin", "config-diff", "|", "awk", `'$1 == "D" {print "/etc/" $2}'`, ">",
 		path.Join(s.backupDir, "/etc.deletions")}
 	_, err := s.ops.RunBashInHostNamespace("ostree", args...)
 	if err != nil {
-		return fmt.Errorf("failed backing up /etc with args %s: %w", args, err)
+		return nil, fmt.Errorf("failed backing up /etc with args %s: %w", args, err)
 	}
 
 	args = []string{"admin", "config-diff", "|", "grep", "-v", "'cni/multus'",
-		"|", "awk", `'$1 != "D" {print "/etc/" $2}'`, "|"}
-	args = append(args, tarArgs...)
-
-	_, err = s.ops.RunBashInHostNamespace("ostree", args...)
+		"|", "awk", `'$1 != "D" {print "/etc/" $2}'`}
+	output, err := s.ops.RunBashInHostNamespace("ostree", args...)
 	if err != nil {
-		return fmt.Errorf("failed backing up /etc with args %s: %w", args, err)
+		return nil, fmt.Errorf("failed backing up /etc with args %s: %w", args, err)
 	}
-	s.log.Info("Backup of /etc created successfully.")
-
-	return nil
+	return lo.Filter(strings.Split(output, "\n"), func(line string, _ int) bool { return line != "" }), nil
 }
 
 func (s *SeedCreator) backupOstree() error {
 	s.log.Info("Backing up ostree")
-	ostreeTar := s.backupDir + "/ostree.tgz"
 
-	// Execute 'tar' command and backup /etc
-	args := []string{"czf", ostreeTar, "--selinux", "-C", "/ostree/repo", "."}
-	if _, err := s.ops.RunBashInHostNamespace("tar", args...); err != nil {
-		return fmt.Errorf("failed backing ostree with args %s: %w", args, err)
+	if err := archive.NewArchiver(s.log, ostreeRepo, nil).Create(path.Join(s.backupDir, seedArchives.Ostree), "."); err != nil {
+		return fmt.Errorf("failed backing up ostree: %w", err)
 	}
 
 	return nil
@@ -400,6 +479,11 @@ func (s *SeedCreator) createAndPushSeedImage() error {
 		return err
 	}
 
+	containerFileContent, err := containerFile(s.backupDir)
+	if err != nil {
+		return err
+	}
+
 	// Create a temporary file for the Dockerfile content
 	tmpfile, err := os.CreateTemp("/var/tmp", "dockerfile-")
 	if err != nil {
@@ -414,27 +498,111 @@ func (s *SeedCreator) createAndPushSeedImage() error {
 	}
 	_ = tmpfile.Close() // Close the temporary file
 
-	// Build the single OCI image (note: We could include --squash-all option, as well)
+	// Build the OCI image, with a layer per COPY instruction
 	podmanBuildArgs := []string{
 		"build",
 		"--file", tmpfile.Name(),
 		"--tag", s.containerRegistry,
 		"--label", fmt.Sprintf("%s=%d", common.SeedFormatOCILabel, common.SeedFormatVersion),
-		s.backupDir,
 	}
+	if s.baseSeedImage != "" {
+		delta, err := s.readSeedDelta()
+		if err != nil {
+			return err
+		}
+		podmanBuildArgs = append(podmanBuildArgs,
+			"--label", fmt.Sprintf("%s=%s", common.SeedBaseDigestOCILabel, delta.BaseSeedDigest),
+			"--label", fmt.Sprintf("%s=%s", common.SeedBaseImageOCILabel, delta.BaseSeedImage))
+	}
+	annotations, err := s.seedContentAnnotations()
+	if err != nil {
+		return err
+	}
+	podmanBuildArgs = append(podmanBuildArgs, annotations...)
+	podmanBuildArgs = append(podmanBuildArgs, s.backupDir)
 	_, err = s.ops.RunInHostNamespace(
 		"podman", podmanBuildArgs...)
 	if err != nil {
 		return fmt.Errorf("failed to build seed image: %w", err)
 	}
 
-	// Push the created OCI image to user's repository
+	// Push the created OCI image to user's repository. The zstd:chunked layers can be pulled in parallel, and
+	// partially when files of a layer are already present
 	_, err = s.ops.RunInHostNamespace(
-		"podman", []string{"push", "--authfile", s.authFile, s.containerRegistry}...)
+		"podman", []string{"push", "--authfile", s.authFile, "--compression-format", "zstd:chunked", s.containerRegistry}...)
 	if err != nil {
 		return fmt.Errorf("failed to push seed image: %w", err)
 	}
 
+	if s.signingKeyFile != "" {
+		if err := s.signSeedImage(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// containerFile returns the Dockerfile content for the IBU seed image, with a COPY instruction for each layer of the
+// files present in the backup directory
+func containerFile(backupDir string) (string, error) {
+	entries, err := os.ReadDir(backupDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", backupDir, err)
+	}
+	names := lo.Map(entries, func(entry os.DirEntry, _ int) string { return entry.Name() })
+
+	lines := []string{"FROM scratch"}
+	for _, layer := range seedImageLayers {
+		if files := lo.Intersect(layer, names); len(files) > 0 {
+			lines = append(lines, fmt.Sprintf("COPY %s /", strings.Join(files, " ")))
+		}
+	}
+
+	// COPY copies the content of the directories, so they are copied one by one
+	var metadata []string
+	for _, entry := range entries {
+		if lo.Contains(lo.Flatten(seedImageLayers), entry.Name()) {
+			continue
+		}
+		if entry.IsDir() {
+			lines = append(lines, fmt.Sprintf("COPY %s /%s", entry.Name(), entry.Name()))
+		} else {
+			metadata = append(metadata, entry.Name())
+		}
+	}
+	if len(metadata) > 0 {
+		lines = append(lines, fmt.Sprintf("COPY %s /", strings.Join(metadata, " ")))
+	}
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+// signSeedImage attaches a sigstore signature to the pushed seed image, in the same format as cosign so that the
+// image can be verified by either tool
+func (s *SeedCreator) signSeedImage() error {
+	s.log.Info("Signing seed image ", s.containerRegistry)
+
+	// The directory is shared with the host, where skopeo runs
+	registriesDir, err := os.MkdirTemp("/var/tmp", "seed-signing-")
+	if err != nil {
+		return fmt.Errorf("failed to create registries.d directory: %w", err)
+	}
+	defer os.RemoveAll(registriesDir)
+
+	if err := sigstore.WriteRegistriesConfig(registriesDir); err != nil {
+		return fmt.Errorf("failed to configure sigstore attachments: %w", err)
+	}
+
+	args := []string{"copy", "--preserve-digests", "--authfile", s.authFile, "--registries.d", registriesDir,
+		"--sign-by-sigstore-private-key", s.signingKeyFile}
+	if s.signingPassFile != "" {
+		args = append(args, "--sign-passphrase-file", s.signingPassFile)
+	}
+	args = append(args, "docker://"+s.containerRegistry, "docker://"+s.containerRegistry)
+
+	if _, err := s.ops.RunInHostNamespace("skopeo", args...); err != nil {
+		return fmt.Errorf("failed to sign seed image: %w", err)
+	}
 	return nil
 }
 
@@ -0,0 +1,58 @@
+package seedcreator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainerFile(t *testing.T) {
+	testcases := []struct {
+		name  string
+		files []string
+		dirs  []string
+		want  string
+	}{
+		{
+			name: "full seed",
+			files: []string{"containers.list", "etc.deletions", "etc.tar.zst", "etc.tar.zst.sha256", "manifest.json",
+				"mco-currentconfig.json", "ostree-abc.1.origin", "ostree.tar.zst", "ostree.tar.zst.sha256",
+				"rpm-ostree.json", "seed-content.json", "var.tar.zst", "var.tar.zst.sha256"},
+			dirs: []string{"recert"},
+			want: "FROM scratch\n" +
+				"COPY ostree.tar.zst ostree.tar.zst.sha256 /\n" +
+				"COPY var.tar.zst var.tar.zst.sha256 /\n" +
+				"COPY etc.deletions etc.tar.zst etc.tar.zst.sha256 /\n" +
+				"COPY recert /recert\n" +
+				"COPY containers.list manifest.json mco-currentconfig.json ostree-abc.1.origin rpm-ostree.json seed-content.json /\n",
+		},
+		{
+			name: "delta seed",
+			files: []string{"containers.list", "delta.json", "etc.batch", "etc.deletions", "manifest.json",
+				"ostree-delta.tar.zst", "ostree-delta.tar.zst.sha256", "var.batch"},
+			want: "FROM scratch\n" +
+				"COPY ostree-delta.tar.zst ostree-delta.tar.zst.sha256 /\n" +
+				"COPY var.batch /\n" +
+				"COPY etc.batch etc.deletions /\n" +
+				"COPY containers.list delta.json manifest.json /\n",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			backupDir := t.TempDir()
+			for _, file := range tc.files {
+				assert.NoError(t, os.WriteFile(filepath.Join(backupDir, file), nil, 0o600))
+			}
+			for _, dir := range tc.dirs {
+				assert.NoError(t, os.Mkdir(filepath.Join(backupDir, dir), 0o700))
+			}
+
+			got, err := containerFile(backupDir)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
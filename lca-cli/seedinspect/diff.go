@@ -0,0 +1,138 @@
+package seedinspect
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/samber/lo"
+
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/seedcontent"
+)
+
+// Diff is the difference between two seed images, from the first one to the second one
+type Diff struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	// Version and FormatVersion are only set when they differ
+	Version           *Change          `json:"version,omitempty"`
+	FormatVersion     *Change          `json:"formatVersion,omitempty"`
+	ImagesAdded       []string         `json:"imagesAdded,omitempty"`
+	ImagesRemoved     []string         `json:"imagesRemoved,omitempty"`
+	KernelArgsAdded   []string         `json:"kernelArgsAdded,omitempty"`
+	KernelArgsRemoved []string         `json:"kernelArgsRemoved,omitempty"`
+	Operators         []OperatorChange `json:"operators,omitempty"`
+}
+
+// Change is a value changed from a seed image to the other
+type Change struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// OperatorChange is an operator added, removed or changed. The version it has in a seed image is empty if the
+// operator is not installed in it
+type OperatorChange struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	From      string `json:"from,omitempty"`
+	To        string `json:"to,omitempty"`
+}
+
+// Compare returns the difference between the seed images of the reports
+func Compare(from, to *Report) *Diff {
+	diff := &Diff{From: from.Image, To: to.Image}
+	if v1, v2 := from.Version(), to.Version(); v1 != v2 {
+		diff.Version = &Change{From: v1, To: v2}
+	}
+	if from.FormatVersion != to.FormatVersion {
+		diff.FormatVersion = &Change{From: from.FormatVersion, To: to.FormatVersion}
+	}
+	diff.ImagesRemoved, diff.ImagesAdded = lo.Difference(from.Images, to.Images)
+	diff.KernelArgsRemoved, diff.KernelArgsAdded = lo.Difference(from.KernelArgs, to.KernelArgs)
+	diff.Operators = compareOperators(operators(from), operators(to))
+	return diff
+}
+
+func operators(r *Report) []seedcontent.Operator {
+	if r.Content == nil {
+		return nil
+	}
+	return r.Content.Operators
+}
+
+func operatorKey(operator seedcontent.Operator) string {
+	return operator.Namespace + "/" + operator.PackageName()
+}
+
+func compareOperators(from, to []seedcontent.Operator) []OperatorChange {
+	fromByKey := lo.KeyBy(from, operatorKey)
+	toByKey := lo.KeyBy(to, operatorKey)
+
+	var changes []OperatorChange
+	for _, key := range lo.Union(lo.Keys(fromByKey), lo.Keys(toByKey)) {
+		before, inFrom := fromByKey[key]
+		after, inTo := toByKey[key]
+		if inFrom && inTo && before.Version == after.Version && before.Channel == after.Channel {
+			continue
+		}
+		change := OperatorChange{}
+		if inFrom {
+			change.Name, change.Namespace, change.From = before.PackageName(), before.Namespace, versionWithChannel(before)
+		}
+		if inTo {
+			change.Name, change.Namespace, change.To = after.PackageName(), after.Namespace, versionWithChannel(after)
+		}
+		changes = append(changes, change)
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Namespace+"/"+changes[i].Name < changes[j].Namespace+"/"+changes[j].Name
+	})
+	return changes
+}
+
+func versionWithChannel(operator seedcontent.Operator) string {
+	if operator.Channel == "" {
+		return operator.Version
+	}
+	return fmt.Sprintf("%s (%s)", operator.Version, operator.Channel)
+}
+
+// Print writes the difference in a human-readable form
+func (d *Diff) Print(w io.Writer) {
+	fmt.Fprintf(w, "--- %s\n+++ %s\n", d.From, d.To)
+	if d.Version != nil {
+		fmt.Fprintf(w, "\nOCP version: %s -> %s\n", d.Version.From, d.Version.To)
+	}
+	if d.FormatVersion != nil {
+		fmt.Fprintf(w, "\nFormat version: %s -> %s\n", d.FormatVersion.From, d.FormatVersion.To)
+	}
+	printList := func(title string, removed, added []string) {
+		if len(removed) == 0 && len(added) == 0 {
+			return
+		}
+		fmt.Fprintf(w, "\n%s:\n", title)
+		for _, value := range removed {
+			fmt.Fprintf(w, "- %s\n", value)
+		}
+		for _, value := range added {
+			fmt.Fprintf(w, "+ %s\n", value)
+		}
+	}
+	printList("Kernel arguments", d.KernelArgsRemoved, d.KernelArgsAdded)
+	printList("Images", d.ImagesRemoved, d.ImagesAdded)
+
+	if len(d.Operators) > 0 {
+		fmt.Fprintln(w, "\nOperators:")
+		for _, change := range d.Operators {
+			switch {
+			case change.From == "":
+				fmt.Fprintf(w, "+ %s/%s %s\n", change.Namespace, change.Name, change.To)
+			case change.To == "":
+				fmt.Fprintf(w, "- %s/%s %s\n", change.Namespace, change.Name, change.From)
+			default:
+				fmt.Fprintf(w, "~ %s/%s %s -> %s\n", change.Namespace, change.Name, change.From, change.To)
+			}
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package seedinspect
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/seedclusterinfo"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/seedcontent"
+)
+
+func TestCompare(t *testing.T) {
+	from := &Report{
+		Image:         "quay.io/example/seed:4.16.1",
+		FormatVersion: "5",
+		ClusterInfo:   &seedclusterinfo.SeedClusterInfo{SeedClusterOCPVersion: "4.16.1"},
+		KernelArgs:    []string{"systemd.cpu_affinity=0,1", "nohz=on"},
+		Images:        []string{"quay.io/example/a:1", "quay.io/example/b:1"},
+		Content: &seedcontent.SeedContent{Operators: []seedcontent.Operator{
+			{Name: "lifecycle-agent.v4.16.0", Namespace: "openshift-lifecycle-agent", Version: "4.16.0", Package: "lifecycle-agent", Channel: "stable"},
+			{Name: "sriov-network-operator.v4.16.0", Namespace: "openshift-sriov-network-operator", Version: "4.16.0", Package: "sriov-network-operator", Channel: "stable"},
+		}},
+	}
+	to := &Report{
+		Image:         "quay.io/example/seed:4.16.2",
+		FormatVersion: "5",
+		ClusterInfo:   &seedclusterinfo.SeedClusterInfo{SeedClusterOCPVersion: "4.16.2"},
+		KernelArgs:    []string{"systemd.cpu_affinity=0,1", "nohz=full"},
+		Images:        []string{"quay.io/example/a:1", "quay.io/example/b:2"},
+		Content: &seedcontent.SeedContent{Operators: []seedcontent.Operator{
+			{Name: "lifecycle-agent.v4.16.1", Namespace: "openshift-lifecycle-agent", Version: "4.16.1", Package: "lifecycle-agent", Channel: "stable"},
+			{Name: "sriov-network-operator.v4.16.0", Namespace: "openshift-sriov-network-operator", Version: "4.16.0", Package: "sriov-network-operator", Channel: "stable"},
+			{Name: "ptp-operator.v4.16.0", Namespace: "openshift-ptp", Version: "4.16.0"},
+		}},
+	}
+
+	diff := Compare(from, to)
+	assert.Equal(t, &Diff{
+		From:              from.Image,
+		To:                to.Image,
+		Version:           &Change{From: "4.16.1", To: "4.16.2"},
+		ImagesAdded:       []string{"quay.io/example/b:2"},
+		ImagesRemoved:     []string{"quay.io/example/b:1"},
+		KernelArgsAdded:   []string{"nohz=full"},
+		KernelArgsRemoved: []string{"nohz=on"},
+		Operators: []OperatorChange{
+			{Name: "lifecycle-agent", Namespace: "openshift-lifecycle-agent", From: "4.16.0 (stable)", To: "4.16.1 (stable)"},
+			{Name: "ptp-operator", Namespace: "openshift-ptp", To: "4.16.0"},
+		},
+	}, diff)
+
+	var out bytes.Buffer
+	diff.Print(&out)
+	assert.Contains(t, out.String(), "OCP version: 4.16.1 -> 4.16.2\n")
+	assert.Contains(t, out.String(), "\nKernel arguments:\n- nohz=on\n+ nohz=full\n")
+	assert.Contains(t, out.String(), "~ openshift-lifecycle-agent/lifecycle-agent 4.16.0 (stable) -> 4.16.1 (stable)\n")
+
+	// Seed images without content have no operators to compare
+	assert.Empty(t, Compare(&Report{}, &Report{}).Operators)
+}
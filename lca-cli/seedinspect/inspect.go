@@ -0,0 +1,328 @@
+package seedinspect
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	configv1 "github.com/openshift/api/config/v1"
+	mcfgv1 "github.com/openshift/api/machineconfiguration/v1"
+	"github.com/samber/lo"
+	"github.com/sirupsen/logrus"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	lcav1alpha1 "github.com/openshift-kni/lifecycle-agent/api/v1alpha1"
+	"github.com/openshift-kni/lifecycle-agent/controllers/utils"
+	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/internal/prep"
+	"github.com/openshift-kni/lifecycle-agent/internal/upgradepath"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
+	ostree "github.com/openshift-kni/lifecycle-agent/lca-cli/ostreeclient"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/seedclusterinfo"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/seedcontent"
+)
+
+// The metadata files of the seed image, at its root
+const (
+	rpmOstreeFile        = "rpm-ostree.json"
+	mcoCurrentConfigFile = "mco-currentconfig.json"
+	containersListFile   = "containers.list"
+)
+
+// Report is what a seed image holds, read from its labels and metadata files. The fields of the files missing from
+// the seed image, e.g. created by an older lca-cli, are left empty
+type Report struct {
+	Image         string `json:"image"`
+	Digest        string `json:"digest"`
+	FormatVersion string `json:"formatVersion"`
+	// BaseSeedImage is the seed image a delta seed image was created from
+	BaseSeedImage string                           `json:"baseSeedImage,omitempty"`
+	ClusterInfo   *seedclusterinfo.SeedClusterInfo `json:"clusterInfo,omitempty"`
+	// Ostree is the booted deployment of the seed cluster
+	Ostree     *Ostree  `json:"ostree,omitempty"`
+	KernelArgs []string `json:"kernelArgs,omitempty"`
+	Images     []string `json:"images,omitempty"`
+	// Content is read from the seed content file, or from the annotations of the seed image
+	Content *seedcontent.SeedContent `json:"content,omitempty"`
+	// Incompatibilities are the reasons the seed image cannot upgrade the cluster it was checked against
+	Incompatibilities []string `json:"incompatibilities,omitempty"`
+}
+
+// Ostree is an rpm-ostree deployment
+type Ostree struct {
+	OSName   string `json:"osName"`
+	Version  string `json:"version"`
+	Checksum string `json:"checksum"`
+}
+
+// Inspector reads the content of seed images from the registry, and from their metadata files when requested
+type Inspector struct {
+	log      *logrus.Logger
+	ops      ops.Ops
+	authFile string
+	// files makes the inspector pull and mount the seed images to read their metadata files
+	files bool
+}
+
+// NewInspector returns an inspector reading the seed images with the credentials of the auth file. With files, the
+// seed images are also pulled and mounted to read their metadata files
+func NewInspector(log *logrus.Logger, ops ops.Ops, authFile string, files bool) *Inspector {
+	return &Inspector{log: log, ops: ops, authFile: authFile, files: files}
+}
+
+// Inspect returns what the seed image holds. Its labels and annotations are read from the registry, and the image is
+// only pulled and mounted to read its metadata files when requested, or when it has no seed content annotations, as
+// when created by an older lca-cli. A seed image pulled by Inspect is removed afterwards
+func (i *Inspector) Inspect(image string) (*Report, error) {
+	report, err := i.inspectRegistry(image)
+	if err != nil {
+		return nil, err
+	}
+	if !i.files && report.Content != nil {
+		return report, nil
+	}
+
+	if _, err := i.ops.RunInHostNamespace("podman", "image", "exists", image); err != nil {
+		i.log.Infof("Pulling seed image %s", image)
+		if _, err := i.ops.RunInHostNamespace("podman", "pull", "--authfile", i.authFile, image); err != nil {
+			return nil, fmt.Errorf("failed to pull seed image %s: %w", image, err)
+		}
+		defer func() {
+			if _, err := i.ops.RunInHostNamespace("podman", "rmi", image); err != nil {
+				i.log.Warnf("Failed to remove seed image %s: %v", image, err)
+			}
+		}()
+	}
+
+	mountpoint, err := i.ops.RunInHostNamespace("podman", "image", "mount", image)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mount seed image %s: %w", image, err)
+	}
+	defer func() {
+		if _, err := i.ops.RunInHostNamespace("podman", "image", "umount", image); err != nil {
+			i.log.Warnf("Failed to unmount seed image %s: %v", image, err)
+		}
+	}()
+
+	if err := i.readMetadata(strings.TrimSpace(mountpoint), report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// inspectRegistry reads the digest and the labels of the seed image, and the seed content held in its manifest
+// annotations, without pulling it
+func (i *Inspector) inspectRegistry(image string) (*Report, error) {
+	inspectRaw, err := i.ops.RunInHostNamespace("skopeo", "inspect", "--no-tags", "--authfile", i.authFile, "docker://"+image)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect seed image %s: %w", image, err)
+	}
+	var inspect struct {
+		Digest string            `json:"Digest"`
+		Labels map[string]string `json:"Labels"`
+	}
+	if err := json.Unmarshal([]byte(inspectRaw), &inspect); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal image inspect output: %w", err)
+	}
+
+	content, err := prep.InspectSeedContent(i.ops, image, i.authFile)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	return &Report{
+		Image:         image,
+		Digest:        inspect.Digest,
+		FormatVersion: inspect.Labels[common.SeedFormatOCILabel],
+		BaseSeedImage: inspect.Labels[common.SeedBaseImageOCILabel],
+		Content:       content,
+	}, nil
+}
+
+// Version returns the OCP version of the seed image, from its cluster info or its seed content
+func (r *Report) Version() string {
+	if r.ClusterInfo != nil {
+		return r.ClusterInfo.SeedClusterOCPVersion
+	}
+	if r.Content != nil {
+		return r.Content.Version
+	}
+	return ""
+}
+
+// readMetadata reads the metadata files present at the root of the mounted seed image
+func (i *Inspector) readMetadata(mountpoint string, report *Report) error {
+	output, err := i.ops.RunInHostNamespace("ls", mountpoint)
+	if err != nil {
+		return fmt.Errorf("failed to list seed image files: %w", err)
+	}
+	files := strings.Fields(output)
+
+	read := func(name string) (string, bool, error) {
+		if !lo.Contains(files, name) {
+			i.log.Debugf("Seed image has no %s", name)
+			return "", false, nil
+		}
+		content, err := i.ops.RunInHostNamespace("cat", filepath.Join(mountpoint, name))
+		if err != nil {
+			return "", false, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		return content, true, nil
+	}
+	unmarshal := func(name string, into any) (bool, error) {
+		content, found, err := read(name)
+		if err != nil || !found {
+			return false, err
+		}
+		if err := json.Unmarshal([]byte(content), into); err != nil {
+			return false, fmt.Errorf("failed to unmarshal %s: %w", name, err)
+		}
+		return true, nil
+	}
+
+	clusterInfo := &seedclusterinfo.SeedClusterInfo{}
+	if found, err := unmarshal(common.SeedClusterInfoFileName, clusterInfo); err != nil {
+		return err
+	} else if found {
+		report.ClusterInfo = clusterInfo
+	}
+
+	status := &ostree.Status{}
+	if _, err := unmarshal(rpmOstreeFile, status); err != nil {
+		return err
+	}
+	if deployment, found := lo.Find(status.Deployments, func(d ostree.Deployment) bool { return d.Booted }); found {
+		report.Ostree = &Ostree{OSName: deployment.OSName, Version: deployment.Version, Checksum: deployment.Checksum}
+	}
+
+	mc := &mcfgv1.MachineConfig{}
+	if _, err := unmarshal(mcoCurrentConfigFile, mc); err != nil {
+		return err
+	}
+	report.KernelArgs = mc.Spec.KernelArguments
+
+	content := &seedcontent.SeedContent{}
+	if found, err := unmarshal(common.SeedContentFileName, content); err != nil {
+		return err
+	} else if found {
+		report.Content = content
+	}
+
+	images, _, err := read(containersListFile)
+	if err != nil {
+		return err
+	}
+	report.Images = lo.Compact(strings.Split(images, "\n"))
+	return nil
+}
+
+// Check records in the report the reasons the seed image cannot upgrade the cluster: an unsupported seed format, an
+// unsupported upgrade path to the seed version, or a different network type. The upgrade path is validated as in Prep,
+// with the upgrade graph referenced by the IBU of the cluster, if any
+func Check(ctx context.Context, c client.Client, report *Report) error {
+	labels := map[string]string{}
+	if report.FormatVersion != "" {
+		labels[common.SeedFormatOCILabel] = report.FormatVersion
+	}
+	if err := prep.CheckSeedFormatVersion(report.Image, labels); err != nil {
+		report.Incompatibilities = append(report.Incompatibilities, err.Error())
+	}
+
+	if version := report.Version(); version != "" {
+		clusterVersion := &configv1.ClusterVersion{}
+		if err := c.Get(ctx, client.ObjectKey{Name: "version"}, clusterVersion); err != nil {
+			return fmt.Errorf("failed to get clusterversion: %w", err)
+		}
+		ibu := &lcav1alpha1.ImageBasedUpgrade{}
+		if err := c.Get(ctx, client.ObjectKey{Name: utils.IBUName}, ibu); err != nil && !k8serrors.IsNotFound(err) {
+			return fmt.Errorf("failed to get ibu: %w", err)
+		}
+		validator, err := upgradepath.NewValidatorForIBU(ctx, c, ibu)
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+		if _, err := validator.Validate(clusterVersion.Status.Desired.Version, version); err != nil {
+			report.Incompatibilities = append(report.Incompatibilities, err.Error())
+		}
+	}
+
+	if report.Content != nil {
+		network := &configv1.Network{}
+		if err := c.Get(ctx, client.ObjectKey{Name: "cluster"}, network); err != nil {
+			return fmt.Errorf("failed to get cluster network: %w", err)
+		}
+		if network.Status.NetworkType != report.Content.NetworkType {
+			report.Incompatibilities = append(report.Incompatibilities, fmt.Sprintf(
+				"seed network type %s differs from the cluster network type %s",
+				report.Content.NetworkType, network.Status.NetworkType))
+		}
+	}
+	return nil
+}
+
+// Print writes the report in a human-readable form
+func (r *Report) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	row := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(tw, "%s:\t%s\n", name, value)
+		}
+	}
+	row("Image", r.Image)
+	row("Digest", r.Digest)
+	row("Format version", r.FormatVersion)
+	row("Base seed image", r.BaseSeedImage)
+	row("OCP version", r.Version())
+	if r.ClusterInfo != nil {
+		row("Cluster", r.ClusterInfo.ClusterName+"."+r.ClusterInfo.BaseDomain)
+		row("Release registry", r.ClusterInfo.ReleaseRegistry)
+		row("Recert image", r.ClusterInfo.RecertImagePullSpec)
+	}
+	if r.Ostree != nil {
+		row("RHCOS version", r.Ostree.Version)
+		row("Ostree commit", r.Ostree.Checksum)
+	}
+	row("Kernel arguments", strings.Join(r.KernelArgs, " "))
+	if r.Content != nil {
+		row("Release image", r.Content.ReleaseImage)
+		row("Network type", r.Content.NetworkType)
+		row("FIPS", fmt.Sprint(r.Content.FIPS))
+	}
+	switch {
+	case r.Images != nil:
+		row("Images", fmt.Sprint(len(r.Images)))
+	case r.Content != nil && r.Content.ImageCount > 0:
+		row("Images", fmt.Sprint(r.Content.ImageCount))
+	}
+	if r.Content != nil {
+		row("SBOM digest", r.Content.SBOMDigest)
+	}
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	if r.Content != nil && len(r.Content.Operators) > 0 {
+		fmt.Fprintln(w, "\nOperators:")
+		tw = tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "  NAMESPACE\tNAME\tVERSION\tCHANNEL")
+		for _, operator := range r.Content.Operators {
+			fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\n", operator.Namespace, operator.Name, operator.Version, operator.Channel)
+		}
+		if err := tw.Flush(); err != nil {
+			return fmt.Errorf("failed to write report: %w", err)
+		}
+	}
+
+	if len(r.Incompatibilities) > 0 {
+		fmt.Fprintln(w, "\nIncompatibilities:")
+		for _, incompatibility := range r.Incompatibilities {
+			fmt.Fprintf(w, "  - %s\n", incompatibility)
+		}
+	}
+	return nil
+}
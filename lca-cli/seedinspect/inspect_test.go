@@ -0,0 +1,201 @@
+package seedinspect
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	lcav1alpha1 "github.com/openshift-kni/lifecycle-agent/api/v1alpha1"
+	"github.com/openshift-kni/lifecycle-agent/controllers/utils"
+	"github.com/openshift-kni/lifecycle-agent/internal/upgradepath"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/seedclusterinfo"
+	"github.com/openshift-kni/lifecycle-agent/lca-cli/seedcontent"
+)
+
+const seedImage = "quay.io/example/seed:4.16.1"
+
+func TestInspect(t *testing.T) {
+	const (
+		inspect      = `{"Digest":"sha256:abc","Labels":{"com.openshift.lifecycle-agent.seed_format_version":"5"}}`
+		manifest     = `{"annotations":{"com.openshift.lifecycle-agent.seed.version":"4.16.1","com.openshift.lifecycle-agent.seed.release_image":"quay.io/openshift-release-dev/ocp-release:4.16.1","com.openshift.lifecycle-agent.seed.image_count":"2"}}`
+		noAnnotation = `{"layers":[]}`
+	)
+	content := &seedcontent.SeedContent{
+		Version:      "4.16.1",
+		ReleaseImage: "quay.io/openshift-release-dev/ocp-release:4.16.1",
+		ImageCount:   2,
+	}
+	metadata := &Report{
+		Image:         seedImage,
+		Digest:        "sha256:abc",
+		FormatVersion: "5",
+		ClusterInfo:   &seedclusterinfo.SeedClusterInfo{SeedClusterOCPVersion: "4.16.1", ClusterName: "seed", BaseDomain: "example.com"},
+		Ostree:        &Ostree{OSName: "rhcos", Version: "416.94", Checksum: "5f9e8a"},
+		KernelArgs:    []string{"systemd.cpu_affinity=0,1"},
+		Images:        []string{"quay.io/openshift-release-dev/ocp-v4.0-art-dev@sha256:def", "quay.io/edge-infrastructure/recert:v0"},
+	}
+
+	testcases := []struct {
+		name     string
+		files    bool
+		manifest string
+		// pulled is whether the seed image is missing from the host, and pulled then removed by Inspect
+		pulled bool
+		want   *Report
+	}{
+		{
+			name:     "registry only",
+			manifest: manifest,
+			want:     &Report{Image: seedImage, Digest: "sha256:abc", FormatVersion: "5", Content: content},
+		},
+		{
+			name:     "files of a missing image",
+			files:    true,
+			manifest: manifest,
+			pulled:   true,
+			want: func() *Report {
+				r := *metadata
+				r.Content = content
+				return &r
+			}(),
+		},
+		{
+			name:     "older seed image already on the host",
+			manifest: noAnnotation,
+			want:     metadata,
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			mockOps := ops.NewMockOps(ctrl)
+
+			mockOps.EXPECT().RunInHostNamespace("skopeo", "inspect", "--no-tags", "--authfile", "/auth.json", "docker://"+seedImage).Return(inspect, nil)
+			mockOps.EXPECT().RunInHostNamespace("skopeo", "inspect", "--raw", "--authfile", "/auth.json", "docker://"+seedImage).Return(tc.manifest, nil)
+			if tc.want.ClusterInfo != nil {
+				if tc.pulled {
+					mockOps.EXPECT().RunInHostNamespace("podman", "image", "exists", seedImage).Return("", errors.New("exit status 1"))
+					mockOps.EXPECT().RunInHostNamespace("podman", "pull", "--authfile", "/auth.json", seedImage).Return("", nil)
+					mockOps.EXPECT().RunInHostNamespace("podman", "rmi", seedImage).Return("", nil)
+				} else {
+					mockOps.EXPECT().RunInHostNamespace("podman", "image", "exists", seedImage).Return("", nil)
+				}
+				mockOps.EXPECT().RunInHostNamespace("podman", "image", "mount", seedImage).Return("/mnt/seed", nil)
+				mockOps.EXPECT().RunInHostNamespace("ls", "/mnt/seed").Return(
+					"containers.list\nmanifest.json\nmco-currentconfig.json\nrpm-ostree.json\nvar.tar.zst\n", nil)
+				mockOps.EXPECT().RunInHostNamespace("cat", "/mnt/seed/manifest.json").Return(
+					`{"seed_cluster_ocp_version":"4.16.1","cluster_name":"seed","base_domain":"example.com"}`, nil)
+				mockOps.EXPECT().RunInHostNamespace("cat", "/mnt/seed/rpm-ostree.json").Return(
+					`{"deployments":[{"osname":"rhcos","version":"416.94","checksum":"5f9e8a","booted":true}]}`, nil)
+				mockOps.EXPECT().RunInHostNamespace("cat", "/mnt/seed/mco-currentconfig.json").Return(
+					`{"spec":{"kernelArguments":["systemd.cpu_affinity=0,1"]}}`, nil)
+				mockOps.EXPECT().RunInHostNamespace("cat", "/mnt/seed/containers.list").Return(
+					"quay.io/openshift-release-dev/ocp-v4.0-art-dev@sha256:def\nquay.io/edge-infrastructure/recert:v0\n", nil)
+				mockOps.EXPECT().RunInHostNamespace("podman", "image", "umount", seedImage).Return("", nil)
+			}
+
+			report, err := NewInspector(logrus.New(), mockOps, "/auth.json", tc.files).Inspect(seedImage)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, report)
+
+			var out bytes.Buffer
+			assert.NoError(t, report.Print(&out))
+			assert.Regexp(t, `OCP version: +4.16.1\n`, out.String())
+			assert.Regexp(t, `Images: +2\n`, out.String())
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	testcases := []struct {
+		name   string
+		report *Report
+		// graph is the upgrade graph referenced by the ibu, if any
+		graph string
+		want  []string
+	}{
+		{
+			name: "compatible",
+			report: &Report{
+				FormatVersion: "5",
+				ClusterInfo:   &seedclusterinfo.SeedClusterInfo{SeedClusterOCPVersion: "4.16.1"},
+				Content:       &seedcontent.SeedContent{NetworkType: "OVNKubernetes"},
+			},
+		},
+		{
+			name: "version from the seed content, with the upgrade graph of the ibu",
+			report: &Report{
+				FormatVersion: "5",
+				Content:       &seedcontent.SeedContent{Version: "4.16.1", NetworkType: "OVNKubernetes"},
+			},
+			graph: `{"nodes":[{"version":"4.15.2"},{"version":"4.16.1"}],"edges":[]}`,
+			want: []string{
+				"upgrade from 4.15.2 to 4.16.1 is not supported: there is no upgrade path between these versions in the upgrade graph",
+			},
+		},
+		{
+			name: "incompatible",
+			report: &Report{
+				FormatVersion: "2",
+				ClusterInfo:   &seedclusterinfo.SeedClusterInfo{SeedClusterOCPVersion: "4.14.0"},
+				Content:       &seedcontent.SeedContent{NetworkType: "OpenShiftSDN"},
+			},
+			want: []string{
+				"seed image format version mismatch: expected 3 to 5, got 2",
+				"upgrade from 4.15.2 to 4.14.0 is not supported: the target version must be higher than the current version",
+				"seed network type OpenShiftSDN differs from the cluster network type OVNKubernetes",
+			},
+		},
+	}
+
+	scheme := runtime.NewScheme()
+	assert.NoError(t, configv1.AddToScheme(scheme))
+	assert.NoError(t, corev1.AddToScheme(scheme))
+	assert.NoError(t, lcav1alpha1.AddToScheme(scheme))
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			objs := []client.Object{
+				&configv1.ClusterVersion{
+					ObjectMeta: metav1.ObjectMeta{Name: "version"},
+					Status:     configv1.ClusterVersionStatus{Desired: configv1.Release{Version: "4.15.2"}},
+				},
+				&configv1.Network{
+					ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+					Status:     configv1.NetworkStatus{NetworkType: "OVNKubernetes"},
+				},
+			}
+			if tc.graph != "" {
+				objs = append(objs,
+					&lcav1alpha1.ImageBasedUpgrade{
+						ObjectMeta: metav1.ObjectMeta{Name: utils.IBUName},
+						Spec: lcav1alpha1.ImageBasedUpgradeSpec{
+							UpgradeGraphRef: &lcav1alpha1.ConfigMapRef{Name: "graph", Namespace: "openshift-lifecycle-agent"},
+						},
+					},
+					&corev1.ConfigMap{
+						ObjectMeta: metav1.ObjectMeta{Name: "graph", Namespace: "openshift-lifecycle-agent"},
+						Data:       map[string]string{upgradepath.GraphKey: tc.graph},
+					},
+				)
+			}
+			c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+
+			assert.NoError(t, Check(context.Background(), c, tc.report))
+			assert.Equal(t, tc.want, tc.report.Incompatibilities)
+		})
+	}
+}
@@ -31,6 +31,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -64,9 +65,12 @@ import (
 	"github.com/openshift-kni/lifecycle-agent/controllers/utils"
 	"github.com/openshift-kni/lifecycle-agent/internal/backuprestore"
 	"github.com/openshift-kni/lifecycle-agent/internal/common"
+	"github.com/openshift-kni/lifecycle-agent/internal/hooks"
+	"github.com/openshift-kni/lifecycle-agent/internal/notify"
 	"github.com/openshift-kni/lifecycle-agent/internal/ostreeclient"
 	"github.com/openshift-kni/lifecycle-agent/internal/precache"
 	"github.com/openshift-kni/lifecycle-agent/internal/reboot"
+	"github.com/openshift-kni/lifecycle-agent/internal/webhooks"
 	"github.com/openshift-kni/lifecycle-agent/lca-cli/ops"
 	rpmostreeclient "github.com/openshift-kni/lifecycle-agent/lca-cli/ostreeclient"
 	lcautils "github.com/openshift-kni/lifecycle-agent/utils"
@@ -144,6 +148,9 @@ func main() {
 		Metrics: server.Options{
 			BindAddress: metricsAddr,
 		},
+		WebhookServer: webhook.NewServer(webhook.Options{
+			Port: 9443,
+		}),
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
@@ -196,18 +203,27 @@ func main() {
 
 	backupRestore := &backuprestore.BRHandler{
 		Client: mgr.GetClient(), DynamicClient: dynamicClient, Log: log.WithName("BackupRestore")}
+	hooksRunner := &hooks.Runner{Client: mgr.GetClient(), Log: log.WithName("Hooks")}
+
+	notifier := notify.NewNotifier(mgr.GetClient(), log.WithName("Notify"), common.PathOutsideChroot(notify.Dir))
+	if err := mgr.Add(notifier); err != nil {
+		setupLog.Error(err, "unable to add notifier to the manager")
+		os.Exit(1)
+	}
 
 	if err = (&controllers.ImageBasedUpgradeReconciler{
 		Client:          mgr.GetClient(),
 		Log:             log,
 		Scheme:          mgr.GetScheme(),
 		Precache:        &precache.PHandler{Client: mgr.GetClient(), Log: log.WithName("Precache")},
+		Hooks:           hooksRunner,
 		RPMOstreeClient: rpmOstreeClient,
 		Executor:        executor,
 		OstreeClient:    ostreeClient,
 		Ops:             op,
 		RebootClient:    rebootClient,
 		BackupRestore:   backupRestore,
+		Notifier:        notifier,
 		PrepTask:        &controllers.Task{Active: false, Success: false, Cancel: nil, Progress: ""},
 		UpgradeHandler: &controllers.UpgHandler{
 			Client:          mgr.GetClient(),
@@ -221,6 +237,7 @@ func main() {
 			RPMOstreeClient: rpmOstreeClient,
 			OstreeClient:    ostreeClient,
 			RebootClient:    rebootClient,
+			Hooks:           hooksRunner,
 		},
 		Mux: mux,
 	}).SetupWithManager(mgr); err != nil {
@@ -242,6 +259,25 @@ func main() {
 	}
 	//+kubebuilder:scaffold:builder
 
+	// The webhook server needs the serving certificate provisioned by OLM, so it can be disabled when
+	// running the manager locally
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = (&webhooks.IBUValidator{
+			Client:        mgr.GetClient(),
+			BackupRestore: backupRestore,
+			Log:           ctrl.Log.WithName("webhooks").WithName("ImageBasedUpgrade"),
+		}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ImageBasedUpgrade")
+			os.Exit(1)
+		}
+		if err = (&webhooks.SeedGeneratorValidator{
+			Log: ctrl.Log.WithName("webhooks").WithName("SeedGenerator"),
+		}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "SeedGenerator")
+			os.Exit(1)
+		}
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
@@ -53,8 +53,29 @@ func readPrecacheSpecFile() (precacheSpec []string, err error) {
 	}
 	log.Info("Precache spec file found.")
 
+	return readImageListFile(precacheSpecFile)
+}
+
+// readAdditionalSpecFile returns the list of additional images as specified in the optional additional spec file
+func readAdditionalSpecFile() (additionalSpec []string, err error) {
+	additionalSpecFile := os.Getenv(precache.EnvPrecacheAdditionalSpecFile)
+	if additionalSpecFile == "" {
+		return additionalSpec, nil
+	}
+
+	// Check if additionalSpecFile exists
+	if _, err := os.Stat(additionalSpecFile); os.IsNotExist(err) {
+		return additionalSpec, fmt.Errorf("missing precache additional spec file")
+	}
+	log.Info("Precache additional spec file found.")
+
+	return readImageListFile(additionalSpecFile)
+}
+
+// readImageListFile returns the non-empty lines of the given file
+func readImageListFile(filename string) (imageList []string, err error) {
 	var content []byte
-	content, err = os.ReadFile(precacheSpecFile)
+	content, err = os.ReadFile(filename)
 	if err != nil {
 		return
 	}
@@ -64,11 +85,11 @@ func readPrecacheSpecFile() (precacheSpec []string, err error) {
 	// Filter out empty lines
 	for _, line := range lines {
 		if line != "" {
-			precacheSpec = append(precacheSpec, line)
+			imageList = append(imageList, line)
 		}
 	}
 
-	return precacheSpec, nil
+	return imageList, nil
 }
 
 func main() {
@@ -90,6 +111,11 @@ func main() {
 
 	log.Info("Loaded precache spec file.")
 
+	additionalSpec, err := readAdditionalSpecFile()
+	if err != nil {
+		terminateOnError(err)
+	}
+
 	// Change root directory to /host
 	if err := syscall.Chroot(common.Host); err != nil {
 		terminateOnError(fmt.Errorf("failed to chroot to %s, err: %w", common.Host, err))
@@ -106,7 +132,7 @@ func main() {
 	if err != nil {
 		terminateOnError(err)
 	}
-	if err := workload.Precache(precacheSpec, authFile, bestEffort); err != nil {
+	if err := workload.Precache(precacheSpec, additionalSpec, authFile, bestEffort); err != nil {
 		terminateOnError(err)
 	}
 }